@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// QueryEvent describes a statement run through QueryContext, QueryRowContext, ExecContext or ExecAsync.
+type QueryEvent struct {
+	// Query is the statement as sent to SQLite, including the annotation comment.
+	Query string
+	Args  []any
+	// Annotations are the values attached to the context with Annotate.
+	Annotations map[string]string
+	// Duration is the time until the statement returned, excluding iteration over rows.
+	Duration time.Duration
+	Err      error
+}
+
+type annotationsKey struct{}
+
+// Annotate returns a context carrying key=value, such as a request or user ID. Statements run with
+// the context get the annotations appended as an SQL comment, so they show up wherever SQL text is
+// logged, and are passed to the hook set with WithQueryHook. Statements run directly on a *sql.Tx
+// are not annotated.
+func Annotate(ctx context.Context, key, value string) context.Context {
+	annotations := maps.Clone(annotationsFromContext(ctx))
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = value
+	return context.WithValue(ctx, annotationsKey{}, annotations)
+}
+
+func annotationsFromContext(ctx context.Context) map[string]string {
+	annotations, _ := ctx.Value(annotationsKey{}).(map[string]string)
+	return annotations
+}
+
+// annotateQuery appends the annotations in ctx to query as a comment in the sqlcommenter format,
+// e.g. "SELECT 1 /*request_id='abc',user_id='42'*/". Values are URL-encoded so they cannot close the comment.
+func annotateQuery(ctx context.Context, query string) string {
+	annotations := annotationsFromContext(ctx)
+	if len(annotations) == 0 {
+		return query
+	}
+
+	pairs := make([]string, 0, len(annotations))
+	for _, key := range slices.Sorted(maps.Keys(annotations)) {
+		pairs = append(pairs, url.QueryEscape(key)+"='"+url.QueryEscape(annotations[key])+"'")
+	}
+
+	return query + " /*" + strings.Join(pairs, ",") + "*/"
+}
+
+// WithQueryHook calls hook after every statement run through QueryContext, QueryRowContext,
+// ExecContext or ExecAsync, e.g. for logging or tracing slow queries.
+func WithQueryHook(hook func(ctx context.Context, event QueryEvent)) Option {
+	return func(c *config) {
+		c.queryHook = hook
+	}
+}
+
+// observe reports a finished statement to the query hook.
+func (d *DB) observe(ctx context.Context, query string, args []any, start time.Time, err error) *queryStat {
+	duration := time.Since(start)
+
+	cfg := d.config.Load()
+	var stat *queryStat
+	if cfg.queryStats {
+		stat = d.stats.record(query, duration, err)
+	}
+
+	if cfg.queryHook != nil {
+		cfg.queryHook(ctx, QueryEvent{
+			Query:       query,
+			Args:        args,
+			Annotations: annotationsFromContext(ctx),
+			Duration:    duration,
+			Err:         err,
+		})
+	}
+	return stat
+}
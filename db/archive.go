@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ArchiveOptions controls how Archive moves rows out of a hot table.
+type ArchiveOptions struct {
+	// Archive is the destination table, "<table>_archive" by default. It is created with the
+	// columns of the source table if it does not exist.
+	Archive string
+	// AttachPath is an optional database file holding the archive table. It is attached as the
+	// schema "archive" for the duration of the call and created if it does not exist.
+	AttachPath string
+	// BatchSize is the number of rows moved per transaction, 1000 by default.
+	BatchSize int
+	// Progress is called after each committed batch with the total number of rows moved so far.
+	Progress func(moved int64)
+}
+
+// Archive moves the rows of table whose column is before cutoff into an archive table, in batches
+// of one transaction each so writers are never blocked for long. Rows are moved in rowid order,
+// so the archive keeps them in insertion order. It returns the number of rows moved; when ctx is
+// cancelled, the batches committed so far stay moved. With the write queue enabled, each batch
+// waits for its turn in the queue.
+//
+//	moved, err := db.Archive(ctx, "events", "created_at", time.Now().AddDate(0, -3, 0), db.ArchiveOptions{
+//		AttachPath: "./data/events-archive.db",
+//	})
+func Archive(ctx context.Context, table, column string, cutoff any, opts ArchiveOptions) (int64, error) {
+	return defaultDB().Archive(ctx, table, column, cutoff, opts)
+}
+
+// Archive moves rows older than cutoff into an archive table, see the package-level Archive.
+func (d *DB) Archive(ctx context.Context, table, column string, cutoff any, opts ArchiveOptions) (int64, error) {
+	if err := d.checkOpen(); err != nil {
+		return 0, err
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	archive := opts.Archive
+	if archive == "" {
+		archive = table + "_archive"
+	}
+	archiveRef := quoteIdent(archive)
+	if opts.AttachPath != "" {
+		archiveRef = "archive." + archiveRef
+	}
+
+	run, release, err := d.archiveConn(ctx, opts.AttachPath)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	var columns string
+	err = run(func(conn *sql.Conn) error {
+		if columns, err = archiveColumns(ctx, conn, table); err != nil {
+			return err
+		}
+		create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s AS SELECT %s FROM %s WHERE 0", archiveRef, columns, quoteIdent(table))
+		if _, err := conn.ExecContext(ctx, create); err != nil {
+			return fmt.Errorf("failed to create archive table %s: %w", archive, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	batch := fmt.Sprintf("SELECT rowid FROM %s WHERE %s < ? ORDER BY rowid LIMIT ?", quoteIdent(table), quoteIdent(column))
+	copyRows := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s WHERE rowid IN (%s)", archiveRef, columns, columns, quoteIdent(table), batch)
+	deleteRows := fmt.Sprintf("DELETE FROM %s WHERE rowid IN (%s)", quoteIdent(table), batch)
+
+	var moved int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return moved, err
+		}
+
+		var n int64
+		err := run(func(conn *sql.Conn) error {
+			var err error
+			n, err = archiveBatch(ctx, conn, copyRows, deleteRows, cutoff, batchSize)
+			return err
+		})
+		if err != nil {
+			return moved, err
+		}
+		if n == 0 {
+			return moved, nil
+		}
+
+		moved += n
+		if opts.Progress != nil {
+			opts.Progress(moved)
+		}
+		if n < int64(batchSize) {
+			return moved, nil
+		}
+	}
+}
+
+// archiveConn returns a function running steps of Archive on a connection with the database at
+// attachPath, if any, attached as "archive", and a function releasing the connection. ATTACH
+// applies to a single connection, so all steps run on the same one: with the write queue enabled,
+// each step is a job on the writer connection, attached for the step only; otherwise a connection
+// is reserved for the call.
+func (d *DB) archiveConn(ctx context.Context, attachPath string) (run func(fn func(conn *sql.Conn) error) error, release func(), err error) {
+	attach := func(conn *sql.Conn) (detach func(), err error) {
+		if attachPath == "" {
+			return func() {}, nil
+		}
+		if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS archive", attachPath); err != nil {
+			return nil, fmt.Errorf("failed to attach archive database: %w", err)
+		}
+		// Detach even if ctx was cancelled, so the connection is returned clean.
+		return func() { conn.ExecContext(context.WithoutCancel(ctx), "DETACH DATABASE archive") }, nil
+	}
+
+	if w := d.queue(); w != nil {
+		run = func(fn func(conn *sql.Conn) error) error {
+			future := w.submit(ctx, func(ctx context.Context, conn *sql.Conn) (sql.Result, error) {
+				detach, err := attach(conn)
+				if err != nil {
+					return nil, err
+				}
+				defer detach()
+				return nil, fn(conn)
+			})
+			// Wait for the job itself, it observes ctx on its own.
+			<-future.Done()
+			return future.err
+		}
+		return run, func() {}, nil
+	}
+
+	conn, err := d.pool.Load().Conn(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	detach, err := attach(conn)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	run = func(fn func(conn *sql.Conn) error) error { return fn(conn) }
+	return run, func() { detach(); conn.Close() }, nil
+}
+
+// archiveColumns returns the quoted, comma-separated column list of table.
+func archiveColumns(ctx context.Context, conn *sql.Conn, table string) (string, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return "", fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+
+	var columns []string
+	for column, err := range ScanAll[string](rows) {
+		if err != nil {
+			return "", fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+		columns = append(columns, quoteIdent(column))
+	}
+	if len(columns) == 0 {
+		return "", fmt.Errorf("table %s does not exist", table)
+	}
+
+	return strings.Join(columns, ", "), nil
+}
+
+// archiveBatch copies one batch into the archive and deletes it from the source table in a single transaction.
+func archiveBatch(ctx context.Context, conn *sql.Conn, copyRows, deleteRows string, cutoff any, batchSize int) (int64, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, copyRows, cutoff, batchSize); err != nil {
+		return 0, fmt.Errorf("failed to copy rows to archive: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, deleteRows, cutoff, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete archived rows: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count archived rows: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// querier is a *DB or *sql.DB.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// ids returns the id column of table in rowid order.
+func ids(tb testing.TB, conn querier, table string) []int {
+	tb.Helper()
+	rows, err := conn.QueryContext(context.Background(), fmt.Sprintf("SELECT id FROM %s ORDER BY rowid", table))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var ids []int
+	for id, err := range ScanAll[int](rows) {
+		if err != nil {
+			tb.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestArchive(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		attach bool
+		opts   []Option
+	}{
+		{name: "same database"},
+		{name: "attached", attach: true},
+		{name: "attached with write queue", attach: true, opts: []Option{WithWriteQueue(16)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := openTestDB(t, tt.opts...)
+			mustExec(t, d, "CREATE TABLE events (id INTEGER, created_at INTEGER, payload TEXT)")
+			// Ids descend in insertion order, which the archive must keep.
+			for i := 1; i <= 25; i++ {
+				mustExec(t, d, fmt.Sprintf("INSERT INTO events VALUES (%d, %d, 'p')", 100-i, i))
+			}
+
+			opts := ArchiveOptions{BatchSize: 7}
+			var progress []int64
+			opts.Progress = func(moved int64) { progress = append(progress, moved) }
+			if tt.attach {
+				opts.AttachPath = filepath.Join(t.TempDir(), "archive.db")
+			}
+			moved, err := d.Archive(ctx, "events", "created_at", 20, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if moved != 19 || !slices.Equal(progress, []int64{7, 14, 19}) {
+				t.Errorf("moved %d with progress %v, want 19 with [7 14 19]", moved, progress)
+			}
+
+			var wantArchived, wantKept []int
+			for i := 1; i <= 25; i++ {
+				if i < 20 {
+					wantArchived = append(wantArchived, 100-i)
+				} else {
+					wantKept = append(wantKept, 100-i)
+				}
+			}
+			if got := ids(t, d, "events"); !slices.Equal(got, wantKept) {
+				t.Errorf("source has %v, want %v", got, wantKept)
+			}
+
+			var archive querier = d
+			if tt.attach {
+				var n int
+				if err := d.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE name = 'events_archive'").Scan(&n); err != nil || n != 0 {
+					t.Errorf("archive table created in the source database (%v)", err)
+				}
+				conn, err := sql.Open("sqlite", opts.AttachPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer conn.Close()
+				archive = conn
+			}
+			if got := ids(t, archive, "events_archive"); !slices.Equal(got, wantArchived) {
+				t.Errorf("archive has %v, want %v", got, wantArchived)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+)
+
+// auditSchema creates the audit log and the single-row table holding the actor of the
+// current write transaction. Triggers read the actor from there, since they cannot see the Go context.
+const auditSchema = `
+CREATE TABLE IF NOT EXISTS _audit_log (
+	id INTEGER PRIMARY KEY,
+	table_name TEXT NOT NULL,
+	row_id INTEGER NOT NULL,
+	operation TEXT NOT NULL,
+	actor TEXT,
+	old_values TEXT,
+	new_values TEXT,
+	created_at DATETIME NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX IF NOT EXISTS _audit_log_row ON _audit_log (table_name, row_id, id);
+CREATE TABLE IF NOT EXISTS _audit_actor (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	actor TEXT
+);
+`
+
+// AuditEntry is a single recorded change of an audited row.
+type AuditEntry struct {
+	ID        int64           `db:"id"`
+	Table     string          `db:"table_name"`
+	RowID     int64           `db:"row_id"`
+	Operation string          `db:"operation"`  // INSERT, UPDATE or DELETE
+	Actor     string          `db:"actor"`      // empty when no actor was set
+	OldValues json.RawMessage `db:"old_values"` // nil for INSERT
+	NewValues json.RawMessage `db:"new_values"` // nil for DELETE
+	CreatedAt time.Time       `db:"created_at"`
+}
+
+type actorKey struct{}
+
+// WithActor returns a context whose writes are attributed to actor in the audit log.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func actorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	return actor, ok
+}
+
+// EnableAudit records every INSERT, UPDATE and DELETE on the given tables into the _audit_log table,
+// including the old and new column values as JSON and the actor set with WithActor.
+// Changes are captured by triggers, so they are recorded regardless of how the table is written.
+// Call it on every startup, after migrations: it is idempotent and recreates the triggers
+// so they pick up schema changes. BLOB values are recorded hex encoded.
+func EnableAudit(ctx context.Context, tables ...string) error {
+	return defaultDB().EnableAudit(ctx, tables...)
+}
+
+// EnableAudit records changes on the given tables, see the package-level EnableAudit.
+func (d *DB) EnableAudit(ctx context.Context, tables ...string) error {
+	err := d.WithTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, auditSchema); err != nil {
+			return fmt.Errorf("failed to create audit tables: %w", err)
+		}
+
+		for _, table := range tables {
+			if err := createAuditTriggers(ctx, tx, table); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	d.audit.Store(true)
+	return nil
+}
+
+func createAuditTriggers(ctx context.Context, tx *sql.Tx, table string) error {
+	rows, err := tx.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+
+	var columns []string
+	for column, err := range ScanAll[string](rows) {
+		if err != nil {
+			return fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("table %s does not exist", table)
+	}
+
+	for _, op := range []string{"INSERT", "UPDATE", "DELETE"} {
+		trigger := quoteIdent("_audit_" + table + "_" + strings.ToLower(op))
+
+		oldValues, newValues, rowID := "NULL", "NULL", "NEW.rowid"
+		if op != "INSERT" {
+			oldValues = auditJSON("OLD", columns)
+		}
+		if op != "DELETE" {
+			newValues = auditJSON("NEW", columns)
+		} else {
+			rowID = "OLD.rowid"
+		}
+
+		if _, err := tx.ExecContext(ctx, "DROP TRIGGER IF EXISTS "+trigger); err != nil {
+			return fmt.Errorf("failed to drop audit trigger on %s: %w", table, err)
+		}
+
+		create := fmt.Sprintf(`CREATE TRIGGER %s AFTER %s ON %s BEGIN
+	INSERT INTO _audit_log (table_name, row_id, operation, actor, old_values, new_values)
+	VALUES ('%s', %s, '%s', (SELECT actor FROM _audit_actor WHERE id = 1), %s, %s);
+END`, trigger, op, quoteIdent(table), strings.ReplaceAll(table, "'", "''"), rowID, op, oldValues, newValues)
+		if _, err := tx.ExecContext(ctx, create); err != nil {
+			return fmt.Errorf("failed to create audit trigger on %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// auditJSON builds a json_object expression over the columns of the OLD or NEW row.
+func auditJSON(row string, columns []string) string {
+	args := make([]string, 0, len(columns))
+	for _, column := range columns {
+		ref := row + "." + quoteIdent(column)
+		// JSON cannot hold BLOB values.
+		value := fmt.Sprintf("CASE WHEN typeof(%s) = 'blob' THEN hex(%s) ELSE %s END", ref, ref, ref)
+		args = append(args, fmt.Sprintf("'%s', %s", strings.ReplaceAll(column, "'", "''"), value))
+	}
+	return "json_object(" + strings.Join(args, ", ") + ")"
+}
+
+// setActor attributes the writes of tx to the actor in ctx. clearActor must be called before commit,
+// so the actor never outlives the transaction. SQLite allows a single writer at a time, so triggers
+// always see the actor of the transaction that fired them.
+func setActor(ctx context.Context, tx *sql.Tx) error {
+	actor, ok := actorFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT OR REPLACE INTO _audit_actor (id, actor) VALUES (1, ?)", actor); err != nil {
+		return fmt.Errorf("failed to set audit actor: %w", err)
+	}
+	return nil
+}
+
+func clearActor(ctx context.Context, tx *sql.Tx) error {
+	if _, ok := actorFromContext(ctx); !ok {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM _audit_actor"); err != nil {
+		return fmt.Errorf("failed to clear audit actor: %w", err)
+	}
+	return nil
+}
+
+// AuditHistory returns the recorded changes of a row, oldest first.
+func AuditHistory(ctx context.Context, table string, rowid int64) iter.Seq2[AuditEntry, error] {
+	return defaultDB().AuditHistory(ctx, table, rowid)
+}
+
+// AuditHistory returns the recorded changes of a row, see the package-level AuditHistory.
+func (d *DB) AuditHistory(ctx context.Context, table string, rowid int64) iter.Seq2[AuditEntry, error] {
+	rows, err := d.QueryContext(ctx, "SELECT * FROM _audit_log WHERE table_name = ? AND row_id = ? ORDER BY id", table, rowid)
+	if err != nil {
+		return func(yield func(AuditEntry, error) bool) {
+			yield(AuditEntry{}, fmt.Errorf("failed to query audit log: %w", err))
+		}
+	}
+	return ScanAll[AuditEntry](rows)
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxBlobSize is the largest value WriteBlob writes, as the value is held in memory whole.
+const maxBlobSize = 64 << 20 // 64MB
+
+// ErrBlobTooLarge is returned by WriteBlob for content larger than 64MB.
+var ErrBlobTooLarge = errors.New("blob exceeds 64MB")
+
+// WriteBlob reads r to the end and stores its content in the BLOB column of the row identified by
+// rowid with a single UPDATE, replacing any existing value, within the WithTx transaction of ctx if
+// there is one. Returns the number of bytes written.
+// Returns sql.ErrNoRows if the row does not exist.
+//
+// Streaming is not supported: the driver does not expose SQLite's incremental blob I/O, so the
+// content is held in memory whole. Content over 64MB fails with ErrBlobTooLarge without reading
+// further; store larger payloads in files or in several rows.
+func WriteBlob(ctx context.Context, table, column string, rowid int64, r io.Reader) (int64, error) {
+	return defaultDB().WriteBlob(ctx, table, column, rowid, r)
+}
+
+// WriteBlob stores the content of r in the BLOB column of the row identified by rowid, see the package-level WriteBlob.
+func (d *DB) WriteBlob(ctx context.Context, table, column string, rowid int64, r io.Reader) (int64, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxBlobSize+1))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read blob data: %w", err)
+	}
+	if len(data) > maxBlobSize {
+		return 0, ErrBlobTooLarge
+	}
+
+	err = d.WithTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE rowid = ?", quoteIdent(table), quoteIdent(column))
+		res, err := tx.ExecContext(ctx, query, data, rowid)
+		if err != nil {
+			return fmt.Errorf("failed to write blob: %w", err)
+		}
+		if n, err := res.RowsAffected(); err == nil && n == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
+}
+
+// ReadBlob writes the BLOB column of the row identified by rowid to w.
+// Returns the number of bytes written to w.
+// Returns sql.ErrNoRows if the row does not exist. A NULL value writes nothing.
+//
+// Like WriteBlob, it does not stream: the value is read with a single query and held in memory whole.
+func ReadBlob(ctx context.Context, table, column string, rowid int64, w io.Writer) (int64, error) {
+	return defaultDB().ReadBlob(ctx, table, column, rowid, w)
+}
+
+// ReadBlob writes the BLOB column of the row identified by rowid to w, see the package-level ReadBlob.
+func (d *DB) ReadBlob(ctx context.Context, table, column string, rowid int64, w io.Writer) (int64, error) {
+	if err := d.checkOpen(); err != nil {
+		return 0, err
+	}
+
+	var data []byte
+	query := fmt.Sprintf("SELECT CAST(%s AS BLOB) FROM %s WHERE rowid = ?", quoteIdent(column), quoteIdent(table))
+	if err := d.pool.Load().QueryRowContext(ctx, query, rowid).Scan(&data); err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(data)
+	if err != nil {
+		return int64(n), fmt.Errorf("failed to write blob data: %w", err)
+	}
+	return int64(n), nil
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBlob(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+	mustExec(t, d, "CREATE TABLE files (data BLOB)", "INSERT INTO files VALUES (NULL)")
+
+	var buf bytes.Buffer
+	if n, err := d.ReadBlob(ctx, "files", "data", 1, &buf); err != nil || n != 0 {
+		t.Fatalf("ReadBlob of NULL wrote %d bytes, error %v", n, err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", []byte{}},
+		{"small", []byte("hello")},
+		{"large", bytes.Repeat([]byte("0123456789"), 250_000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := d.WriteBlob(ctx, "files", "data", 1, bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(len(tt.data)) {
+				t.Errorf("wrote %d bytes, want %d", n, len(tt.data))
+			}
+
+			var typ string
+			if err := d.QueryRowContext(ctx, "SELECT typeof(data) FROM files").Scan(&typ); err != nil || typ != "blob" {
+				t.Errorf("stored a value of type %q, error %v", typ, err)
+			}
+
+			var buf bytes.Buffer
+			if _, err := d.ReadBlob(ctx, "files", "data", 1, &buf); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), tt.data) {
+				t.Errorf("read %d bytes that differ from the %d written", buf.Len(), len(tt.data))
+			}
+		})
+	}
+
+	if _, err := d.WriteBlob(ctx, "files", "data", 2, strings.NewReader("x")); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("WriteBlob of a missing row returned %v, want sql.ErrNoRows", err)
+	}
+	if _, err := d.ReadBlob(ctx, "files", "data", 2, &buf); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("ReadBlob of a missing row returned %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestWriteBlobTooLarge(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+	mustExec(t, d, "CREATE TABLE files (data BLOB)", "INSERT INTO files VALUES (X'01')")
+
+	r := &countingReader{limit: maxBlobSize * 2}
+	if _, err := d.WriteBlob(ctx, "files", "data", 1, r); !errors.Is(err, ErrBlobTooLarge) {
+		t.Fatalf("got %v, want ErrBlobTooLarge", err)
+	}
+	if r.read > maxBlobSize+1 {
+		t.Errorf("read %d bytes, more than the limit", r.read)
+	}
+
+	var data []byte
+	if err := d.QueryRowContext(ctx, "SELECT data FROM files").Scan(&data); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte{1}) {
+		t.Errorf("blob is %d bytes after the failed write, want the original", len(data))
+	}
+}
+
+// countingReader reads up to limit zero bytes, counting them.
+type countingReader struct {
+	limit, read int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n := int(min(int64(len(p)), r.limit-r.read))
+	if n == 0 {
+		return 0, io.EOF
+	}
+	clear(p[:n])
+	r.read += int64(n)
+	return n, nil
+}
@@ -0,0 +1,315 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCacheEntries is the number of cached results kept unless set with WithQueryCache.
+const defaultCacheEntries = 1000
+
+// queryCache holds the results of QueryCached, indexed by the tables they were read from.
+type queryCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	tables  map[string]map[string]struct{} // table → keys of entries reading it
+
+	// gen counts invalidations, so a result read before one is not stored after it.
+	gen uint64
+	// linked holds the tables whose writes may change other tables through triggers or foreign key
+	// actions, loaded on the first write and dropped with the whole cache, e.g. by DDL.
+	linked map[string]bool
+}
+
+type cacheEntry struct {
+	value   any // []T
+	expires time.Time
+	tables  []string
+}
+
+// QueryCached runs query like QueryContext and scans all rows into a slice like ScanAll, caching the
+// result for ttl under the query, its args and T. tables names the tables the query reads; their cached
+// results are dropped by InvalidateCache and by every successful write through ExecContext or ExecAsync
+// to one of them. Writes to tables with triggers or with foreign keys referencing them that cascade,
+// set NULL or set a default, which may change other tables as well, drop the whole cache:
+//
+//	users, err := db.QueryCached[User](ctx, time.Minute, []string{"users"}, "SELECT * FROM users WHERE active = ?", true)
+//
+// Writes made directly on a *sql.Tx are not seen, call InvalidateCache after such transactions.
+// Every caller gets its own copy of the slice, but the elements are shared, so pointer fields
+// must not be modified.
+func QueryCached[T any](ctx context.Context, ttl time.Duration, tables []string, query string, args ...any) ([]T, error) {
+	return QueryCachedOn[T](ctx, defaultDB(), ttl, tables, query, args...)
+}
+
+// QueryCachedOn is QueryCached on the database d, e.g. one obtained with Use.
+func QueryCachedOn[T any](ctx context.Context, d *DB, ttl time.Duration, tables []string, query string, args ...any) ([]T, error) {
+	var zero T
+	key := cacheKey(reflect.TypeOf(&zero).Elem(), query, args)
+
+	value, gen, ok := d.cache.get(key)
+	if ok {
+		if result, ok := value.([]T); ok {
+			return slices.Clone(result), nil
+		}
+	}
+
+	rows, err := d.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []T{}
+	for row, err := range ScanAll[T](rows) {
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+
+	d.cache.put(key, gen, result, ttl, tables, d.config.Load().cacheEntries)
+	return slices.Clone(result), nil
+}
+
+// InvalidateCache drops the cached results of QueryCached reading any of tables.
+// Without tables, the whole cache is dropped.
+func InvalidateCache(tables ...string) {
+	defaultDB().InvalidateCache(tables...)
+}
+
+// InvalidateCache drops cached results, see the package-level InvalidateCache.
+func (d *DB) InvalidateCache(tables ...string) {
+	d.cache.invalidate(tables...)
+}
+
+// cacheKey identifies a result by the scanned type, the query and its args.
+func cacheKey(t reflect.Type, query string, args []any) string {
+	var b strings.Builder
+	b.WriteString(t.String())
+	b.WriteByte(0)
+	b.WriteString(query)
+	for _, arg := range args {
+		arg = cacheArg(arg)
+		fmt.Fprintf(&b, "\x00%T:%v", arg, arg)
+	}
+	return b.String()
+}
+
+// cacheArg returns the value an arg is bound as, so args are keyed by value rather than by the
+// address of pointers: the value of a driver.Valuer and the value pointers point to, nil for nil.
+func cacheArg(arg any) any {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		if v := reflect.ValueOf(arg); v.Kind() == reflect.Pointer && v.IsNil() {
+			return nil
+		}
+		if value, err := valuer.Value(); err == nil {
+			return value
+		}
+		return arg
+	}
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// get returns the cached value of key and the current generation to pass to put on a miss.
+func (c *queryCache) get(key string) (any, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, c.gen, false
+	}
+	return entry.value, c.gen, true
+}
+
+// put stores value under key unless the cache was invalidated since generation gen.
+func (c *queryCache) put(key string, gen uint64, value any, ttl time.Duration, tables []string, maxEntries int) {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheEntries
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.gen != gen {
+		return
+	}
+
+	if c.entries == nil {
+		c.entries = map[string]cacheEntry{}
+		c.tables = map[string]map[string]struct{}{}
+	}
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= maxEntries {
+		c.evict(maxEntries)
+	}
+
+	entry := cacheEntry{value: value, expires: time.Now().Add(ttl)}
+	for _, table := range tables {
+		table = strings.ToLower(table)
+		entry.tables = append(entry.tables, table)
+		if c.tables[table] == nil {
+			c.tables[table] = map[string]struct{}{}
+		}
+		c.tables[table][key] = struct{}{}
+	}
+	c.entries[key] = entry
+}
+
+// evict makes room for a new entry, dropping expired entries or else an arbitrary one.
+func (c *queryCache) evict(maxEntries int) {
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			c.remove(key)
+		}
+	}
+	for key := range c.entries {
+		if len(c.entries) < maxEntries {
+			break
+		}
+		c.remove(key)
+	}
+}
+
+func (c *queryCache) remove(key string) {
+	for _, table := range c.entries[key].tables {
+		delete(c.tables[table], key)
+		if len(c.tables[table]) == 0 {
+			delete(c.tables, table)
+		}
+	}
+	delete(c.entries, key)
+}
+
+func (c *queryCache) invalidate(tables ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if len(tables) == 0 {
+		c.entries = nil
+		c.tables = nil
+		c.linked = nil
+		return
+	}
+	for _, table := range tables {
+		for key := range c.tables[strings.ToLower(table)] {
+			c.remove(key)
+		}
+	}
+}
+
+// invalidateWrite drops the cached results affected by a successful write statement. The written
+// table is taken from INSERT, REPLACE, UPDATE and DELETE statements; any other statement, such as
+// DDL or multiple statements, drops the whole cache, as does a write to one of the tables returned
+// by loadLinked, which may change others through triggers or foreign key actions.
+func (c *queryCache) invalidateWrite(query string, loadLinked func() (map[string]bool, error)) {
+	table, ok := writtenTable(query)
+	if !ok || c.isLinked(table, loadLinked) {
+		c.invalidate()
+		return
+	}
+	c.invalidate(table)
+}
+
+// isLinked reports whether writes to table may change other tables, loading the linked tables with
+// load if they are not known. A table is taken as linked if they cannot be loaded.
+func (c *queryCache) isLinked(table string, load func() (map[string]bool, error)) bool {
+	c.mu.Lock()
+	linked, gen := c.linked, c.gen
+	c.mu.Unlock()
+	if linked != nil {
+		return linked[table]
+	}
+
+	linked, err := load()
+	if err != nil {
+		return true
+	}
+	c.mu.Lock()
+	// The schema may have changed while loading.
+	if c.gen == gen {
+		c.linked = linked
+	}
+	c.mu.Unlock()
+	return linked[table]
+}
+
+// linkedTables returns the tables of the database whose writes may change other tables: those with
+// triggers and those referenced by foreign keys with an action other than NO ACTION or RESTRICT.
+func (d *DB) linkedTables() (map[string]bool, error) {
+	rows, err := d.pool.Load().Query(`SELECT tbl_name FROM sqlite_master WHERE type = 'trigger'
+		UNION SELECT f."table" FROM sqlite_master m, pragma_foreign_key_list(m.name) f
+		WHERE m.type = 'table' AND (f.on_delete NOT IN ('NO ACTION', 'RESTRICT') OR f.on_update NOT IN ('NO ACTION', 'RESTRICT'))`)
+	if err != nil {
+		return nil, err
+	}
+	linked := map[string]bool{}
+	for table, err := range ScanAll[string](rows) {
+		if err != nil {
+			return nil, err
+		}
+		linked[strings.ToLower(table)] = true
+	}
+	return linked, nil
+}
+
+// writtenTable returns the table written by a single INSERT, REPLACE, UPDATE or DELETE statement.
+func writtenTable(query string) (string, bool) {
+	// Strip the annotation comment and a trailing semicolon before looking for more statements.
+	query, _, _ = strings.Cut(query, "/*")
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	if strings.Contains(query, ";") {
+		return "", false
+	}
+
+	words := strings.Fields(query)
+	if len(words) < 2 {
+		return "", false
+	}
+
+	switch strings.ToUpper(words[0]) {
+	case "INSERT", "REPLACE", "DELETE":
+		// INSERT [OR ...] INTO t, REPLACE INTO t, DELETE FROM t
+		for i, word := range words[:len(words)-1] {
+			if strings.EqualFold(word, "INTO") || strings.EqualFold(word, "FROM") {
+				return tableName(words[i+1]), true
+			}
+		}
+	case "UPDATE":
+		// UPDATE [OR ...] t
+		if strings.EqualFold(words[1], "OR") {
+			if len(words) < 4 {
+				return "", false
+			}
+			return tableName(words[3]), true
+		}
+		return tableName(words[1]), true
+	}
+	return "", false
+}
+
+// tableName strips quoting, a schema qualifier and a column list from a table reference.
+func tableName(ref string) string {
+	ref, _, _ = strings.Cut(ref, "(")
+	if i := strings.LastIndexByte(ref, '.'); i >= 0 {
+		ref = ref[i+1:]
+	}
+	return strings.ToLower(strings.Trim(ref, "\"`[]"))
+}
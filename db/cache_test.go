@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWrittenTable(t *testing.T) {
+	tests := []struct {
+		query string
+		table string
+		ok    bool
+	}{
+		{"INSERT INTO users (name) VALUES (?)", "users", true},
+		{"insert or replace into \"Users\"(name) values (?)", "users", true},
+		{"REPLACE INTO main.users VALUES (?)", "users", true},
+		{"UPDATE users SET name = ?", "users", true},
+		{"UPDATE OR IGNORE users SET name = ?", "users", true},
+		{"DELETE FROM users WHERE id = ?; ", "users", true},
+		{"DELETE FROM users /* app=api */", "users", true},
+		{"DELETE FROM users; DELETE FROM orders", "", false},
+		{"CREATE TABLE users (id INTEGER)", "", false},
+	}
+	for _, tt := range tests {
+		table, ok := writtenTable(tt.query)
+		if table != tt.table || ok != tt.ok {
+			t.Errorf("writtenTable(%q) = %q, %v, want %q, %v", tt.query, table, ok, tt.table, tt.ok)
+		}
+	}
+}
+
+func TestQueryCached(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+	mustExec(t, d,
+		"CREATE TABLE parents (id INTEGER PRIMARY KEY)",
+		"CREATE TABLE children (id INTEGER, parent INTEGER REFERENCES parents(id) ON DELETE CASCADE)",
+		"CREATE TABLE other (id INTEGER)",
+		"PRAGMA foreign_keys = ON",
+		"INSERT INTO parents VALUES (1)",
+		"INSERT INTO children VALUES (1, 1), (2, 1)",
+	)
+	children := func(min *int) []int {
+		t.Helper()
+		ids, err := QueryCachedOn[int](ctx, d, time.Minute, []string{"children"}, "SELECT id FROM children WHERE id >= ? ORDER BY id", min)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ids
+	}
+
+	min := 1
+	if got := children(&min); !slices.Equal(got, []int{1, 2}) {
+		t.Fatalf("got %v, want [1 2]", got)
+	}
+	// Args are keyed by the values pointers point to.
+	min = 2
+	if got := children(&min); !slices.Equal(got, []int{2}) {
+		t.Errorf("got %v after changing the pointed to arg, want [2]", got)
+	}
+
+	// A write to another table keeps the result, a write to the table drops it.
+	mustExec(t, d, "INSERT INTO children VALUES (3, 1)")
+	if _, err := d.ExecContext(ctx, "INSERT INTO other VALUES (1)"); err != nil {
+		t.Fatal(err)
+	}
+	if got := children(&min); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("got %v after a write to the table, want [2 3]", got)
+	}
+
+	// Writes cascading to the table drop it as well.
+	mustExec(t, d, "DELETE FROM parents")
+	if got := children(&min); len(got) != 0 {
+		t.Errorf("got %v after a cascading delete, want none", got)
+	}
+}
+
+func TestQueryCachedReopened(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	for _, file := range []string{"first", "second"} {
+		// Written outside the handle, so no write through it drops the cache.
+		conn, err := sql.Open("sqlite", filepath.Join(dir, file+".db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.Exec("CREATE TABLE t (v TEXT); INSERT INTO t VALUES ('" + file + "')"); err != nil {
+			t.Fatal(err)
+		}
+		conn.Close()
+	}
+
+	for _, file := range []string{"first", "second"} {
+		closeDB, err := InitNamed(t.Name(), WithPath(filepath.Join(dir, file+".db")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := QueryCachedOn[string](ctx, Use(t.Name()), time.Minute, []string{"t"}, "SELECT v FROM t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, []string{file}) {
+			t.Errorf("got %v from %s, want [%s]", got, file, file)
+		}
+		if err := closeDB(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// drainPollInterval is how often drain checks for in-flight work.
+const drainPollInterval = 10 * time.Millisecond
+
+// drain stops the maintenance scheduler, releases held locks, stops the write queue and waits until
+// queued writes are done and no connections are in use, which covers running statements, unclosed
+// rows and open transactions. It gives up after timeout.
+func (d *DB) drain(timeout time.Duration) error {
+	d.stopMaintenance()
+	// Locks are released first, so no other process waits for them to expire.
+	stopErr := d.releaseLocks()
+
+	d.writerMu.Lock()
+	w := d.writer
+	d.writer = nil
+	d.writerMu.Unlock()
+
+	var stopped chan error
+	if w != nil {
+		stopped = make(chan error, 1)
+		go func() { stopped <- w.stop() }()
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		if stopped != nil {
+			select {
+			case err := <-stopped:
+				stopped = nil
+				if err != nil {
+					stopErr = errors.Join(stopErr, fmt.Errorf("failed to stop write queue: %w", err))
+				}
+			default:
+			}
+		}
+
+		n := d.inUse()
+		if n == 0 && stopped == nil {
+			return stopErr
+		}
+		if time.Now().After(deadline) {
+			if stopped != nil {
+				return errors.Join(stopErr, fmt.Errorf("timed out after %v waiting for queued writes", timeout))
+			}
+			return errors.Join(stopErr, fmt.Errorf("timed out after %v waiting for %d in-flight connections", timeout, n))
+		}
+		time.Sleep(drainPollInterval)
+	}
+}
+
+// inUse returns the number of connections currently in use across all pools.
+func (d *DB) inUse() int {
+	n := d.pool.Load().Stats().InUse
+
+	d.txPoolsMu.Lock()
+	defer d.txPoolsMu.Unlock()
+	for _, pool := range d.txPools {
+		n += pool.Stats().InUse
+	}
+
+	return n
+}
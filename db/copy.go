@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// CopyOptions controls how CopyTable copies rows.
+type CopyOptions struct {
+	// BatchSize is the number of rows inserted per transaction on the destination, 1000 by default.
+	BatchSize int
+	// Progress is called after each committed batch with the total number of rows copied so far.
+	Progress func(copied int64)
+}
+
+// CopyTable streams all rows of table from src to dst, e.g. from an old file to a new one
+// when re-sharding. If dst has no such table, it is created along with its indexes from the
+// schema in src. Rows are inserted in batches of one transaction each and keep their primary
+// keys, so copying into a table that already holds some of them fails. It returns the number
+// of rows copied.
+func CopyTable(ctx context.Context, src, dst *DB, table string, opts CopyOptions) (int64, error) {
+	if err := src.checkOpen(); err != nil {
+		return 0, err
+	}
+	if err := dst.checkOpen(); err != nil {
+		return 0, err
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	if err := copySchema(ctx, src, dst, table); err != nil {
+		return 0, err
+	}
+
+	rows, err := src.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	var names, selects []string
+	for column, err := range ScanAll[string](rows) {
+		if err != nil {
+			return 0, fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+		names = append(names, quoteIdent(column))
+		// Unary + drops the declared type, so the driver returns stored values unconverted,
+		// e.g. DATETIME text stays text instead of becoming time.Time.
+		selects = append(selects, "+"+quoteIdent(column))
+	}
+
+	rows, err = src.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s", strings.Join(selects, ", "), quoteIdent(table)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdent(table), strings.Join(names, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", "))
+
+	var copied int64
+	batch := make([][]any, 0, batchSize)
+	flush := func() error {
+		err := dst.WithTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+			stmt, err := tx.PrepareContext(ctx, insert)
+			if err != nil {
+				return err
+			}
+			defer stmt.Close()
+
+			for _, values := range batch {
+				if _, err := stmt.ExecContext(ctx, values...); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to copy rows of %s: %w", table, err)
+		}
+
+		copied += int64(len(batch))
+		batch = batch[:0]
+		if opts.Progress != nil {
+			opts.Progress(copied)
+		}
+		return nil
+	}
+
+	for rows.Next() {
+		values := make([]any, len(names))
+		targets := make([]any, len(values))
+		for i := range values {
+			targets[i] = &values[i]
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return copied, fmt.Errorf("failed to read %s: %w", table, err)
+		}
+		for i, value := range values {
+			// The driver returns an empty BLOB as a nil slice, which would be written as NULL.
+			if b, ok := value.([]byte); ok && b == nil {
+				values[i] = []byte{}
+			}
+		}
+
+		batch = append(batch, values)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return copied, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return copied, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+
+	if len(batch) > 0 {
+		if err := flush(); err != nil {
+			return copied, err
+		}
+	}
+	return copied, nil
+}
+
+// copySchema creates table and its indexes in dst from their definitions in src, unless dst already has the table.
+func copySchema(ctx context.Context, src, dst *DB, table string) error {
+	var exists bool
+	err := dst.QueryRowContext(ctx, "SELECT count(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to read schema of %s: %w", table, err)
+	}
+	if exists {
+		return nil
+	}
+
+	rows, err := src.QueryContext(ctx, `SELECT sql FROM sqlite_master
+		WHERE tbl_name = ? AND type IN ('table', 'index') AND sql IS NOT NULL
+		ORDER BY type = 'table' DESC`, table)
+	if err != nil {
+		return fmt.Errorf("failed to read schema of %s: %w", table, err)
+	}
+
+	var statements []string
+	for statement, err := range ScanAll[string](rows) {
+		if err != nil {
+			return fmt.Errorf("failed to read schema of %s: %w", table, err)
+		}
+		statements = append(statements, statement)
+	}
+	if len(statements) == 0 {
+		return fmt.Errorf("table %s does not exist", table)
+	}
+
+	return dst.WithTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("failed to create %s: %w", table, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// dump returns the rows of table as SQL literals, which tell stored types apart.
+func dump(tb testing.TB, d *DB, table string) []string {
+	tb.Helper()
+	rows, err := d.QueryContext(context.Background(), "SELECT quote(id) || ',' || quote(created) || ',' || quote(flag) || ',' || quote(data) || ',' || quote(amount) || ',' || quote(code) FROM "+table+" ORDER BY id")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var values []string
+	for v, err := range ScanAll[string](rows) {
+		if err != nil {
+			tb.Fatal(err)
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestCopyTable(t *testing.T) {
+	ctx := context.Background()
+	src := openTestDB(t)
+	closeDst, err := InitNamed(t.Name()+"/dst", WithPath(filepath.Join(t.TempDir(), "dst.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDst()
+	dst := Use(t.Name() + "/dst")
+
+	mustExec(t, src,
+		"CREATE TABLE items (id INTEGER PRIMARY KEY, created DATETIME, flag BOOLEAN, data BLOB, amount REAL, code TEXT)",
+		"CREATE INDEX items_created ON items (created)",
+	)
+	// Values the driver would convert when scanning by declared type, and storage classes
+	// other than the declared affinity.
+	for _, values := range []string{
+		"1, '2024-01-02 03:04:05', 1, X'00ff', 1.5, '007'",
+		"2, '2024-01-02T03:04:05.123Z', 0, X'', 2, 'abc'",
+		"3, NULL, NULL, NULL, NULL, NULL",
+		"4, 1700000000, 'yes', 'text in blob', 'not a number', 12",
+		"5, 'not a date', 2, X'616263', -0.25, ''",
+	} {
+		mustExec(t, src, "INSERT INTO items VALUES ("+values+")")
+	}
+
+	var progress []int64
+	copied, err := CopyTable(ctx, src, dst, "items", CopyOptions{BatchSize: 2, Progress: func(n int64) { progress = append(progress, n) }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied != 5 || !slices.Equal(progress, []int64{2, 4, 5}) {
+		t.Errorf("copied %d with progress %v, want 5 with [2 4 5]", copied, progress)
+	}
+
+	want := dump(t, src, "items")
+	if got := dump(t, dst, "items"); !slices.Equal(got, want) {
+		t.Errorf("copied rows\n%v\nwant\n%v", got, want)
+	}
+
+	var index int
+	if err := dst.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type = 'index' AND name = 'items_created'").Scan(&index); err != nil || index != 1 {
+		t.Errorf("index not copied (%v)", err)
+	}
+
+	// Copying again collides on the primary keys.
+	if _, err := CopyTable(ctx, src, dst, "items", CopyOptions{}); err == nil {
+		t.Error("copying into a table holding the rows succeeded")
+	}
+	if _, err := CopyTable(ctx, src, dst, "missing", CopyOptions{}); err == nil {
+		t.Error("copying a missing table succeeded")
+	}
+}
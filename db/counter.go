@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+const countersSchema = `
+CREATE TABLE IF NOT EXISTS _counters (
+	name TEXT PRIMARY KEY,
+	value INTEGER NOT NULL
+)`
+
+// NextVal increments the counter name and returns its new value, starting at 1, like a sequence:
+//
+//	number, err := db.NextVal(ctx, "invoice_number")
+//
+// Called inside WithTx, the increment is part of the transaction and rolled back with it,
+// so numbers are gap-free; each number is handed out exactly once either way.
+func NextVal(ctx context.Context, name string) (int64, error) {
+	return defaultDB().NextVal(ctx, name)
+}
+
+// NextVal increments the counter name and returns its new value, see the package-level NextVal.
+func (d *DB) NextVal(ctx context.Context, name string) (int64, error) {
+	return d.IncrBy(ctx, name, 1)
+}
+
+// IncrBy adds delta to the counter name and returns its new value. Counters start at 0,
+// live in the _counters table created on first use, and may be decremented with a negative delta.
+func IncrBy(ctx context.Context, name string, delta int64) (int64, error) {
+	return defaultDB().IncrBy(ctx, name, delta)
+}
+
+// IncrBy adds delta to the counter name and returns its new value, see the package-level IncrBy.
+func (d *DB) IncrBy(ctx context.Context, name string, delta int64) (int64, error) {
+	var value int64
+	err := d.WithTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if !d.countersReady.Load() {
+			if _, err := tx.ExecContext(ctx, countersSchema); err != nil {
+				return fmt.Errorf("failed to create counters table: %w", err)
+			}
+		}
+
+		// A single upsert reads and writes the counter atomically.
+		err := tx.QueryRowContext(ctx, `INSERT INTO _counters (name, value) VALUES (?, ?)
+			ON CONFLICT (name) DO UPDATE SET value = value + excluded.value
+			RETURNING value`, name, delta).Scan(&value)
+		if err != nil {
+			return fmt.Errorf("failed to increment counter %s: %w", name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// An outer transaction may still roll back the table creation.
+	if _, nested := TxFromContext(ctx); !nested {
+		d.countersReady.Store(true)
+	}
+	return value, nil
+}
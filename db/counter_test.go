@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestIncrBy(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+
+	tests := []struct {
+		name  string
+		delta int64
+		want  int64
+	}{
+		{"a", 1, 1},
+		{"a", 5, 6},
+		{"b", -2, -2},
+		{"a", -6, 0},
+		{"b", 3, 1},
+	}
+	for _, tt := range tests {
+		got, err := d.IncrBy(ctx, tt.name, tt.delta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("IncrBy(%q, %d) = %d, want %d", tt.name, tt.delta, got, tt.want)
+		}
+	}
+}
+
+func TestNextValRolledBack(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+
+	// The first use creates the table inside the transaction, so it is rolled back too.
+	errRollback := errors.New("rollback")
+	err := d.WithTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if n, err := d.NextVal(ctx, "invoice"); err != nil || n != 1 {
+			t.Errorf("NextVal = %d, %v, want 1", n, err)
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("WithTx returned %v, want %v", err, errRollback)
+	}
+
+	if n, err := d.NextVal(ctx, "invoice"); err != nil || n != 1 {
+		t.Errorf("NextVal after rollback = %d, %v, want 1", n, err)
+	}
+}
+
+func TestNextValConcurrent(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t, WithWriteQueue(64))
+
+	const n = 50
+	var wg sync.WaitGroup
+	values := make(chan int64, n)
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := d.NextVal(ctx, "seq")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			values <- v
+		}()
+	}
+	wg.Wait()
+	close(values)
+
+	seen := map[int64]bool{}
+	for v := range values {
+		if seen[v] || v < 1 || v > n {
+			t.Errorf("got duplicate or out of range value %d", v)
+		}
+		seen[v] = true
+	}
+}
+
+func TestNextValReopened(t *testing.T) {
+	ctx := context.Background()
+	for _, file := range []string{"first.db", "second.db"} {
+		closeDB, err := InitNamed(t.Name(), WithPath(filepath.Join(t.TempDir(), file)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, err := Use(t.Name()).NextVal(ctx, "seq"); err != nil || n != 1 {
+			t.Errorf("NextVal on %s = %d, %v, want 1", file, n, err)
+		}
+		if err := closeDB(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
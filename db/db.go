@@ -8,10 +8,39 @@
 //   - stdlib-compatible API: QueryContext, QueryRowContext, ExecContext, BeginTx
 //   - Generic Scan[T] for single row mapping
 //   - Generic ScanAll[T] for multiple rows with iterator pattern
+//   - Generic ScanChunks[T] for batched row iteration
+//   - BLOB columns written from readers and read into writers with WriteBlob and ReadBlob
+//   - Byte slice fields for binary columns with NULL → nil and empty BLOB → []byte{}
+//   - Custom enum types of any integer kind, such as type Status uint8, with overflow checks
+//   - Nestable transactions with WithTx backed by savepoints
+//   - BEGIN IMMEDIATE / EXCLUSIVE transactions via BeginTx options, see TxMode
+//   - Optional serialized write queue with futures via EnableWriteQueue and ExecAsync
+//   - Transaction retry on busy/conflict errors with WithTxRetry
+//   - Graceful close that drains in-flight queries and transactions
+//   - Multiple named databases via InitNamed and Use
+//   - One database file per tenant with Tenants and WithTenant
+//   - Opt-in row-level audit log with EnableAudit, WithActor and AuditHistory
+//   - Query plan inspection with Explain and an optional full scan warning hook
+//   - Integrity checks with CheckIntegrity and QuickCheck, optionally at startup
+//   - Request-scoped query annotations with Annotate and a query hook for logging and tracing
+//   - Per-fingerprint query statistics with QueryStats
+//   - Application-defined SQL functions with RegisterFunction
+//   - IN-clause placeholder expansion with In
+//   - Safe dynamic query composition with Fragment
+//   - Struct inserts with Insert, including field defaults via `db:",default=..."`
+//   - Batched archiving of old rows with Archive, optionally into an attached database
+//   - Streaming table copies between databases with CopyTable
+//   - Declarative data retention with Retention, run by RunMaintenance or the WithMaintenance scheduler
+//   - Cross-process advisory locks with Lock, TryLock and Unlock
+//   - Atomic counters and sequences with NextVal and IncrBy
+//   - Embedded key-value store with TTL via KVSet, KVGet, KVDelete and KVList
+//   - Opt-in result caching with QueryCached, invalidated by table on writes
 //   - Hybrid column mapping: explicit db tags or automatic snake_case conversion
+//   - Slice fields from GROUP_CONCAT columns via `db:"tags,split=,"`
+//   - Per-type mapping cache, pre-populated with RegisterType
 //   - Support for SELECT * queries with any column order (ScanAll only)
 //   - Iterator-based results with iter.Seq2[T, error] for proper error handling
-//   - Database initialization from APP_NAME environment variable
+//   - Database initialization from APP_NAME environment variable or functional options
 //
 // Example usage:
 //
@@ -48,37 +77,145 @@
 //	defer tx.Rollback()
 //	// ... use tx
 //	tx.Commit()
+//
+//	// Nestable transaction, inner calls use savepoints
+//	err = db.WithTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+//		// ... use tx, pass ctx to nested WithTx calls
+//		return nil
+//	})
 package db
 
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
 	"iter"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	"unicode"
 
 	_ "modernc.org/sqlite"
 )
 
-var db *sql.DB
+// defaultName is the name of the database opened by Init.
+const defaultName = ""
+
+// DB is a database handle returned by Use. The package-level functions operate on the
+// database opened by Init, the methods of the same name operate on a specific database.
+type DB struct {
+	name string
+
+	// pool and config are set by InitNamed and replaced when the database is opened again. A closed
+	// pool is kept, so operations racing with the close function fail instead of finding none.
+	pool   atomic.Pointer[sql.DB]
+	config atomic.Pointer[config]
+
+	// mu serializes InitNamed and the close function.
+	mu sync.Mutex
+	// closing is set while the close function returned by Init drains in-flight work,
+	// closed once it has closed the pool.
+	closing atomic.Bool
+	closed  atomic.Bool
+
+	// txPools holds connections opened with a non-default transaction begin mode, see TxMode.
+	txPools   map[TxMode]*sql.DB
+	txPoolsMu sync.Mutex
+
+	// writer is the write queue enabled with EnableWriteQueue, see queue.
+	writer   *writeQueue
+	writerMu sync.Mutex
+
+	// audit is set by EnableAudit, so transactions record the actor from the context.
+	audit atomic.Bool
+
+	// explained holds the queries already checked by checkFullScans.
+	explained explainedQueries
+
+	maintenance maintenance
+
+	// locks holds the advisory locks acquired through this handle, see Lock.
+	locks      map[string]*heldLock
+	locksMu    sync.Mutex
+	locksReady atomic.Bool
+
+	// countersReady is set once the _counters table exists, see IncrBy.
+	countersReady atomic.Bool
+
+	// kvReady is set once the _kv table exists, see KVSet.
+	kvReady atomic.Bool
 
-func Init() (func() error, error) {
-	appName := os.Getenv("APP_NAME")
-	if appName == "" {
-		return nil, fmt.Errorf("APP_NAME environment variable is required")
+	cache queryCache
+	stats queryStats
+}
+
+var (
+	registry   = map[string]*DB{}
+	registryMu sync.Mutex
+)
+
+// Use returns the handle of the database opened with InitNamed under name.
+// The handle can be obtained before the database is opened and stays valid across re-initialization;
+// using it while the database is not open returns an error. The close function returned by an
+// earlier InitNamed does not affect the database opened again.
+func Use(name string) *DB {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	d, ok := registry[name]
+	if !ok {
+		d = &DB{name: name}
+		registry[name] = d
+	}
+	return d
+}
+
+// unregister removes d from the registry unless it is open, so the registry no longer holds on to
+// it and the next Use of its name returns a new handle.
+func unregister(d *DB) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if registry[d.name] == d && !d.isOpen() {
+		delete(registry, d.name)
+	}
+}
+
+// Init opens the database configured by opts. Settings that are not given fall back to
+// environment variables: the path defaults to ./data/$APP_NAME.db and the close timeout
+// to $DB_CLOSE_TIMEOUT. Returns a function that drains in-flight work and closes the database.
+func Init(opts ...Option) (func() error, error) {
+	return InitNamed(defaultName, opts...)
+}
+
+// InitNamed opens an additional database under name, configured like Init, so one process can
+// own several SQLite files. Access it with Use(name). Without WithPath the file defaults to
+// ./data/$APP_NAME-<name>.db.
+func InitNamed(name string, opts ...Option) (func() error, error) {
+	cfg, err := loadConfig(name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	d := Use(name)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.isOpen() {
+		return nil, fmt.Errorf("database %s already initialized", d)
 	}
 
 	// Ensure data directory exists
-	dataDir := "./data"
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(cfg.path), 0755); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	dbPath := dataDir + "/" + appName + ".db"
-	conn, err := sql.Open("sqlite", dbPath)
+	conn, err := openPool(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -88,46 +225,201 @@ func Init() (func() error, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	db = conn
+	d.forget()
+	d.config.Store(&cfg)
+	d.pool.Store(conn)
+	d.closed.Store(false)
 
-	closeFunc := func() error {
-		if db != nil {
-			err := db.Close()
-			db = nil
-			return err
+	if cfg.writeQueueSize > 0 {
+		if err := d.EnableWriteQueue(cfg.writeQueueSize); err != nil {
+			d.shutdown(conn)
+			return nil, err
+		}
+	}
+
+	if cfg.integrityCheck != nil {
+		report, err := d.checkIntegrity(context.Background(), cfg.integrityQuick)
+		if err != nil {
+			d.shutdown(conn)
+			return nil, err
+		}
+		if !report.OK {
+			cfg.integrityCheck(report)
 		}
+	}
+
+	if cfg.maintenanceInterval > 0 {
+		d.startMaintenance(cfg.maintenanceInterval, cfg.maintenanceError)
+	}
+
+	return func() error { return d.close(conn) }, nil
+}
+
+// close drains in-flight work and closes the database, unless it was closed since it was opened
+// with pool, which includes having been opened again.
+func (d *DB) close(pool *sql.DB) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.pool.Load() != pool || d.closed.Load() {
 		return nil
 	}
+	return d.shutdown(pool)
+}
+
+// shutdown drains in-flight work and closes pool, the pool of the database. d.mu must be held.
+func (d *DB) shutdown(pool *sql.DB) error {
+	d.closing.Store(true)
+	defer d.closing.Store(false)
+
+	drainErr := d.drain(d.config.Load().closeTimeout)
+
+	d.txPoolsMu.Lock()
+	for mode, pool := range d.txPools {
+		pool.Close()
+		delete(d.txPools, mode)
+	}
+	d.txPoolsMu.Unlock()
 
-	return closeFunc, nil
+	err := pool.Close()
+	d.closed.Store(true)
+	d.forget()
+	return errors.Join(drainErr, err)
+}
+
+// forget drops what the handle learned about the database file, as it may be opened again on
+// another file. It is called when the database is closed, and again when it is opened, for
+// operations that raced with the close.
+func (d *DB) forget() {
+	d.explained.clear()
+	d.locksReady.Store(false)
+	d.countersReady.Store(false)
+	d.kvReady.Store(false)
+	d.cache.invalidate()
+}
+
+// isOpen reports whether the database has been opened and not closed since.
+func (d *DB) isOpen() bool {
+	return d.pool.Load() != nil && !d.closed.Load()
+}
+
+// String returns the database name for use in messages.
+func (d *DB) String() string {
+	if d.name == defaultName {
+		return "default"
+	}
+	return fmt.Sprintf("%q", d.name)
+}
+
+// defaultDB returns the database opened by Init.
+func defaultDB() *DB {
+	return Use(defaultName)
+}
+
+// checkOpen returns an error if the database cannot accept new work.
+func (d *DB) checkOpen() error {
+	if !d.isOpen() {
+		if d.name == defaultName {
+			return fmt.Errorf("database not initialized, call Init() first")
+		}
+		return fmt.Errorf("database %s not initialized, call InitNamed() first", d)
+	}
+	if d.closing.Load() {
+		return fmt.Errorf("database %s is closing", d)
+	}
+	return nil
 }
 
 // QueryContext executes a query that returns rows, typically a SELECT.
 // The args are for any placeholder parameters in the query.
 func QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-	if db == nil {
-		return nil, fmt.Errorf("database not initialized, call Init() first")
+	return defaultDB().QueryContext(ctx, query, args...)
+}
+
+// QueryContext executes a query that returns rows, typically a SELECT.
+// The args are for any placeholder parameters in the query.
+func (d *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if err := d.checkOpen(); err != nil {
+		return nil, err
 	}
-	return db.QueryContext(ctx, query, args...)
+	d.checkFullScans(ctx, query, args...)
+	query = annotateQuery(ctx, query)
+	start := time.Now()
+	rows, err := d.pool.Load().QueryContext(ctx, query, args...)
+	stat := d.observe(ctx, query, args, start, err)
+	d.stats.trackRows(rows, stat)
+	return rows, err
 }
 
 // QueryRowContext executes a query that is expected to return at most one row.
 // The args are for any placeholder parameters in the query.
 func QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
-	return db.QueryRowContext(ctx, query, args...)
+	return defaultDB().QueryRowContext(ctx, query, args...)
+}
+
+// QueryRowContext executes a query that is expected to return at most one row.
+// The args are for any placeholder parameters in the query.
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if err := d.checkOpen(); err != nil {
+		return errRow(ctx, err)
+	}
+	d.checkFullScans(ctx, query, args...)
+	query = annotateQuery(ctx, query)
+	start := time.Now()
+	row := d.pool.Load().QueryRowContext(ctx, query, args...)
+	d.observe(ctx, query, args, start, row.Err())
+	return row
 }
 
-// BeginTx starts a transaction. The default isolation level is dependent on the driver.
+// errRow returns a row whose Err and Scan return err. database/sql has no constructor for one,
+// so it is queried from a pool whose connector fails with err.
+func errRow(ctx context.Context, err error) *sql.Row {
+	pool := sql.OpenDB(errConnector{err})
+	defer pool.Close()
+	return pool.QueryRowContext(ctx, "")
+}
+
+// errConnector is a driver.Connector that fails to connect with err.
+type errConnector struct{ err error }
+
+func (c errConnector) Connect(context.Context) (driver.Conn, error) { return nil, c.err }
+func (c errConnector) Driver() driver.Driver                        { return nil }
+
+// BeginTx starts a transaction. As SQLite transactions are always serializable, the isolation
+// level of opts selects how the transaction acquires locks instead: sql.LevelSerializable begins
+// it IMMEDIATE and sql.LevelLinearizable EXCLUSIVE, see TxMode.Options; other levels begin it
+// DEFERRED. Read-only transactions cannot begin IMMEDIATE or EXCLUSIVE.
+// With the write queue enabled, only read-only transactions can be started; use WithTx to write.
 func BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
-	if db == nil {
-		return nil, fmt.Errorf("database not initialized, call Init() first")
+	return defaultDB().BeginTx(ctx, opts)
+}
+
+// BeginTx starts a transaction, see the package-level BeginTx.
+func (d *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if err := d.checkOpen(); err != nil {
+		return nil, err
+	}
+	if (opts == nil || !opts.ReadOnly) && d.queue() != nil {
+		return nil, errors.New("write queue enabled, use WithTx for transactions that write")
+	}
+	return d.beginTx(ctx, opts)
+}
+
+// beginTx starts a transaction in the mode selected by opts, outside the write queue.
+func (d *DB) beginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	mode, err := txModeOf(opts)
+	if err != nil {
+		return nil, err
 	}
-	return db.BeginTx(ctx, opts)
+	pool, err := d.txPool(mode)
+	if err != nil {
+		return nil, err
+	}
+	return pool.BeginTx(ctx, opts)
 }
 
-func scanRow[T any](rows *sql.Rows, columns []string) (T, error) {
+func scanRow[T any](rows *sql.Rows, columns []string, fields []*fieldMapping) (T, error) {
 	var result T
-	resultType := reflect.TypeOf(result)
+	resultType := reflect.TypeFor[T]()
 
 	if resultType.Kind() != reflect.Struct {
 		if len(columns) != 1 {
@@ -140,60 +432,19 @@ func scanRow[T any](rows *sql.Rows, columns []string) (T, error) {
 	}
 
 	resultValue := reflect.ValueOf(&result).Elem()
-
 	scanValues := make([]any, len(columns))
-	columnToField := make(map[string]reflect.Value)
-	nullableFields := make(map[int]reflect.Value) // Track fields that need NULL handling
-
-	for i := 0; i < resultType.NumField(); i++ {
-		field := resultType.Field(i)
-		fieldValue := resultValue.Field(i)
-
-		if !fieldValue.CanSet() {
-			continue
-		}
-
-		columnName := field.Tag.Get("db")
-		if columnName == "" {
-			columnName = toSnakeCase(field.Name)
-		}
-
-		columnToField[columnName] = fieldValue
-	}
 
-	for i, column := range columns {
-		if fieldValue, exists := columnToField[column]; exists {
-			fieldType := fieldValue.Type()
-
-			// Handle non-pointer types that need NULL support
-			switch fieldType.Kind() {
-			case reflect.String:
-				// Use sql.NullString for string fields to handle NULL
-				var nullStr sql.NullString
-				scanValues[i] = &nullStr
-				nullableFields[i] = fieldValue
-			case reflect.Int, reflect.Int64:
-				// Use sql.NullInt64 for int fields to handle NULL
-				var nullInt sql.NullInt64
-				scanValues[i] = &nullInt
-				nullableFields[i] = fieldValue
-			case reflect.Float64:
-				// Use sql.NullFloat64 for float64 fields to handle NULL
-				var nullFloat sql.NullFloat64
-				scanValues[i] = &nullFloat
-				nullableFields[i] = fieldValue
-			case reflect.Bool:
-				// Use sql.NullBool for bool fields to handle NULL
-				var nullBool sql.NullBool
-				scanValues[i] = &nullBool
-				nullableFields[i] = fieldValue
-			default:
-				// For pointer types and other types, use direct scanning
-				scanValues[i] = fieldValue.Addr().Interface()
-			}
-		} else {
+	for i, field := range fields {
+		switch {
+		case field == nil:
 			var dummy any
 			scanValues[i] = &dummy
+		case field.nullable:
+			// Handle non-pointer types that need NULL support
+			scanValues[i] = field.scanTarget()
+		default:
+			// For pointer types and other types, use direct scanning
+			scanValues[i] = resultValue.Field(field.index).Addr().Interface()
 		}
 	}
 
@@ -202,38 +453,27 @@ func scanRow[T any](rows *sql.Rows, columns []string) (T, error) {
 	}
 
 	// Convert NULL values to appropriate zero values for non-pointer fields
-	for i, fieldValue := range nullableFields {
-		switch v := scanValues[i].(type) {
-		case *sql.NullString:
-			if v.Valid {
-				fieldValue.SetString(v.String)
-			} else {
-				fieldValue.SetString("") // NULL → empty string
-			}
-		case *sql.NullInt64:
-			if v.Valid {
-				fieldValue.SetInt(v.Int64)
-			} else {
-				fieldValue.SetInt(0) // NULL → 0
-			}
-		case *sql.NullFloat64:
-			if v.Valid {
-				fieldValue.SetFloat(v.Float64)
-			} else {
-				fieldValue.SetFloat(0.0) // NULL → 0.0
-			}
-		case *sql.NullBool:
-			if v.Valid {
-				fieldValue.SetBool(v.Bool)
-			} else {
-				fieldValue.SetBool(false) // NULL → false
-			}
+	for i, field := range fields {
+		if field == nil || !field.nullable {
+			continue
+		}
+		if err := field.assign(resultValue.Field(field.index), scanValues[i]); err != nil {
+			return result, fmt.Errorf("column %s: %w", columns[i], err)
 		}
 	}
 
 	return result, nil
 }
 
+// resultFields returns the fields the columns of a result set are scanned into, nil for scalar types.
+func resultFields[T any](columns []string) []*fieldMapping {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return columnFields(t, columns)
+}
+
 func toSnakeCase(s string) string {
 	var result strings.Builder
 	for i, r := range s {
@@ -249,22 +489,148 @@ func toSnakeCase(s string) string {
 	return result.String()
 }
 
+// scannerType is the type of sql.Scanner.
+var scannerType = reflect.TypeFor[sql.Scanner]()
+
+// nullableTarget returns a scan destination that accepts NULL for a non-pointer field of a
+// primitive kind, including defined types such as `type Status int`. It returns nil for
+// fields that are scanned into directly, such as pointers, structs and types implementing
+// sql.Scanner, which handle NULL themselves.
+func nullableTarget(fieldType reflect.Type) any {
+	if reflect.PointerTo(fieldType).Implements(scannerType) {
+		return nil
+	}
+	switch fieldType.Kind() {
+	case reflect.String:
+		return new(sql.NullString)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(sql.NullInt64)
+	case reflect.Float32, reflect.Float64:
+		return new(sql.NullFloat64)
+	case reflect.Bool:
+		return new(sql.NullBool)
+	case reflect.Slice:
+		if fieldType.Elem().Kind() == reflect.Uint8 {
+			// Use any for byte slice fields to tell NULL from empty BLOB
+			return new(any)
+		}
+	}
+	return nil
+}
+
+// assignNullable stores a value scanned into a nullableTarget destination in fieldValue.
+// NULL becomes the zero value, and the defined type of the field is preserved.
+func assignNullable(fieldValue reflect.Value, scanned any) error {
+	switch v := scanned.(type) {
+	case *sql.NullString:
+		fieldValue.SetString(v.String) // NULL → empty string
+	case *sql.NullInt64:
+		if fieldValue.CanUint() {
+			if v.Int64 < 0 || fieldValue.OverflowUint(uint64(v.Int64)) {
+				return fmt.Errorf("value %d overflows %v", v.Int64, fieldValue.Type())
+			}
+			fieldValue.SetUint(uint64(v.Int64)) // NULL → 0
+			return nil
+		}
+		if fieldValue.OverflowInt(v.Int64) {
+			return fmt.Errorf("value %d overflows %v", v.Int64, fieldValue.Type())
+		}
+		fieldValue.SetInt(v.Int64) // NULL → 0
+	case *sql.NullFloat64:
+		fieldValue.SetFloat(v.Float64) // NULL → 0.0
+	case *sql.NullBool:
+		fieldValue.SetBool(v.Bool) // NULL → false
+	case *any:
+		b, err := toBytes(*v)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetBytes(b) // NULL → nil
+	}
+	return nil
+}
+
+// toBytes converts a scanned column value for a byte slice field.
+// The driver reports both NULL and an empty BLOB as a nil value, but only NULL arrives as
+// an untyped nil, so NULL maps to nil and an empty BLOB maps to a non-nil empty slice.
+func toBytes(v any) ([]byte, error) {
+	switch x := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		if x == nil {
+			return []byte{}, nil
+		}
+		return x, nil
+	case string:
+		return []byte(x), nil
+	case int64, float64, bool:
+		return fmt.Appendf(nil, "%v", x), nil
+	default:
+		return nil, fmt.Errorf("cannot scan %T into byte slice", v)
+	}
+}
+
+// quoteIdent quotes an SQL identifier such as a table or column name.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
 // ExecContext executes a query without returning any rows.
 // The args are for any placeholder parameters in the query.
+// When the write queue is enabled, the query waits for its turn in the queue.
 func ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
-	if db == nil {
-		return nil, fmt.Errorf("database not initialized, call Init() first")
+	return defaultDB().ExecContext(ctx, query, args...)
+}
+
+// ExecContext executes a query without returning any rows.
+// The args are for any placeholder parameters in the query.
+// When the write queue is enabled, the query waits for its turn in the queue.
+func (d *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if err := d.checkOpen(); err != nil {
+		return nil, err
+	}
+	d.checkFullScans(ctx, query, args...)
+
+	_, hasActor := actorFromContext(ctx)
+	audited := hasActor && d.audit.Load()
+	if d.queue() != nil && !audited {
+		// ExecAsync annotates and reports the query itself.
+		return d.ExecAsync(ctx, query, args...).Wait(ctx)
 	}
-	return db.ExecContext(ctx, query, args...)
+
+	query = annotateQuery(ctx, query)
+	start := time.Now()
+	var result sql.Result
+	var err error
+	if audited {
+		// Run in a transaction so the audit triggers can see the actor.
+		err = d.WithTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+			var err error
+			result, err = tx.ExecContext(ctx, query, args...)
+			return err
+		})
+	} else {
+		result, err = d.pool.Load().ExecContext(ctx, query, args...)
+	}
+	stat := d.observe(ctx, query, args, start, err)
+	if err == nil {
+		d.stats.addResult(stat, result)
+		d.cache.invalidateWrite(query, d.linkedTables)
+	}
+	return result, err
 }
 
 // Scan scans a single row into a value of type T.
 // For scalar types (string, int, etc.), it scans directly.
 // For struct types, it scans fields in declaration order with NULL handling.
 // Pointer fields receive nil for NULL values, non-pointer primitives receive zero values.
+// Byte slice fields (including named types such as json.RawMessage) receive a copy of the
+// column value, or nil for NULL.
 func Scan[T any](row *sql.Row) (T, error) {
 	var result T
-	resultType := reflect.TypeOf(result)
+	resultType := reflect.TypeFor[T]()
 
 	// Handle scalar types
 	if resultType.Kind() != reflect.Struct {
@@ -276,54 +642,16 @@ func Scan[T any](row *sql.Row) (T, error) {
 
 	// Handle struct types - scan fields in declaration order
 	resultValue := reflect.ValueOf(&result).Elem()
-	scanValues := make([]any, 0, resultType.NumField())
-	nullableFields := make([]struct {
-		index int
-		value reflect.Value
-	}, 0)
-
-	for i := 0; i < resultType.NumField(); i++ {
-		fieldValue := resultValue.Field(i)
+	fields := mappingOf(resultType).fields
+	scanValues := make([]any, len(fields))
 
-		if !fieldValue.CanSet() {
-			continue
-		}
-
-		fieldType := fieldValue.Type()
-
-		// Handle non-pointer types that need NULL support
-		switch fieldType.Kind() {
-		case reflect.String:
-			var nullStr sql.NullString
-			scanValues = append(scanValues, &nullStr)
-			nullableFields = append(nullableFields, struct {
-				index int
-				value reflect.Value
-			}{len(scanValues) - 1, fieldValue})
-		case reflect.Int, reflect.Int64:
-			var nullInt sql.NullInt64
-			scanValues = append(scanValues, &nullInt)
-			nullableFields = append(nullableFields, struct {
-				index int
-				value reflect.Value
-			}{len(scanValues) - 1, fieldValue})
-		case reflect.Float64:
-			var nullFloat sql.NullFloat64
-			scanValues = append(scanValues, &nullFloat)
-			nullableFields = append(nullableFields, struct {
-				index int
-				value reflect.Value
-			}{len(scanValues) - 1, fieldValue})
-		case reflect.Bool:
-			var nullBool sql.NullBool
-			scanValues = append(scanValues, &nullBool)
-			nullableFields = append(nullableFields, struct {
-				index int
-				value reflect.Value
-			}{len(scanValues) - 1, fieldValue})
-		default:
+	for i, field := range fields {
+		if field.nullable {
+			// Handle non-pointer types that need NULL support
+			scanValues[i] = field.scanTarget()
+		} else {
 			// For pointer types and other types, use direct scanning
-			scanValues = append(scanValues, fieldValue.Addr().Interface())
+			scanValues[i] = resultValue.Field(field.index).Addr().Interface()
 		}
 	}
 
@@ -332,32 +660,12 @@ func Scan[T any](row *sql.Row) (T, error) {
 	}
 
 	// Convert NULL values to appropriate zero values for non-pointer fields
-	for _, nf := range nullableFields {
-		switch v := scanValues[nf.index].(type) {
-		case *sql.NullString:
-			if v.Valid {
-				nf.value.SetString(v.String)
-			} else {
-				nf.value.SetString("") // NULL → empty string
-			}
-		case *sql.NullInt64:
-			if v.Valid {
-				nf.value.SetInt(v.Int64)
-			} else {
-				nf.value.SetInt(0) // NULL → 0
-			}
-		case *sql.NullFloat64:
-			if v.Valid {
-				nf.value.SetFloat(v.Float64)
-			} else {
-				nf.value.SetFloat(0.0) // NULL → 0.0
-			}
-		case *sql.NullBool:
-			if v.Valid {
-				nf.value.SetBool(v.Bool)
-			} else {
-				nf.value.SetBool(false) // NULL → false
-			}
+	for i, field := range fields {
+		if !field.nullable {
+			continue
+		}
+		if err := field.assign(resultValue.Field(field.index), scanValues[i]); err != nil {
+			return result, err
 		}
 	}
 
@@ -371,6 +679,8 @@ func Scan[T any](row *sql.Row) (T, error) {
 func ScanAll[T any](rows *sql.Rows) iter.Seq2[T, error] {
 	return func(yield func(T, error) bool) {
 		var zero T
+		var scanned int64
+		defer func() { countScanned(rows, scanned) }()
 		defer rows.Close()
 
 		columns, err := rows.Columns()
@@ -378,14 +688,16 @@ func ScanAll[T any](rows *sql.Rows) iter.Seq2[T, error] {
 			yield(zero, fmt.Errorf("failed to get columns: %w", err))
 			return
 		}
+		fields := resultFields[T](columns)
 
 		for rows.Next() {
-			result, err := scanRow[T](rows, columns)
+			result, err := scanRow[T](rows, columns, fields)
 			if err != nil {
 				yield(zero, fmt.Errorf("failed to scan row: %w", err))
 				return
 			}
 
+			scanned++
 			if !yield(result, nil) {
 				return
 			}
@@ -396,3 +708,53 @@ func ScanAll[T any](rows *sql.Rows) iter.Seq2[T, error] {
 		}
 	}
 }
+
+// ScanChunks scans multiple rows into an iterator of slices holding up to n values of type T.
+// Column mapping and NULL handling are the same as in ScanAll. The last chunk may be shorter than n.
+// Each yielded slice is freshly allocated, so it is safe to retain it after the next iteration.
+func ScanChunks[T any](rows *sql.Rows, n int) iter.Seq2[[]T, error] {
+	return func(yield func([]T, error) bool) {
+		var scanned int64
+		defer func() { countScanned(rows, scanned) }()
+		defer rows.Close()
+
+		if n <= 0 {
+			yield(nil, fmt.Errorf("chunk size must be positive, got %d", n))
+			return
+		}
+
+		columns, err := rows.Columns()
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to get columns: %w", err))
+			return
+		}
+		fields := resultFields[T](columns)
+
+		chunk := make([]T, 0, n)
+		for rows.Next() {
+			result, err := scanRow[T](rows, columns, fields)
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to scan row: %w", err))
+				return
+			}
+
+			scanned++
+			chunk = append(chunk, result)
+			if len(chunk) == n {
+				if !yield(chunk, nil) {
+					return
+				}
+				chunk = make([]T, 0, n)
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(nil, fmt.Errorf("row iteration error: %w", err))
+			return
+		}
+
+		if len(chunk) > 0 {
+			yield(chunk, nil)
+		}
+	}
+}
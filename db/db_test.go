@@ -0,0 +1,235 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// openTestDB opens a database in a temporary file under the name of the test, closed when it ends.
+func openTestDB(tb testing.TB, opts ...Option) *DB {
+	tb.Helper()
+	opts = append([]Option{WithPath(filepath.Join(tb.TempDir(), "test.db"))}, opts...)
+	closeDB, err := InitNamed(tb.Name(), opts...)
+	if err != nil {
+		tb.Fatalf("failed to open database: %v", err)
+	}
+	tb.Cleanup(func() {
+		if err := closeDB(); err != nil {
+			tb.Errorf("failed to close database: %v", err)
+		}
+	})
+	return Use(tb.Name())
+}
+
+// mustExec runs statements on d, failing the test on the first error.
+func mustExec(tb testing.TB, d *DB, statements ...string) {
+	tb.Helper()
+	for _, statement := range statements {
+		if _, err := d.ExecContext(context.Background(), statement); err != nil {
+			tb.Fatalf("failed to run %q: %v", statement, err)
+		}
+	}
+}
+
+func TestScanChunks(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+	mustExec(t, d, "CREATE TABLE t (v INTEGER)")
+	for i := 1; i <= 7; i++ {
+		mustExec(t, d, fmt.Sprintf("INSERT INTO t VALUES (%d)", i))
+	}
+
+	tests := []struct {
+		n    int
+		want [][]int
+	}{
+		{3, [][]int{{1, 2, 3}, {4, 5, 6}, {7}}},
+		{7, [][]int{{1, 2, 3, 4, 5, 6, 7}}},
+		{10, [][]int{{1, 2, 3, 4, 5, 6, 7}}},
+	}
+	for _, tt := range tests {
+		rows, err := d.QueryContext(ctx, "SELECT v FROM t ORDER BY v")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got [][]int
+		for chunk, err := range ScanChunks[int](rows, tt.n) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, chunk)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("chunks of %d: got %v, want %v", tt.n, got, tt.want)
+		}
+	}
+
+	rows, err := d.QueryContext(ctx, "SELECT v FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range ScanChunks[int](rows, 0) {
+		if err == nil {
+			t.Error("chunk size 0 was accepted")
+		}
+	}
+}
+
+func TestScanBytes(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+	mustExec(t, d, "CREATE TABLE files (id INTEGER PRIMARY KEY, data BLOB, doc TEXT)")
+
+	type file struct {
+		ID   int64
+		Data []byte
+		Doc  json.RawMessage
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{"nil binds NULL", nil, nil},
+		{"empty binds an empty BLOB", []byte{}, []byte{}},
+		{"bytes", []byte{0, 1, 2}, []byte{0, 1, 2}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := d.ExecContext(ctx, "INSERT INTO files VALUES (?, ?, ?)", i, tt.data, `{"a":1}`); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := d.QueryContext(ctx, "SELECT * FROM files WHERE id = ?", i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for f, err := range ScanAll[file](rows) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(f.Data, tt.want) || string(f.Doc) != `{"a":1}` {
+					t.Errorf("ScanAll got %#v and %s, want %#v", f.Data, f.Doc, tt.want)
+				}
+			}
+
+			f, err := Scan[file](d.QueryRowContext(ctx, "SELECT * FROM files WHERE id = ?", i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(f.Data, tt.want) {
+				t.Errorf("Scan got %#v, want %#v", f.Data, tt.want)
+			}
+		})
+	}
+}
+
+type (
+	status   uint8
+	priority int16
+)
+
+// upper is a string that scans itself upper-cased, and NULL as "none".
+type upper string
+
+func (u *upper) Scan(src any) error {
+	if src == nil {
+		*u = "none"
+		return nil
+	}
+	*u = upper(strings.ToUpper(fmt.Sprint(src)))
+	return nil
+}
+
+func TestScanEnums(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+
+	type task struct {
+		Status   status
+		Priority priority
+		Label    upper
+	}
+	tests := []struct {
+		values  string
+		want    task
+		wantErr bool
+	}{
+		{values: "2, -3, 'todo'", want: task{Status: 2, Priority: -3, Label: "TODO"}},
+		{values: "255, 32767, 'x'", want: task{Status: 255, Priority: 32767, Label: "X"}},
+		{values: "NULL, NULL, NULL", want: task{Label: "none"}},
+		{values: "256, 0, 'x'", wantErr: true},
+		{values: "-1, 0, 'x'", wantErr: true},
+		{values: "0, -32769, 'x'", wantErr: true},
+	}
+	for _, tt := range tests {
+		query := "SELECT column1 AS status, column2 AS priority, column3 AS label FROM (VALUES (" + tt.values + "))"
+
+		got, err := Scan[task](d.QueryRowContext(ctx, query))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Scan of %s got %+v, want overflow error", tt.values, got)
+			}
+		} else if err != nil || got != tt.want {
+			t.Errorf("Scan of %s got %+v, %v, want %+v", tt.values, got, err, tt.want)
+		}
+
+		rows, err := d.QueryContext(ctx, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for got, err := range ScanAll[task](rows) {
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ScanAll of %s got %+v, want overflow error", tt.values, got)
+				}
+			} else if err != nil || got != tt.want {
+				t.Errorf("ScanAll of %s got %+v, %v, want %+v", tt.values, got, err, tt.want)
+			}
+			break
+		}
+	}
+}
+
+func TestClosed(t *testing.T) {
+	ctx := context.Background()
+	name := t.Name()
+	path := filepath.Join(t.TempDir(), "test.db")
+	closeDB, err := InitNamed(name, WithPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := InitNamed(name, WithPath(path)); err == nil {
+		t.Error("InitNamed of an open database succeeded")
+	}
+	if err := closeDB(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := Use(name)
+	if _, err := d.ExecContext(ctx, "SELECT 1"); err == nil {
+		t.Error("ExecContext on a closed database succeeded")
+	}
+	var v int
+	if err := d.QueryRowContext(ctx, "SELECT 1").Scan(&v); err == nil {
+		t.Error("QueryRowContext on a closed database succeeded")
+	}
+
+	// A close function of an earlier open leaves the database opened again alone.
+	closeAgain, err := InitNamed(name, WithPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeAgain()
+	if err := closeDB(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.QueryRowContext(ctx, "SELECT 1").Scan(&v); err != nil {
+		t.Errorf("stale close function closed the database opened again: %v", err)
+	}
+}
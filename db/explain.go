@@ -0,0 +1,196 @@
+package db
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// PlanStep is one line of an EXPLAIN QUERY PLAN result.
+type PlanStep struct {
+	ID     int
+	Parent int
+	Detail string // raw detail text, e.g. "SEARCH users USING INDEX users_email (email=?)"
+	// Table is the table (or alias) read by a SCAN or SEARCH step, empty for other steps.
+	Table string
+	// Index is the index used to read Table, "INTEGER PRIMARY KEY" for rowid lookups,
+	// or empty when no index is used.
+	Index string
+	// FullScan reports that Table is read row by row without any index.
+	FullScan bool
+}
+
+// QueryPlan is the parsed result of EXPLAIN QUERY PLAN.
+type QueryPlan struct {
+	Steps []PlanStep
+}
+
+// FullScans returns the tables read without any index.
+func (p QueryPlan) FullScans() []string {
+	var tables []string
+	for _, step := range p.Steps {
+		if step.FullScan {
+			tables = append(tables, step.Table)
+		}
+	}
+	return tables
+}
+
+// String returns the plan as indented detail lines, like the sqlite3 shell prints it.
+func (p QueryPlan) String() string {
+	depth := map[int]int{}
+	var b strings.Builder
+	for _, step := range p.Steps {
+		depth[step.ID] = depth[step.Parent] + 1
+		b.WriteString(strings.Repeat("  ", depth[step.ID]-1))
+		b.WriteString(step.Detail)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Explain returns the query plan SQLite would use to run query with args, without running it.
+func Explain(ctx context.Context, query string, args ...any) (QueryPlan, error) {
+	return defaultDB().Explain(ctx, query, args...)
+}
+
+// Explain returns the query plan for query, see the package-level Explain.
+func (d *DB) Explain(ctx context.Context, query string, args ...any) (QueryPlan, error) {
+	if err := d.checkOpen(); err != nil {
+		return QueryPlan{}, err
+	}
+	return explain(ctx, d, query, args...)
+}
+
+func explain(ctx context.Context, d *DB, query string, args ...any) (QueryPlan, error) {
+	// The driver runs every statement of a multi-statement query, so only a single one may follow EXPLAIN.
+	if strings.Contains(strings.TrimRight(strings.TrimSpace(query), ";"), ";") {
+		return QueryPlan{}, fmt.Errorf("cannot explain multiple statements")
+	}
+
+	rows, err := d.pool.Load().QueryContext(ctx, "EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		return QueryPlan{}, fmt.Errorf("failed to explain query: %w", err)
+	}
+	defer rows.Close()
+
+	var plan QueryPlan
+	for rows.Next() {
+		var step PlanStep
+		var notUsed int
+		if err := rows.Scan(&step.ID, &step.Parent, &notUsed, &step.Detail); err != nil {
+			return QueryPlan{}, fmt.Errorf("failed to scan query plan: %w", err)
+		}
+		parsePlanDetail(&step)
+		plan.Steps = append(plan.Steps, step)
+	}
+	if err := rows.Err(); err != nil {
+		return QueryPlan{}, fmt.Errorf("failed to read query plan: %w", err)
+	}
+
+	return plan, nil
+}
+
+// parsePlanDetail fills the table and index of a SCAN or SEARCH step from its detail text.
+func parsePlanDetail(step *PlanStep) {
+	op, rest, ok := strings.Cut(step.Detail, " ")
+	if !ok || (op != "SCAN" && op != "SEARCH") {
+		return
+	}
+
+	table, using, _ := strings.Cut(rest, " ")
+	// Skip subqueries ("SCAN (subquery-1)") and "SCAN CONSTANT ROW".
+	if strings.HasPrefix(table, "(") || rest == "CONSTANT ROW" {
+		return
+	}
+	step.Table = table
+
+	switch {
+	case strings.HasPrefix(using, "USING INTEGER PRIMARY KEY"):
+		step.Index = "INTEGER PRIMARY KEY"
+	case strings.HasPrefix(using, "USING INDEX "), strings.HasPrefix(using, "USING COVERING INDEX "),
+		strings.HasPrefix(using, "USING AUTOMATIC INDEX "), strings.HasPrefix(using, "USING AUTOMATIC COVERING INDEX "):
+		name := using[strings.Index(using, "INDEX ")+len("INDEX "):]
+		name, _, _ = strings.Cut(name, " ")
+		step.Index = name
+	}
+
+	step.FullScan = op == "SCAN" && step.Index == ""
+}
+
+// maxExplained is the number of distinct queries checkFullScans remembers. Beyond that the least
+// recently run are forgotten, and explained again if they run again, so queries built with inlined
+// values do not grow memory without bound.
+const maxExplained = 10000
+
+// explainedQueries is the set of queries checked by checkFullScans, bounded to maxExplained.
+type explainedQueries struct {
+	mu      sync.Mutex
+	order   *list.List // most recently run first
+	queries map[string]*list.Element
+}
+
+// add adds query to the set as the most recently run, and reports whether it was in the set already.
+func (e *explainedQueries) add(query string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if elem, ok := e.queries[query]; ok {
+		e.order.MoveToFront(elem)
+		return true
+	}
+	if e.queries == nil {
+		e.order = list.New()
+		e.queries = map[string]*list.Element{}
+	}
+	e.queries[query] = e.order.PushFront(query)
+	if e.order.Len() > maxExplained {
+		delete(e.queries, e.order.Remove(e.order.Back()).(string))
+	}
+	return false
+}
+
+// clear empties the set.
+func (e *explainedQueries) clear() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.order = nil
+	e.queries = nil
+}
+
+// checkFullScans explains query once per distinct query string and calls the configured
+// hook for each full scan of a table with at least the configured number of rows.
+// Errors are ignored, the check must never fail the query itself.
+func (d *DB) checkFullScans(ctx context.Context, query string, args ...any) {
+	hook := d.config.Load().fullScanHook
+	if hook == nil {
+		return
+	}
+	if d.explained.add(query) {
+		return
+	}
+
+	keyword, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	switch strings.ToUpper(keyword) {
+	case "SELECT", "WITH", "INSERT", "REPLACE", "UPDATE", "DELETE":
+	default:
+		return
+	}
+
+	plan, err := explain(ctx, d, query, args...)
+	if err != nil {
+		return
+	}
+
+	for _, table := range plan.FullScans() {
+		// max(rowid) is a cheap estimate of the row count, it fails for aliases and WITHOUT ROWID tables.
+		var rows int64
+		err := d.pool.Load().QueryRowContext(ctx, "SELECT coalesce(max(rowid), 0) FROM "+quoteIdent(table)).Scan(&rows)
+		if err != nil || rows < d.config.Load().fullScanMinRows {
+			continue
+		}
+		hook(ctx, query, table, rows)
+	}
+}
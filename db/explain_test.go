@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+	mustExec(t, d, "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT)", "CREATE INDEX users_email ON users (email)")
+
+	tests := []struct {
+		query     string
+		fullScans []string
+	}{
+		{"SELECT * FROM users WHERE id = ?", nil},
+		{"SELECT * FROM users WHERE email = ?", nil},
+		{"SELECT * FROM users WHERE email LIKE ?", []string{"users"}},
+	}
+	for _, tt := range tests {
+		plan, err := d.Explain(ctx, tt.query, "x")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := plan.FullScans(); !slices.Equal(got, tt.fullScans) {
+			t.Errorf("full scans of %q are %v, want %v\n%s", tt.query, got, tt.fullScans, plan)
+		}
+	}
+}
+
+func TestFullScanWarning(t *testing.T) {
+	ctx := context.Background()
+	var warnings []string
+	d := openTestDB(t, WithFullScanWarning(1, func(ctx context.Context, query, table string, rows int64) {
+		warnings = append(warnings, table)
+	}))
+	mustExec(t, d, "CREATE TABLE t (v INTEGER)", "INSERT INTO t VALUES (1)")
+
+	for range 3 {
+		if _, err := d.ExecContext(ctx, "UPDATE t SET v = v + 1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !slices.Equal(warnings, []string{"t"}) {
+		t.Errorf("got warnings %v, want one for t", warnings)
+	}
+}
+
+func TestExplainedQueries(t *testing.T) {
+	var e explainedQueries
+	if e.add("q0") {
+		t.Error("a new query was reported as explained")
+	}
+	for i := 1; i <= maxExplained; i++ {
+		e.add(fmt.Sprint("q", i))
+	}
+	if len(e.queries) != maxExplained {
+		t.Errorf("holds %d queries, want %d", len(e.queries), maxExplained)
+	}
+	if e.add("q0") {
+		t.Error("the least recently run query was not forgotten")
+	}
+	if !e.add(fmt.Sprint("q", maxExplained)) {
+		t.Error("a recently run query was forgotten")
+	}
+}
+
+func TestFullScanWarningReopened(t *testing.T) {
+	ctx := context.Background()
+	var warnings []string
+	opts := []Option{WithFullScanWarning(1, func(ctx context.Context, query, table string, rows int64) {
+		warnings = append(warnings, table)
+	})}
+	query := "SELECT * FROM t WHERE v = 1"
+
+	for i, schema := range []string{"CREATE TABLE t (v INTEGER PRIMARY KEY)", "CREATE TABLE t (v INTEGER)"} {
+		closeDB, err := InitNamed(t.Name(), append(opts, WithPath(filepath.Join(t.TempDir(), fmt.Sprint(i, ".db"))))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := Use(t.Name())
+		mustExec(t, d, schema, "INSERT INTO t VALUES (1)")
+		if _, err := d.ExecContext(ctx, query); err != nil {
+			t.Fatal(err)
+		}
+		if err := closeDB(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The query uses the primary key in the first file and scans the table in the second.
+	if !slices.Equal(warnings, []string{"t"}) {
+		t.Errorf("got warnings %v, want one for t in the second file", warnings)
+	}
+}
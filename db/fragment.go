@@ -0,0 +1,103 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Fragment is a piece of SQL together with the arguments for its ? placeholders.
+// Fragments are built from constant SQL text, validated identifiers and parameterized values,
+// so dynamic queries never need fmt.Sprintf:
+//
+//	where := []db.Fragment{db.SQL("active = ?", true)}
+//	if name != "" {
+//		where = append(where, db.SQL("name = ?", name))
+//	}
+//	q := db.Concat(
+//		db.SQL("SELECT * FROM users WHERE"), db.Join(" AND ", where...),
+//		db.SQL("ORDER BY"), db.OneOf(sortColumn, "name", "created_at"), db.Desc(descending),
+//	)
+//	query, args, err := q.Build()
+//
+// An invalid identifier makes the whole fragment invalid, reported by Build.
+type Fragment struct {
+	text string
+	args []any
+	err  error
+}
+
+// identPattern matches plain and table-qualified identifiers such as name or users.name.
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// SQL returns a fragment of trusted SQL text with args for its ? placeholders.
+// text must not contain user input, use Ident, OneOf or Value for dynamic parts.
+func SQL(text string, args ...any) Fragment {
+	return Fragment{text: text, args: args}
+}
+
+// Value returns a single ? placeholder bound to v.
+func Value(v any) Fragment {
+	return Fragment{text: "?", args: []any{v}}
+}
+
+// Ident returns a quoted identifier, such as a column name chosen at runtime.
+// The name must consist of letters, digits and underscores, optionally qualified with a table name.
+func Ident(name string) Fragment {
+	if !identPattern.MatchString(name) {
+		return Fragment{err: fmt.Errorf("invalid identifier %q", name)}
+	}
+	table, column, qualified := strings.Cut(name, ".")
+	if qualified {
+		return Fragment{text: quoteIdent(table) + "." + quoteIdent(column)}
+	}
+	return Fragment{text: quoteIdent(name)}
+}
+
+// OneOf returns name as a quoted identifier if it is one of allowed, e.g. for sort columns from a request.
+func OneOf(name string, allowed ...string) Fragment {
+	if !slices.Contains(allowed, name) {
+		return Fragment{err: fmt.Errorf("identifier %q is not allowed", name)}
+	}
+	return Ident(name)
+}
+
+// Desc returns DESC if desc is set and ASC otherwise, for ORDER BY clauses.
+func Desc(desc bool) Fragment {
+	if desc {
+		return Fragment{text: "DESC"}
+	}
+	return Fragment{text: "ASC"}
+}
+
+// Join joins the non-empty parts with sep, e.g. " AND " for filters or ", " for column lists.
+func Join(sep string, parts ...Fragment) Fragment {
+	var result Fragment
+	var texts []string
+	for _, part := range parts {
+		if part.err != nil {
+			return Fragment{err: part.err}
+		}
+		if part.text == "" {
+			continue
+		}
+		texts = append(texts, part.text)
+		result.args = append(result.args, part.args...)
+	}
+	result.text = strings.Join(texts, sep)
+	return result
+}
+
+// Concat joins the non-empty parts with spaces.
+func Concat(parts ...Fragment) Fragment {
+	return Join(" ", parts...)
+}
+
+// Build returns the query text and args. Slice args are expanded as with In.
+func (f Fragment) Build() (string, []any, error) {
+	if f.err != nil {
+		return "", nil, f.err
+	}
+	return In(f.text, f.args...)
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIdent(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "name", want: `"name"`},
+		{name: "_created_at2", want: `"_created_at2"`},
+		{name: "users.name", want: `"users"."name"`},
+		{name: "order", want: `"order"`},
+		{name: "", wantErr: true},
+		{name: "2name", wantErr: true},
+		{name: "a.b.c", wantErr: true},
+		{name: `na"me`, wantErr: true},
+		{name: "name; DROP TABLE users", wantErr: true},
+		{name: "users.", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		query, args, err := Ident(tt.name).Build()
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Ident(%q) = %q, want error", tt.name, query)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Ident(%q): %v", tt.name, err)
+			continue
+		}
+		if query != tt.want || len(args) != 0 {
+			t.Errorf("Ident(%q) = %q %v, want %q", tt.name, query, args, tt.want)
+		}
+	}
+}
+
+func TestFragment(t *testing.T) {
+	tests := []struct {
+		name      string
+		fragment  Fragment
+		wantQuery string
+		wantArgs  []any
+		wantErr   bool
+	}{
+		{
+			name: "filters",
+			fragment: Concat(
+				SQL("SELECT * FROM users WHERE"), Join(" AND ", SQL("active = ?", true), SQL(""), SQL("id IN (?)", []int{1, 2})),
+				SQL("ORDER BY"), OneOf("name", "name", "created_at"), Desc(true),
+			),
+			wantQuery: `SELECT * FROM users WHERE active = ? AND id IN (?, ?) ORDER BY "name" DESC`,
+			wantArgs:  []any{true, 1, 2},
+		},
+		{
+			name:      "columns",
+			fragment:  Concat(SQL("SELECT"), Join(", ", Ident("id"), Ident("users.name")), SQL("FROM users WHERE id ="), Value(7)),
+			wantQuery: `SELECT "id", "users"."name" FROM users WHERE id = ?`,
+			wantArgs:  []any{7},
+		},
+		{
+			name:      "ascending",
+			fragment:  Concat(SQL("ORDER BY id"), Desc(false)),
+			wantQuery: "ORDER BY id ASC",
+			wantArgs:  []any{},
+		},
+		{
+			name:     "not allowed",
+			fragment: Concat(SQL("ORDER BY"), OneOf("password", "name", "created_at")),
+			wantErr:  true,
+		},
+		{
+			name:     "nested invalid",
+			fragment: Concat(SQL("SELECT"), Join(", ", Ident("id"), Ident("bad name")), SQL("FROM users")),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := tt.fragment.Build()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %q, want error", query)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query != tt.wantQuery {
+				t.Errorf("got query %q, want %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("got args %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
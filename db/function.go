@@ -0,0 +1,65 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"modernc.org/sqlite"
+)
+
+// RegisterFunction makes fn callable from SQL as name with nArgs arguments, or any number of
+// arguments if nArgs is negative, e.g. to add a regexp function for the REGEXP operator:
+//
+//	err := db.RegisterFunction("regexp", 2, func(args []any) (any, error) {
+//		pattern, _ := args[0].(string)
+//		value, _ := args[1].(string)
+//		return regexp.MatchString(pattern, value)
+//	})
+//
+// Arguments are nil, int64, float64, string or []byte, and must not be retained after fn returns.
+// fn may return those types, int, bool or time.Time, which is stored as Unix seconds; a returned
+// error fails the statement.
+// Functions are registered for the whole process and must be registered before Init or InitNamed.
+func RegisterFunction(name string, nArgs int, fn func(args []any) (any, error)) error {
+	return registerFunction(name, nArgs, false, fn)
+}
+
+// RegisterDeterministicFunction is like RegisterFunction for functions that always return the same
+// result for the same arguments, such as a distance calculation. SQLite can then use them in indexes
+// on expressions and partial indexes, and evaluate them fewer times.
+func RegisterDeterministicFunction(name string, nArgs int, fn func(args []any) (any, error)) error {
+	return registerFunction(name, nArgs, true, fn)
+}
+
+func registerFunction(name string, nArgs int, deterministic bool, fn func(args []any) (any, error)) error {
+	// Functions are added to connections when they are opened, so a pool that is already
+	// open would only have the function on some of its connections.
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, d := range registry {
+		if d.isOpen() {
+			return fmt.Errorf("function %s must be registered before database %s is initialized", name, d)
+		}
+	}
+
+	err := sqlite.RegisterFunction(name, &sqlite.FunctionImpl{
+		NArgs:         int32(nArgs),
+		Deterministic: deterministic,
+		Scalar: func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			values := make([]any, len(args))
+			for i, arg := range args {
+				values[i] = arg
+			}
+
+			result, err := fn(values)
+			if n, ok := result.(int); ok {
+				return int64(n), err
+			}
+			return result, err
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register function %s: %w", name, err)
+	}
+	return nil
+}
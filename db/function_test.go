@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// errFunction is returned by the test_result function for unknown arguments.
+var errFunction = errors.New("failed")
+
+// registerTestFunctions registers the functions of TestRegisterFunction once, as functions
+// cannot be registered again in the same process, e.g. with go test -count.
+var registerTestFunctions = sync.OnceValue(func() error {
+	err := RegisterFunction("regexp", 2, func(args []any) (any, error) {
+		pattern, _ := args[0].(string)
+		value, _ := args[1].(string)
+		return regexp.MatchString(pattern, value)
+	})
+	if err != nil {
+		return err
+	}
+	err = RegisterDeterministicFunction("test_join", -1, func(args []any) (any, error) {
+		var parts []string
+		for _, arg := range args {
+			switch arg := arg.(type) {
+			case nil:
+				parts = append(parts, "NULL")
+			case []byte:
+				parts = append(parts, "blob:"+string(arg))
+			case string:
+				parts = append(parts, arg)
+			case int64:
+				parts = append(parts, "int")
+			case float64:
+				parts = append(parts, "float")
+			}
+		}
+		return strings.Join(parts, ","), nil
+	})
+	if err != nil {
+		return err
+	}
+	return RegisterFunction("test_result", 1, func(args []any) (any, error) {
+		switch args[0].(string) {
+		case "int":
+			return 7, nil
+		case "bool":
+			return true, nil
+		case "time":
+			return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), nil
+		default:
+			return nil, errFunction
+		}
+	})
+})
+
+func TestRegisterFunction(t *testing.T) {
+	ctx := context.Background()
+	if err := registerTestFunctions(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := openTestDB(t)
+	mustExec(t, d, "CREATE TABLE t (name TEXT)", "INSERT INTO t VALUES ('ann'), ('bob'), ('anna')",
+		"CREATE INDEX t_joined ON t (test_join(name, 'x'))")
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT group_concat(name) FROM t WHERE name REGEXP '^an'", "ann,anna"},
+		{"SELECT test_join(NULL, X'6162', 'c', 1, 1.5)", "NULL,blob:ab,c,int,float"},
+		{"SELECT test_join()", ""},
+		{"SELECT typeof(test_result('int')) || ':' || test_result('int')", "integer:7"},
+		{"SELECT test_result('bool')", "1"},
+		{"SELECT count(*) FROM t WHERE test_join(name, 'x') = 'bob,x'", "1"},
+	}
+	for _, tt := range tests {
+		var got string
+		if err := d.QueryRowContext(ctx, tt.query).Scan(&got); err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	var unix int64
+	if err := d.QueryRowContext(ctx, "SELECT test_result('time')").Scan(&unix); err != nil || unix != 1704164645 {
+		t.Errorf("time result = %d, %v, want Unix seconds", unix, err)
+	}
+	if _, err := d.ExecContext(ctx, "SELECT test_result('error')"); err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Errorf("failing function returned %v, want its error", err)
+	}
+
+	// Connections already open would miss a function registered now.
+	err := RegisterFunction("test_late", 0, func(args []any) (any, error) { return nil, nil })
+	if err == nil {
+		t.Error("RegisterFunction with an open database succeeded")
+	}
+}
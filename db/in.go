@@ -0,0 +1,110 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// In expands slice arguments into one placeholder per element and flattens them into the returned args:
+//
+//	query, args, err := db.In("SELECT * FROM users WHERE id IN (?) AND active = ?", ids, true)
+//	rows, err := db.QueryContext(ctx, query, args...)
+//
+// Byte slices and driver.Valuer implementations are bound as single values. An empty slice expands
+// to an empty list, which SQLite accepts ("id IN ()" matches nothing). Only anonymous ? placeholders
+// are supported; placeholders inside string literals and comments are ignored.
+func In(query string, args ...any) (string, []any, error) {
+	var b strings.Builder
+	expanded := make([]any, 0, len(args))
+	argIndex := 0
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				end = len(query) - i - 2
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 1
+			continue
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			b.WriteString(query[i : i+end])
+			i += end - 1
+			continue
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i:], "*/")
+			if end < 0 {
+				end = len(query) - i - 2
+			}
+			b.WriteString(query[i : i+end+2])
+			i += end + 1
+			continue
+		case c != '?':
+			b.WriteByte(c)
+			continue
+		}
+
+		if i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9' {
+			return "", nil, fmt.Errorf("numbered placeholders are not supported")
+		}
+		if argIndex >= len(args) {
+			return "", nil, fmt.Errorf("query has more placeholders than args (%d)", len(args))
+		}
+
+		arg := args[argIndex]
+		argIndex++
+
+		values, ok := expandArg(arg)
+		if !ok {
+			b.WriteByte('?')
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		for j, value := range values {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteByte('?')
+			expanded = append(expanded, value)
+		}
+	}
+
+	if argIndex != len(args) {
+		return "", nil, fmt.Errorf("query has %d placeholders but %d args", argIndex, len(args))
+	}
+
+	return b.String(), expanded, nil
+}
+
+// expandArg returns the elements of arg if it is a slice or array that should be expanded.
+func expandArg(arg any) ([]any, bool) {
+	if arg == nil {
+		return nil, false
+	}
+	if _, ok := arg.(driver.Valuer); ok {
+		return nil, false
+	}
+
+	v := reflect.ValueOf(arg)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+
+	values := make([]any, v.Len())
+	for i := range values {
+		values[i] = v.Index(i).Interface()
+	}
+	return values, true
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// csv is a slice bound as a single comma-separated value.
+type csv []string
+
+func (c csv) Value() (driver.Value, error) {
+	return strings.Join(c, ","), nil
+}
+
+func TestIn(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		args      []any
+		wantQuery string
+		wantArgs  []any
+		wantErr   bool
+	}{
+		{
+			name:      "slice",
+			query:     "SELECT * FROM t WHERE id IN (?) AND active = ?",
+			args:      []any{[]int{1, 2, 3}, true},
+			wantQuery: "SELECT * FROM t WHERE id IN (?, ?, ?) AND active = ?",
+			wantArgs:  []any{1, 2, 3, true},
+		},
+		{
+			name:      "empty slice",
+			query:     "SELECT * FROM t WHERE id IN (?)",
+			args:      []any{[]string{}},
+			wantQuery: "SELECT * FROM t WHERE id IN ()",
+			wantArgs:  []any{},
+		},
+		{
+			name:      "array",
+			query:     "SELECT * FROM t WHERE id IN (?)",
+			args:      []any{[2]string{"a", "b"}},
+			wantQuery: "SELECT * FROM t WHERE id IN (?, ?)",
+			wantArgs:  []any{"a", "b"},
+		},
+		{
+			name:      "string literals",
+			query:     `SELECT '?', "a?", ` + "`b?`" + ` FROM t WHERE id IN (?)`,
+			args:      []any{[]int{1, 2}},
+			wantQuery: `SELECT '?', "a?", ` + "`b?`" + ` FROM t WHERE id IN (?, ?)`,
+			wantArgs:  []any{1, 2},
+		},
+		{
+			name:      "escaped quote",
+			query:     "SELECT 'it''s ?' FROM t WHERE id IN (?)",
+			args:      []any{[]int{1, 2}},
+			wantQuery: "SELECT 'it''s ?' FROM t WHERE id IN (?, ?)",
+			wantArgs:  []any{1, 2},
+		},
+		{
+			name:      "comments",
+			query:     "SELECT * FROM t -- id = ?\nWHERE /* ? */ id IN (?)",
+			args:      []any{[]int{1, 2}},
+			wantQuery: "SELECT * FROM t -- id = ?\nWHERE /* ? */ id IN (?, ?)",
+			wantArgs:  []any{1, 2},
+		},
+		{
+			name:      "trailing comment",
+			query:     "SELECT * FROM t WHERE id = ? -- ?",
+			args:      []any{1},
+			wantQuery: "SELECT * FROM t WHERE id = ? -- ?",
+			wantArgs:  []any{1},
+		},
+		{
+			name:      "bytes",
+			query:     "SELECT * FROM t WHERE data = ?",
+			args:      []any{[]byte("ab")},
+			wantQuery: "SELECT * FROM t WHERE data = ?",
+			wantArgs:  []any{[]byte("ab")},
+		},
+		{
+			name:      "valuer",
+			query:     "SELECT * FROM t WHERE tags = ?",
+			args:      []any{csv{"a", "b"}},
+			wantQuery: "SELECT * FROM t WHERE tags = ?",
+			wantArgs:  []any{csv{"a", "b"}},
+		},
+		{
+			name:      "nil",
+			query:     "SELECT * FROM t WHERE v IS ?",
+			args:      []any{nil},
+			wantQuery: "SELECT * FROM t WHERE v IS ?",
+			wantArgs:  []any{nil},
+		},
+		{
+			name:    "numbered placeholder",
+			query:   "SELECT * FROM t WHERE id = ?1",
+			args:    []any{1},
+			wantErr: true,
+		},
+		{
+			name:    "too few args",
+			query:   "SELECT * FROM t WHERE id = ? AND v = ?",
+			args:    []any{1},
+			wantErr: true,
+		},
+		{
+			name:    "too many args",
+			query:   "SELECT * FROM t WHERE id = ?",
+			args:    []any{1, 2},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := In(tt.query, tt.args...)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %q, want error", query)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query != tt.wantQuery {
+				t.Errorf("got query %q, want %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("got args %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestInQuery(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+	mustExec(t, d, "CREATE TABLE t (id INTEGER PRIMARY KEY)", "INSERT INTO t VALUES (1), (2), (3), (4)")
+
+	query, args, err := In("SELECT id FROM t WHERE id IN (?) ORDER BY id", []int64{2, 4, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := d.QueryContext(ctx, query, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for id, err := range ScanAll[int64](rows) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, id)
+	}
+	if want := []int64{2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
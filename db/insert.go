@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// columnTag describes how a struct field maps to a database column.
+type columnTag struct {
+	// Name is the column name from the db tag, or the snake_case field name.
+	Name string
+	// Field is the Go field name.
+	Field string
+	// Default is the value from the default tag option, nil if the field has none.
+	Default *string
+	// OmitZero reports that a zero value is left out of inserts so SQLite applies
+	// the column default, e.g. for INTEGER PRIMARY KEY columns.
+	OmitZero bool
+	// Split is the separator from the split tag option, nil if the field has none. A slice field
+	// with a separator is scanned from a delimited list such as a GROUP_CONCAT column.
+	Split *string
+}
+
+// parseTag parses a db struct tag of the form "name,omitzero,default=value" or "name,split=sep".
+// The default and split options must come last, their value extends to the end of the tag and may
+// contain commas, so `db:"tags,split=,"` splits on commas.
+func parseTag(field reflect.StructField) columnTag {
+	column := columnTag{Field: field.Name}
+
+	tag := field.Tag.Get("db")
+	name, options, _ := strings.Cut(tag, ",")
+	for options != "" {
+		if value, ok := strings.CutPrefix(options, "default="); ok {
+			column.Default = &value
+			break
+		}
+		if value, ok := strings.CutPrefix(options, "split="); ok {
+			column.Split = &value
+			break
+		}
+		var option string
+		option, options, _ = strings.Cut(options, ",")
+		if option == "omitzero" {
+			column.OmitZero = true
+		}
+	}
+
+	column.Name = name
+	if column.Name == "" {
+		column.Name = toSnakeCase(field.Name)
+	}
+
+	return column
+}
+
+// Insert inserts the struct (or struct pointer) v as a new row of table, mapping fields to columns like ScanAll.
+// Zero-valued fields tagged `db:",default=..."` are inserted with the default value instead,
+// and zero-valued fields tagged `db:",omitzero"` are left out so SQLite applies the column default.
+func Insert(ctx context.Context, table string, v any) (sql.Result, error) {
+	return defaultDB().Insert(ctx, table, v)
+}
+
+// Insert inserts the struct v as a new row of table, see the package-level Insert.
+func (d *DB) Insert(ctx context.Context, table string, v any) (sql.Result, error) {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("type %T is not a struct", v)
+	}
+
+	var names []string
+	var args []any
+	for _, field := range mappingOf(value.Type()).fields {
+		column := field.column
+		fieldValue := value.Field(field.index)
+		arg := fieldValue.Interface()
+		if column.Split != nil && fieldValue.Kind() == reflect.Slice {
+			arg = joinSplit(fieldValue, *column.Split)
+		}
+
+		if fieldValue.IsZero() {
+			if column.OmitZero {
+				continue
+			}
+			if column.Default != nil {
+				var err error
+				arg, err = parseDefault(field.typ, *column.Default)
+				if err != nil {
+					return nil, fmt.Errorf("invalid default for field %s: %w", column.Field, err)
+				}
+			}
+		}
+
+		names = append(names, quoteIdent(column.Name))
+		args = append(args, arg)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdent(table), strings.Join(names, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", "))
+	if len(names) == 0 {
+		query = fmt.Sprintf("INSERT INTO %s DEFAULT VALUES", quoteIdent(table))
+	}
+
+	return d.ExecContext(ctx, query, args...)
+}
+
+// parseDefault converts a default tag value to a value of type t.
+// Pointer types use the value of their element type.
+func parseDefault(t reflect.Type, value string) (any, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(value, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(value, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(value, 64)
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	default:
+		return nil, fmt.Errorf("defaults are not supported for type %v", t)
+	}
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type account struct {
+	ID     int64   `db:"id,omitzero"`
+	Name   string  `db:",default=anonymous"`
+	Role   string  `db:"role,default=member, guest"`
+	Limit  int     `db:",default=10"`
+	Rate   float64 `db:",default=0.5"`
+	Active bool    `db:",default=true"`
+	Note   *string `db:",default=none"`
+}
+
+func TestInsert(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+	mustExec(t, d, "CREATE TABLE accounts (id INTEGER PRIMARY KEY, name TEXT, role TEXT, \"limit\" INTEGER, rate REAL, active INTEGER, note TEXT)")
+
+	admin, none := "admin", "none"
+	tests := []struct {
+		name string
+		in   account
+		want account
+	}{
+		{
+			name: "defaults",
+			in:   account{},
+			want: account{ID: 1, Name: "anonymous", Role: "member, guest", Limit: 10, Rate: 0.5, Active: true, Note: &none},
+		},
+		{
+			name: "set",
+			in:   account{ID: 5, Name: "ann", Role: "owner", Limit: 3, Rate: 2, Active: true, Note: &admin},
+			want: account{ID: 5, Name: "ann", Role: "owner", Limit: 3, Rate: 2, Active: true, Note: &admin},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := d.Insert(ctx, "accounts", &tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			id, err := result.LastInsertId()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Scan[account](d.QueryRowContext(ctx, "SELECT * FROM accounts WHERE id = ?", id))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInsertDefaultValues(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+	mustExec(t, d, "CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT DEFAULT 'x')")
+
+	type row struct {
+		ID int64 `db:"id,omitzero"`
+	}
+	if _, err := d.Insert(ctx, "t", row{}); err != nil {
+		t.Fatal(err)
+	}
+	var v string
+	if err := d.QueryRowContext(ctx, "SELECT v FROM t").Scan(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v != "x" {
+		t.Errorf("got %q, want the column default", v)
+	}
+}
+
+func TestInsertInvalidDefault(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+	mustExec(t, d, "CREATE TABLE t (n INTEGER)")
+
+	type row struct {
+		N int `db:",default=ten"`
+	}
+	if _, err := d.Insert(ctx, "t", row{}); err == nil {
+		t.Error("Insert with an invalid default succeeded")
+	}
+	if _, err := d.Insert(ctx, "t", 1); err == nil {
+		t.Error("Insert of a non-struct succeeded")
+	}
+}
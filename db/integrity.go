@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// IntegrityReport is the result of an integrity check.
+type IntegrityReport struct {
+	// OK reports that no problems were found.
+	OK bool
+	// Quick reports that the report comes from PRAGMA quick_check rather than integrity_check.
+	Quick bool
+	// Problems lists the problems found, one per line of the PRAGMA output.
+	Problems []string
+}
+
+// CheckIntegrity runs PRAGMA integrity_check, which verifies the whole database file
+// including that indexes match their tables. It can take a long time on large databases.
+func CheckIntegrity(ctx context.Context) (IntegrityReport, error) {
+	return defaultDB().CheckIntegrity(ctx)
+}
+
+// CheckIntegrity runs PRAGMA integrity_check, see the package-level CheckIntegrity.
+func (d *DB) CheckIntegrity(ctx context.Context) (IntegrityReport, error) {
+	return d.checkIntegrity(ctx, false)
+}
+
+// QuickCheck runs PRAGMA quick_check, which skips verifying index contents and is much
+// faster than CheckIntegrity.
+func QuickCheck(ctx context.Context) (IntegrityReport, error) {
+	return defaultDB().QuickCheck(ctx)
+}
+
+// QuickCheck runs PRAGMA quick_check, see the package-level QuickCheck.
+func (d *DB) QuickCheck(ctx context.Context) (IntegrityReport, error) {
+	return d.checkIntegrity(ctx, true)
+}
+
+func (d *DB) checkIntegrity(ctx context.Context, quick bool) (IntegrityReport, error) {
+	report := IntegrityReport{Quick: quick}
+
+	pragma := "PRAGMA integrity_check"
+	if quick {
+		pragma = "PRAGMA quick_check"
+	}
+
+	rows, err := d.QueryContext(ctx, pragma)
+	if err != nil {
+		return corruptionReport(report, err)
+	}
+
+	for line, err := range ScanAll[string](rows) {
+		if err != nil {
+			return corruptionReport(report, err)
+		}
+		if line != "ok" {
+			report.Problems = append(report.Problems, line)
+		}
+	}
+
+	report.OK = len(report.Problems) == 0
+	return report, nil
+}
+
+// corruptionReport turns errors caused by a damaged file into a failed report,
+// since SQLite may refuse to run the check at all in that case.
+func corruptionReport(report IntegrityReport, err error) (IntegrityReport, error) {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() & 0xff {
+		case sqlite3.SQLITE_CORRUPT, sqlite3.SQLITE_NOTADB:
+			report.Problems = append(report.Problems, sqliteErr.Error())
+			return report, nil
+		}
+	}
+	return report, fmt.Errorf("failed to check integrity: %w", err)
+}
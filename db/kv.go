@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+)
+
+const kvSchema = `
+CREATE TABLE IF NOT EXISTS _kv (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL,
+	expires_at DATETIME
+)`
+
+// kvNow is the current time in the format of _kv.expires_at, which compares in time order.
+const kvNow = "strftime('%Y-%m-%d %H:%M:%f', 'now')"
+
+// KVEntry is a stored key-value pair as returned by KVList.
+type KVEntry struct {
+	Key       string          `db:"key"`
+	Value     json.RawMessage `db:"value"`
+	ExpiresAt *time.Time      `db:"expires_at"` // nil if the entry never expires
+}
+
+// KVSet stores value as JSON under key, replacing any previous value. A positive ttl makes the
+// entry expire after that duration, zero keeps it until deleted. The _kv table is created on first use,
+// so small state like feature flags, caches and cursors needs no schema of its own:
+//
+//	err := db.KVSet(ctx, "sync:cursor", cursor, 0)
+//	cursor, ok, err := db.KVGet[Cursor](ctx, "sync:cursor")
+func KVSet(ctx context.Context, key string, value any, ttl time.Duration) error {
+	return defaultDB().KVSet(ctx, key, value, ttl)
+}
+
+// KVSet stores value under key, see the package-level KVSet.
+func (d *DB) KVSet(ctx context.Context, key string, value any, ttl time.Duration) error {
+	if err := d.ensureKV(ctx); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode value of %s: %w", key, err)
+	}
+
+	var expires any // NULL for no expiry
+	if ttl > 0 {
+		expires = fmt.Sprintf("+%.3f seconds", ttl.Seconds())
+	}
+	_, err = d.ExecContext(ctx, `INSERT INTO _kv (key, value, expires_at)
+		VALUES (?, ?, strftime('%Y-%m-%d %H:%M:%f', 'now', ?))
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at`,
+		key, string(data), expires)
+	if err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	return nil
+}
+
+// KVGet returns the value stored under key decoded into T, and whether the key exists and has not expired.
+func KVGet[T any](ctx context.Context, key string) (T, bool, error) {
+	var value T
+	ok, err := defaultDB().KVGet(ctx, key, &value)
+	return value, ok, err
+}
+
+// KVGet decodes the value stored under key into dest, see the package-level KVGet.
+func (d *DB) KVGet(ctx context.Context, key string, dest any) (bool, error) {
+	if err := d.ensureKV(ctx); err != nil {
+		return false, err
+	}
+
+	var data string
+	err := d.QueryRowContext(ctx, "SELECT value FROM _kv WHERE key = ? AND (expires_at IS NULL OR expires_at > "+kvNow+")", key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+
+	if err := json.Unmarshal([]byte(data), dest); err != nil {
+		return false, fmt.Errorf("failed to decode value of %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// KVDelete removes key. Deleting a missing key is not an error.
+func KVDelete(ctx context.Context, key string) error {
+	return defaultDB().KVDelete(ctx, key)
+}
+
+// KVDelete removes key, see the package-level KVDelete.
+func (d *DB) KVDelete(ctx context.Context, key string) error {
+	if err := d.ensureKV(ctx); err != nil {
+		return err
+	}
+	if _, err := d.ExecContext(ctx, "DELETE FROM _kv WHERE key = ?", key); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// KVList returns the unexpired entries whose key starts with prefix, ordered by key.
+func KVList(ctx context.Context, prefix string) iter.Seq2[KVEntry, error] {
+	return defaultDB().KVList(ctx, prefix)
+}
+
+// KVList returns the unexpired entries with a key prefix, see the package-level KVList.
+func (d *DB) KVList(ctx context.Context, prefix string) iter.Seq2[KVEntry, error] {
+	if err := d.ensureKV(ctx); err != nil {
+		return func(yield func(KVEntry, error) bool) {
+			yield(KVEntry{}, err)
+		}
+	}
+
+	// instr matches case-sensitively and without wildcards, unlike LIKE.
+	rows, err := d.QueryContext(ctx, `SELECT key, value, expires_at FROM _kv
+		WHERE instr(key, ?) = 1 AND (expires_at IS NULL OR expires_at > `+kvNow+`) ORDER BY key`, prefix)
+	if err != nil {
+		return func(yield func(KVEntry, error) bool) {
+			yield(KVEntry{}, fmt.Errorf("failed to list %s: %w", prefix, err))
+		}
+	}
+	return ScanAll[KVEntry](rows)
+}
+
+// ensureKV creates the _kv table on first use.
+func (d *DB) ensureKV(ctx context.Context) error {
+	if d.kvReady.Load() {
+		return nil
+	}
+	if _, err := d.ExecContext(ctx, kvSchema); err != nil {
+		return fmt.Errorf("failed to create kv table: %w", err)
+	}
+	d.kvReady.Store(true)
+	return nil
+}
+
+// purgeKV deletes expired entries in batches, as a maintenance job. Expired entries are
+// invisible anyway, so it only runs once the store has been used.
+func (d *DB) purgeKV(ctx context.Context) error {
+	if !d.kvReady.Load() {
+		return nil
+	}
+	_, err := d.applyRetention(ctx, retentionPolicy{table: "_kv", column: "expires_at"})
+	return err
+}
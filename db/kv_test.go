@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestKV(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+
+	type flags struct {
+		Beta  bool
+		Limit int
+	}
+	if err := d.KVSet(ctx, "flags", flags{Beta: true, Limit: 3}, 0); err != nil {
+		t.Fatal(err)
+	}
+	var got flags
+	if ok, err := d.KVGet(ctx, "flags", &got); err != nil || !ok || got != (flags{Beta: true, Limit: 3}) {
+		t.Errorf("KVGet = %+v, %v, %v, want the stored value", got, ok, err)
+	}
+
+	if err := d.KVSet(ctx, "flags", flags{Limit: 5}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := d.KVGet(ctx, "flags", &got); err != nil || !ok || got != (flags{Limit: 5}) {
+		t.Errorf("KVGet after replacing = %+v, %v, %v, want the new value", got, ok, err)
+	}
+
+	if err := d.KVDelete(ctx, "flags"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := d.KVGet(ctx, "flags", &got); err != nil || ok {
+		t.Errorf("KVGet after delete = %v, %v, want not found", ok, err)
+	}
+	if err := d.KVDelete(ctx, "missing"); err != nil {
+		t.Errorf("KVDelete of a missing key: %v", err)
+	}
+}
+
+func TestKVExpiry(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+
+	tests := []struct {
+		key   string
+		ttl   time.Duration
+		alive bool
+	}{
+		{"a:forever", 0, true},
+		{"a:short", 50 * time.Millisecond, false},
+		{"a:long", time.Hour, true},
+		{"b:short", 50 * time.Millisecond, false},
+		{"b:forever", 0, true},
+	}
+	for _, tt := range tests {
+		if err := d.KVSet(ctx, tt.key, tt.key, tt.ttl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	for _, tt := range tests {
+		var value string
+		ok, err := d.KVGet(ctx, tt.key, &value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.alive {
+			t.Errorf("KVGet(%q) found = %v, want %v", tt.key, ok, tt.alive)
+		}
+	}
+
+	var keys []string
+	for entry, err := range d.KVList(ctx, "a:") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, entry.Key)
+		switch {
+		case entry.Key == "a:long" && (entry.ExpiresAt == nil || time.Until(*entry.ExpiresAt) < 59*time.Minute):
+			t.Errorf("%s expires at %v, want in an hour", entry.Key, entry.ExpiresAt)
+		case entry.Key != "a:long" && entry.ExpiresAt != nil:
+			t.Errorf("%s expires at %v, want never", entry.Key, entry.ExpiresAt)
+		}
+	}
+	if want := []string{"a:forever", "a:long"}; !slices.Equal(keys, want) {
+		t.Errorf("KVList got %v, want %v", keys, want)
+	}
+
+	// Maintenance purges the expired entries.
+	if err := d.RunMaintenance(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := d.QueryRowContext(ctx, "SELECT count(*) FROM _kv").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("%d entries left after maintenance, want 3", n)
+	}
+}
+
+func TestKVReopened(t *testing.T) {
+	ctx := context.Background()
+	for _, file := range []string{"first.db", "second.db"} {
+		closeDB, err := InitNamed(t.Name(), WithPath(filepath.Join(t.TempDir(), file)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Use(t.Name()).KVSet(ctx, "file", file, 0); err != nil {
+			t.Errorf("KVSet on %s: %v", file, err)
+		}
+		if err := closeDB(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const locksSchema = `
+CREATE TABLE IF NOT EXISTS _locks (
+	name TEXT PRIMARY KEY,
+	owner TEXT NOT NULL,
+	expires_at INTEGER NOT NULL
+)`
+
+const (
+	// lockTTL is how long a lock outlives a crashed holder. Held locks are refreshed well before.
+	lockTTL = 30 * time.Second
+	// lockPollInterval is how often Lock retries a lock held by someone else.
+	lockPollInterval = 100 * time.Millisecond
+)
+
+// heldLock is a lock acquired through this handle, kept alive by a refresh goroutine until released.
+type heldLock struct {
+	// owner identifies the acquisition in the _locks table, so handles sharing a file never
+	// mistake each other's locks for their own.
+	owner string
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// Lock blocks until it acquires the advisory lock name or ctx is done. Locks are rows in the _locks
+// table, so they coordinate every process sharing the database file, e.g. to run a singleton job:
+//
+//	if err := db.Lock(ctx, "nightly-report"); err != nil {
+//		return err
+//	}
+//	defer db.Unlock(ctx, "nightly-report")
+//
+// A lock is held until Unlock, also against other goroutines of the same process and other handles
+// on the same file. While held it is refreshed in the background; closing the database releases it,
+// and if the process dies it expires after 30 seconds.
+func Lock(ctx context.Context, name string) error {
+	return defaultDB().Lock(ctx, name)
+}
+
+// Lock blocks until it acquires the advisory lock name, see the package-level Lock.
+func (d *DB) Lock(ctx context.Context, name string) error {
+	for {
+		ok, err := d.TryLock(ctx, name)
+		if err != nil || ok {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// TryLock acquires the advisory lock name if it is free and reports whether it did, see Lock.
+func TryLock(ctx context.Context, name string) (bool, error) {
+	return defaultDB().TryLock(ctx, name)
+}
+
+// TryLock acquires the advisory lock name if it is free, see the package-level TryLock.
+func (d *DB) TryLock(ctx context.Context, name string) (bool, error) {
+	if err := d.ensureLocks(ctx); err != nil {
+		return false, err
+	}
+
+	d.locksMu.Lock()
+	defer d.locksMu.Unlock()
+	if _, held := d.locks[name]; held {
+		return false, nil
+	}
+
+	owner := rand.Text()
+	now := time.Now()
+	// Take the lock if it is new or expired.
+	result, err := d.ExecContext(ctx, `INSERT INTO _locks (name, owner, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET owner = excluded.owner, expires_at = excluded.expires_at
+		WHERE _locks.expires_at < ?`,
+		name, owner, now.Add(lockTTL).UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	lock := &heldLock{owner: owner, stop: make(chan struct{}), done: make(chan struct{})}
+	if d.locks == nil {
+		d.locks = make(map[string]*heldLock)
+	}
+	d.locks[name] = lock
+	go d.refreshLock(name, lock)
+
+	return true, nil
+}
+
+// Unlock releases the advisory lock name acquired with Lock or TryLock.
+func Unlock(ctx context.Context, name string) error {
+	return defaultDB().Unlock(ctx, name)
+}
+
+// Unlock releases the advisory lock name, see the package-level Unlock.
+func (d *DB) Unlock(ctx context.Context, name string) error {
+	d.locksMu.Lock()
+	defer d.locksMu.Unlock()
+
+	lock, held := d.locks[name]
+	if !held {
+		return fmt.Errorf("lock %s is not held", name)
+	}
+	close(lock.stop)
+	<-lock.done
+	delete(d.locks, name)
+
+	if _, err := d.ExecContext(ctx, "DELETE FROM _locks WHERE name = ? AND owner = ?", name, lock.owner); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", name, err)
+	}
+	return nil
+}
+
+// ensureLocks creates the _locks table on first use.
+func (d *DB) ensureLocks(ctx context.Context) error {
+	if d.locksReady.Load() {
+		return nil
+	}
+	if _, err := d.ExecContext(ctx, locksSchema); err != nil {
+		return fmt.Errorf("failed to create locks table: %w", err)
+	}
+	d.locksReady.Store(true)
+	return nil
+}
+
+// refreshLock extends the expiry of a held lock until it is released. Failed refreshes are
+// retried on the next tick, the lock is only lost if they keep failing until it expires.
+func (d *DB) refreshLock(name string, lock *heldLock) {
+	defer close(lock.done)
+
+	ticker := time.NewTicker(lockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lock.stop:
+			return
+		case <-ticker.C:
+			d.ExecContext(context.Background(), "UPDATE _locks SET expires_at = ? WHERE name = ? AND owner = ?",
+				time.Now().Add(lockTTL).UnixMilli(), name, lock.owner)
+		}
+	}
+}
+
+// releaseLocks stops refreshing all held locks and releases them, for the database to close.
+func (d *DB) releaseLocks() error {
+	d.locksMu.Lock()
+	defer d.locksMu.Unlock()
+
+	var errs []error
+	for name, lock := range d.locks {
+		close(lock.stop)
+		<-lock.done
+		delete(d.locks, name)
+
+		// ExecContext rejects work while the database is closing, so the pool is used directly.
+		if _, err := d.pool.Load().ExecContext(context.Background(), "DELETE FROM _locks WHERE name = ? AND owner = ?", name, lock.owner); err != nil {
+			errs = append(errs, fmt.Errorf("failed to release lock %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
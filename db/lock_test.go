@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+
+	if err := d.Lock(ctx, "job"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := d.TryLock(ctx, "job"); err != nil || ok {
+		t.Errorf("TryLock of a held lock = %v, %v, want false", ok, err)
+	}
+	if err := d.Unlock(ctx, "job"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := d.TryLock(ctx, "job"); err != nil || !ok {
+		t.Errorf("TryLock of a released lock = %v, %v, want true", ok, err)
+	}
+	if err := d.Unlock(ctx, "other"); err == nil {
+		t.Error("Unlock of a lock that is not held succeeded")
+	}
+}
+
+func TestLockReleasedOnClose(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	closeDB, err := InitNamed(t.Name(), WithPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := Use(t.Name())
+	if err := d.Lock(ctx, "job"); err != nil {
+		t.Fatal(err)
+	}
+	if err := closeDB(); err != nil {
+		t.Fatal(err)
+	}
+
+	closeDB, err = InitNamed(t.Name(), WithPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeDB()
+	var n int
+	if err := d.QueryRowContext(ctx, "SELECT count(*) FROM _locks").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("%d locks left after close, want 0", n)
+	}
+}
+
+func TestLockSharedFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	var handles []*DB
+	for _, name := range []string{t.Name() + "/a", t.Name() + "/b"} {
+		closeDB, err := InitNamed(name, WithPath(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { closeDB() })
+		handles = append(handles, Use(name))
+	}
+	a, b := handles[0], handles[1]
+
+	if ok, err := a.TryLock(ctx, "job"); err != nil || !ok {
+		t.Fatalf("TryLock = %v, %v, want true", ok, err)
+	}
+	if ok, err := b.TryLock(ctx, "job"); err != nil || ok {
+		t.Errorf("TryLock of a lock held by another handle = %v, %v, want false", ok, err)
+	}
+	if err := b.Unlock(ctx, "job"); err == nil {
+		t.Error("Unlock of a lock held by another handle succeeded")
+	}
+	if err := a.Unlock(ctx, "job"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := b.TryLock(ctx, "job"); err != nil || !ok {
+		t.Errorf("TryLock after the other handle unlocked = %v, %v, want true", ok, err)
+	}
+}
+
+func TestLockReopened(t *testing.T) {
+	ctx := context.Background()
+	for _, file := range []string{"first.db", "second.db"} {
+		closeDB, err := InitNamed(t.Name(), WithPath(filepath.Join(t.TempDir(), file)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := Use(t.Name())
+		if err := d.Lock(ctx, "job"); err != nil {
+			t.Fatalf("Lock on %s: %v", file, err)
+		}
+		if err := closeDB(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
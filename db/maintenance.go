@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// retentionBatchSize is the number of expired rows deleted per statement, small enough
+// to keep each write lock short.
+const retentionBatchSize = 500
+
+// retentionPolicy deletes rows of table whose column is older than maxAge.
+type retentionPolicy struct {
+	table  string
+	column string
+	maxAge time.Duration
+}
+
+// maintenance holds the jobs run by RunMaintenance and the background scheduler started with WithMaintenance.
+type maintenance struct {
+	mu        sync.Mutex
+	retention []retentionPolicy
+
+	// cancel stops the scheduler, done is closed once it has returned.
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Retention registers a retention policy deleting rows of table whose column is older than maxAge,
+// e.g. db.Retention("sessions", "created_at", 30*24*time.Hour). Policies run with every RunMaintenance,
+// including the scheduled runs enabled by WithMaintenance. Expired rows are deleted in batches of 500,
+// each in its own statement, so other writers are never blocked for long.
+//
+// column must hold UTC timestamps in SQLite text format, as written by CURRENT_TIMESTAMP or
+// strftime, so they compare in time order. Registering a table again replaces its policy.
+func Retention(table, column string, maxAge time.Duration) {
+	defaultDB().Retention(table, column, maxAge)
+}
+
+// Retention registers a retention policy for table, see the package-level Retention.
+func (d *DB) Retention(table, column string, maxAge time.Duration) {
+	d.maintenance.mu.Lock()
+	defer d.maintenance.mu.Unlock()
+
+	policy := retentionPolicy{table: table, column: column, maxAge: maxAge}
+	for i, p := range d.maintenance.retention {
+		if p.table == table {
+			d.maintenance.retention[i] = policy
+			return
+		}
+	}
+	d.maintenance.retention = append(d.maintenance.retention, policy)
+}
+
+// RunMaintenance runs all maintenance jobs once: the registered retention policies and purging
+// expired KVSet entries. A failing job does not stop the others, all errors are returned joined.
+func RunMaintenance(ctx context.Context) error {
+	return defaultDB().RunMaintenance(ctx)
+}
+
+// RunMaintenance runs all registered maintenance jobs once, see the package-level RunMaintenance.
+func (d *DB) RunMaintenance(ctx context.Context) error {
+	if err := d.checkOpen(); err != nil {
+		return err
+	}
+
+	d.maintenance.mu.Lock()
+	policies := append([]retentionPolicy(nil), d.maintenance.retention...)
+	d.maintenance.mu.Unlock()
+
+	var errs []error
+	for _, p := range policies {
+		if _, err := d.applyRetention(ctx, p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := d.purgeKV(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// applyRetention deletes the expired rows of a policy in batches and returns the number deleted.
+func (d *DB) applyRetention(ctx context.Context, p retentionPolicy) (int64, error) {
+	cutoff := time.Now().UTC().Add(-p.maxAge).Format("2006-01-02 15:04:05.000")
+	query := fmt.Sprintf("DELETE FROM %s WHERE rowid IN (SELECT rowid FROM %s WHERE %s < ? LIMIT ?)",
+		quoteIdent(p.table), quoteIdent(p.table), quoteIdent(p.column))
+
+	var deleted int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+
+		result, err := d.ExecContext(ctx, query, cutoff, retentionBatchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to apply retention on %s: %w", p.table, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return deleted, fmt.Errorf("failed to apply retention on %s: %w", p.table, err)
+		}
+
+		deleted += n
+		if n < retentionBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// startMaintenance runs RunMaintenance every interval until stopMaintenance is called.
+func (d *DB) startMaintenance(interval time.Duration, onError func(err error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	d.maintenance.cancel = cancel
+	d.maintenance.done = done
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := d.RunMaintenance(ctx); err != nil && ctx.Err() == nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}()
+}
+
+// stopMaintenance stops the scheduler, if running, and waits for a running job to return.
+func (d *DB) stopMaintenance() {
+	if d.maintenance.cancel == nil {
+		return
+	}
+	d.maintenance.cancel()
+	<-d.maintenance.done
+	d.maintenance.cancel = nil
+	d.maintenance.done = nil
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRetention(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+	mustExec(t, d, "CREATE TABLE sessions (id TEXT, created_at TEXT)")
+
+	tests := []struct {
+		id        string
+		createdAt string // SQLite expression
+		expired   bool
+	}{
+		{"day old", "datetime('now', '-1 day')", true},
+		{"two hours old", "datetime('now', '-2 hours')", true},
+		{"old with milliseconds", "strftime('%Y-%m-%d %H:%M:%f', 'now', '-61 minutes')", true},
+		{"new with milliseconds", "strftime('%Y-%m-%d %H:%M:%f', 'now', '-59 minutes')", false},
+		{"half an hour old", "datetime('now', '-30 minutes')", false},
+		{"current", "CURRENT_TIMESTAMP", false},
+		{"future", "datetime('now', '+1 day')", false},
+	}
+	var want []string
+	for _, tt := range tests {
+		mustExec(t, d, "INSERT INTO sessions VALUES ('"+tt.id+"', "+tt.createdAt+")")
+		if !tt.expired {
+			want = append(want, tt.id)
+		}
+	}
+	// More expired rows than one batch deletes.
+	mustExec(t, d, "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 1200) "+
+		"INSERT INTO sessions SELECT 'batch', datetime('now', '-3 hours') FROM n")
+
+	d.Retention("sessions", "created_at", time.Hour)
+	if err := d.RunMaintenance(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := d.QueryContext(ctx, "SELECT id FROM sessions ORDER BY rowid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for id, err := range ScanAll[string](rows) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, id)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("kept %v, want %v", got, want)
+	}
+}
+
+func TestMaintenanceScheduled(t *testing.T) {
+	errs := make(chan error, 1)
+	d := openTestDB(t, WithMaintenance(10*time.Millisecond, func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}))
+
+	// A policy on a missing table fails every run, reported to the error callback.
+	d.Retention("missing", "created_at", time.Hour)
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("got nil error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduled maintenance did not run")
+	}
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fieldMapping is the cached mapping of one exported struct field.
+type fieldMapping struct {
+	index  int
+	typ    reflect.Type
+	column columnTag
+	// nullable reports that the field is scanned through scanTarget and assign.
+	nullable bool
+}
+
+// structMapping is the cached column mapping of a struct type.
+type structMapping struct {
+	fields   []fieldMapping // exported fields in declaration order
+	byColumn map[string]int // column name → index in fields
+}
+
+// mappings holds the *structMapping of every struct type scanned or inserted so far, keyed by reflect.Type.
+var mappings sync.Map
+
+// RegisterType computes the column mapping of struct type T ahead of time, e.g. in an init function
+// of generated code, so the first scan or insert of T does not have to. Types are otherwise mapped
+// on first use; either way tags are parsed once per type, not per row.
+func RegisterType[T any]() error {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("type %v is not a struct", t)
+	}
+	mappingOf(t)
+	return nil
+}
+
+// mappingOf returns the cached mapping of struct type t, computing it on first use.
+func mappingOf(t reflect.Type) *structMapping {
+	if m, ok := mappings.Load(t); ok {
+		return m.(*structMapping)
+	}
+	actual, _ := mappings.LoadOrStore(t, newStructMapping(t))
+	return actual.(*structMapping)
+}
+
+// newStructMapping computes the mapping of struct type t from its fields and tags.
+func newStructMapping(t reflect.Type) *structMapping {
+	m := &structMapping{byColumn: map[string]int{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		column := parseTag(field)
+		m.byColumn[column.Name] = len(m.fields)
+		m.fields = append(m.fields, fieldMapping{
+			index:    i,
+			typ:      field.Type,
+			column:   column,
+			nullable: column.Split != nil || nullableTarget(field.Type) != nil,
+		})
+	}
+	return m
+}
+
+// columnFields returns the field of struct type t each result column is scanned into,
+// nil for columns without a matching field. It is computed once per result set.
+func columnFields(t reflect.Type, columns []string) []*fieldMapping {
+	m := mappingOf(t)
+	fields := make([]*fieldMapping, len(columns))
+	for i, column := range columns {
+		if j, ok := m.byColumn[column]; ok {
+			fields[i] = &m.fields[j]
+		}
+	}
+	return fields
+}
+
+// scanTarget returns the scan destination of a nullable field.
+func (f *fieldMapping) scanTarget() any {
+	if f.column.Split != nil {
+		return new(sql.NullString)
+	}
+	return nullableTarget(f.typ)
+}
+
+// assign stores a value scanned into the scanTarget destination in fieldValue.
+func (f *fieldMapping) assign(fieldValue reflect.Value, scanned any) error {
+	if f.column.Split != nil {
+		return assignSplit(fieldValue, scanned.(*sql.NullString).String, *f.column.Split)
+	}
+	return assignNullable(fieldValue, scanned)
+}
+
+// assignSplit fills the slice fieldValue from the sep-delimited list s, e.g. a GROUP_CONCAT column.
+// NULL and the empty string become a nil slice.
+func assignSplit(fieldValue reflect.Value, s, sep string) error {
+	if fieldValue.Kind() != reflect.Slice {
+		return fmt.Errorf("split is not supported for type %v", fieldValue.Type())
+	}
+	if s == "" {
+		fieldValue.SetZero()
+		return nil
+	}
+
+	parts := strings.Split(s, sep)
+	slice := reflect.MakeSlice(fieldValue.Type(), len(parts), len(parts))
+	for i, part := range parts {
+		elem := slice.Index(i)
+		var err error
+		switch elem.Kind() {
+		case reflect.String:
+			elem.SetString(part)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			var n int64
+			n, err = strconv.ParseInt(part, 10, elem.Type().Bits())
+			elem.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			var n uint64
+			n, err = strconv.ParseUint(part, 10, elem.Type().Bits())
+			elem.SetUint(n)
+		case reflect.Float32, reflect.Float64:
+			var n float64
+			n, err = strconv.ParseFloat(part, elem.Type().Bits())
+			elem.SetFloat(n)
+		case reflect.Bool:
+			var b bool
+			b, err = strconv.ParseBool(part)
+			elem.SetBool(b)
+		default:
+			return fmt.Errorf("split is not supported for type %v", fieldValue.Type())
+		}
+		if err != nil {
+			return fmt.Errorf("invalid element %q: %w", part, err)
+		}
+	}
+
+	fieldValue.Set(slice)
+	return nil
+}
+
+// joinSplit joins the elements of the slice fieldValue with sep, the inverse of assignSplit.
+// A nil or empty slice becomes NULL.
+func joinSplit(fieldValue reflect.Value, sep string) any {
+	if fieldValue.Len() == 0 {
+		return nil
+	}
+	parts := make([]string, fieldValue.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(fieldValue.Index(i).Interface())
+	}
+	return strings.Join(parts, sep)
+}
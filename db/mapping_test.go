@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type benchRow struct {
+	ID        int64  `db:"id"`
+	Name      string `db:"name"`
+	Email     string
+	Score     float64
+	Active    bool
+	CreatedAt int64
+	Notes     *string
+	Tags      []string `db:"tags,split=,"`
+}
+
+// BenchmarkMapping compares looking up the cached mapping of a struct type with computing it,
+// which scanning did for every row before mappings were cached.
+func BenchmarkMapping(b *testing.B) {
+	t := reflect.TypeFor[benchRow]()
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			mappingOf(t)
+		}
+	})
+	b.Run("computed", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			newStructMapping(t)
+		}
+	})
+}
+
+// BenchmarkScanAll measures scanning a result set of 1000 rows into structs.
+func BenchmarkScanAll(b *testing.B) {
+	ctx := context.Background()
+	d := openTestDB(b)
+	if _, err := d.ExecContext(ctx, `CREATE TABLE rows (id INTEGER PRIMARY KEY, name TEXT, email TEXT,
+		score REAL, active INTEGER, created_at INTEGER, notes TEXT, tags TEXT)`); err != nil {
+		b.Fatal(err)
+	}
+	err := d.WithTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		for i := range 1000 {
+			_, err := tx.ExecContext(ctx, "INSERT INTO rows VALUES (?, 'name', 'name@example.com', 1.5, 1, 0, NULL, 'a,b,c')", i)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		rows, err := d.QueryContext(ctx, "SELECT * FROM rows")
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, err := range ScanAll[benchRow](rows) {
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestScanSplit(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+
+	type post struct {
+		Tags   []string  `db:"tags,split=,"`
+		TagIDs []int64   `db:"tag_ids,split=|"`
+		Scores []float64 `db:"scores,split=, "`
+	}
+	tests := []struct {
+		query   string
+		want    post
+		wantErr bool
+	}{
+		{query: "SELECT 'a,b' AS tags, '1|2|3' AS tag_ids, '1.5, 2' AS scores", want: post{Tags: []string{"a", "b"}, TagIDs: []int64{1, 2, 3}, Scores: []float64{1.5, 2}}},
+		{query: "SELECT 'a' AS tags, 7 AS tag_ids, '' AS scores", want: post{Tags: []string{"a"}, TagIDs: []int64{7}}},
+		{query: "SELECT NULL AS tags, NULL AS tag_ids, NULL AS scores", want: post{}},
+		{query: "SELECT 'a,,b' AS tags, NULL AS tag_ids, NULL AS scores", want: post{Tags: []string{"a", "", "b"}}},
+		{query: "SELECT NULL AS tags, '1|x' AS tag_ids, NULL AS scores", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Scan[post](d.QueryRowContext(ctx, tt.query))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Scan of %q got %+v, want error", tt.query, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Scan of %q: %v", tt.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Scan of %q got %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestInsertSplit(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t)
+	mustExec(t, d, "CREATE TABLE posts (id INTEGER PRIMARY KEY, tags TEXT)")
+
+	type post struct {
+		ID   int64    `db:"id"`
+		Tags []string `db:"tags,split=,"`
+	}
+	for _, p := range []post{{ID: 1, Tags: []string{"go", "sql"}}, {ID: 2}} {
+		if _, err := d.Insert(ctx, "posts", p); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Scan[post](d.QueryRowContext(ctx, "SELECT * FROM posts WHERE id = ?", p.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, p) {
+			t.Errorf("got %+v, want %+v", got, p)
+		}
+	}
+
+	var isNull bool
+	if err := d.QueryRowContext(ctx, "SELECT tags IS NULL FROM posts WHERE id = 2").Scan(&isNull); err != nil || !isNull {
+		t.Errorf("empty slice stored as %v (%v), want NULL", isNull, err)
+	}
+}
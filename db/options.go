@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+// config holds the settings applied by Init.
+type config struct {
+	path            string
+	pragmas         []string
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxLifetime time.Duration
+	closeTimeout    time.Duration
+	writeQueueSize  int
+	fullScanMinRows int64
+	fullScanHook    func(ctx context.Context, query, table string, rows int64)
+	integrityCheck  func(report IntegrityReport)
+	integrityQuick  bool
+	queryHook       func(ctx context.Context, event QueryEvent)
+
+	maintenanceInterval time.Duration
+	maintenanceError    func(err error)
+
+	cacheEntries int
+	queryStats   bool
+}
+
+// Option configures Init. Settings that are not given fall back to environment variables.
+type Option func(*config)
+
+// WithPath sets the database file path. Parent directories are created if needed.
+// Defaults to ./data/$APP_NAME.db.
+func WithPath(path string) Option {
+	return func(c *config) {
+		c.path = path
+	}
+}
+
+// WithPragma runs PRAGMA name = value on every new connection, e.g. WithPragma("journal_mode", "WAL").
+// It may be given multiple times.
+func WithPragma(name, value string) Option {
+	return func(c *config) {
+		c.pragmas = append(c.pragmas, name+"("+value+")")
+	}
+}
+
+// WithPool sets the maximum number of open and idle connections. Zero keeps the database/sql defaults.
+func WithPool(maxOpen, maxIdle int) Option {
+	return func(c *config) {
+		c.maxOpenConns = maxOpen
+		c.maxIdleConns = maxIdle
+	}
+}
+
+// WithConnMaxLifetime sets the maximum amount of time a connection may be reused.
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(c *config) {
+		c.connMaxLifetime = d
+	}
+}
+
+// WithCloseTimeout sets how long the close function waits for in-flight work.
+// Defaults to $DB_CLOSE_TIMEOUT, or 5s if unset.
+func WithCloseTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.closeTimeout = d
+	}
+}
+
+// WithWriteQueue enables the write queue with the given size, see EnableWriteQueue.
+func WithWriteQueue(size int) Option {
+	return func(c *config) {
+		c.writeQueueSize = size
+	}
+}
+
+// WithFullScanWarning calls hook when a query reads a table of at least minRows rows
+// without using an index. Each distinct query string is explained once, the first time it runs
+// through QueryContext, QueryRowContext or ExecContext; of more than 10000 distinct queries, the
+// least recently run are explained again. The row count is estimated from max(rowid).
+func WithFullScanWarning(minRows int64, hook func(ctx context.Context, query, table string, rows int64)) Option {
+	return func(c *config) {
+		c.fullScanMinRows = minRows
+		c.fullScanHook = hook
+	}
+}
+
+// WithIntegrityCheck runs an integrity check when the database is opened and calls onCorruption
+// if it finds problems. Opening still succeeds so the application can decide how to react.
+// With quick set, PRAGMA quick_check is used instead of the slower full integrity_check.
+func WithIntegrityCheck(quick bool, onCorruption func(report IntegrityReport)) Option {
+	return func(c *config) {
+		c.integrityCheck = onCorruption
+		c.integrityQuick = quick
+	}
+}
+
+// WithMaintenance runs the registered maintenance jobs, such as retention policies, every interval
+// in the background until the database is closed. Errors of a run are passed to onError, which may be nil.
+func WithMaintenance(interval time.Duration, onError func(err error)) Option {
+	return func(c *config) {
+		c.maintenanceInterval = interval
+		c.maintenanceError = onError
+	}
+}
+
+// WithQueryCache limits the number of results kept by QueryCached, 1000 by default.
+func WithQueryCache(maxEntries int) Option {
+	return func(c *config) {
+		c.cacheEntries = maxEntries
+	}
+}
+
+// WithQueryStats collects statistics per query fingerprint, see QueryStats.
+func WithQueryStats() Option {
+	return func(c *config) {
+		c.queryStats = true
+	}
+}
+
+// loadConfig applies opts for the database called name and fills the remaining settings from the environment.
+func loadConfig(name string, opts []Option) (config, error) {
+	c := config{closeTimeout: -1}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if c.path == "" {
+		appName := os.Getenv("APP_NAME")
+		if appName == "" {
+			return c, fmt.Errorf("APP_NAME environment variable is required")
+		}
+		c.path = "./data/" + appName + ".db"
+		if name != defaultName {
+			c.path = "./data/" + appName + "-" + name + ".db"
+		}
+	}
+
+	if c.closeTimeout < 0 {
+		c.closeTimeout = 5 * time.Second
+		if v := os.Getenv("DB_CLOSE_TIMEOUT"); v != "" {
+			timeout, err := time.ParseDuration(v)
+			if err != nil {
+				return c, fmt.Errorf("invalid DB_CLOSE_TIMEOUT: %w", err)
+			}
+			c.closeTimeout = timeout
+		}
+	}
+
+	return c, nil
+}
+
+// openPool opens a connection pool for c with additional driver DSN parameters given as key, value pairs.
+func openPool(c config, params ...string) (*sql.DB, error) {
+	query := url.Values{}
+	for _, pragma := range c.pragmas {
+		query.Add("_pragma", pragma)
+	}
+	for i := 0; i+1 < len(params); i += 2 {
+		query.Add(params[i], params[i+1])
+	}
+
+	dsn := c.path
+	if len(query) > 0 {
+		dsn += "?" + query.Encode()
+	}
+
+	pool, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.maxOpenConns > 0 {
+		pool.SetMaxOpenConns(c.maxOpenConns)
+	}
+	if c.maxIdleConns > 0 {
+		pool.SetMaxIdleConns(c.maxIdleConns)
+	}
+	if c.connMaxLifetime > 0 {
+		pool.SetConnMaxLifetime(c.connMaxLifetime)
+	}
+
+	return pool, nil
+}
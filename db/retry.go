@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// RetryPolicy controls how WithTxRetry re-runs a transaction that failed with a busy or conflict error.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries.
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after each retry.
+	Multiplier float64
+	// TxOptions start each attempt, e.g. TxImmediate.Options(), see WithTxOptions.
+	TxOptions *sql.TxOptions
+}
+
+// DefaultRetryPolicy retries up to 4 times with a delay doubling from 10ms to at most 1s.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 10 * time.Millisecond,
+	MaxBackoff:     time.Second,
+	Multiplier:     2,
+}
+
+var txRetries atomic.Int64
+
+// TxRetries returns the total number of transaction retries performed by WithTxRetry.
+func TxRetries() int64 {
+	return txRetries.Load()
+}
+
+// IsBusy reports whether err was caused by SQLite being unable to acquire a lock
+// (SQLITE_BUSY or SQLITE_LOCKED), meaning the operation may succeed when retried.
+func IsBusy(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	// Mask extended result codes such as SQLITE_BUSY_SNAPSHOT down to the primary code.
+	switch sqliteErr.Code() & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return true
+	default:
+		return false
+	}
+}
+
+// WithTxRetry runs fn in a transaction like WithTx, re-running the whole transaction when it fails
+// with a busy or conflict error (see IsBusy). Delays between attempts grow exponentially according
+// to policy, and each retry is counted in TxRetries. fn must therefore be safe to run more than once.
+// When ctx already carries a transaction, fn runs once inside a savepoint, since only the outermost
+// transaction can be retried.
+func WithTxRetry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return defaultDB().WithTxRetry(ctx, policy, fn)
+}
+
+// WithTxRetry runs fn in a transaction on the database, retrying on busy or conflict errors,
+// see the package-level WithTxRetry.
+func (d *DB) WithTxRetry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	if _, ok := d.txFromContext(ctx); ok {
+		return d.WithTxOptions(ctx, policy.TxOptions, fn)
+	}
+
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := d.WithTxOptions(ctx, policy.TxOptions, fn)
+		if err == nil || !IsBusy(err) || attempt >= policy.MaxAttempts {
+			return err
+		}
+
+		txRetries.Add(1)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		}
+
+		backoff = time.Duration(float64(backoff) * policy.Multiplier)
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
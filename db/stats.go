@@ -0,0 +1,231 @@
+package db
+
+import (
+	"cmp"
+	"database/sql"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"weak"
+)
+
+// statsSamples is the number of most recent durations kept per fingerprint for P95.
+const statsSamples = 1024
+
+// QueryStat summarizes the statements sharing a fingerprint, see QueryStats.
+type QueryStat struct {
+	// Fingerprint is the normalized statement, with literals replaced by ? and IN lists collapsed.
+	Fingerprint string
+	Count       int64
+	Errors      int64
+	// Total, Mean and P95 are measured like QueryEvent.Duration. P95 covers the 1024 most recent runs.
+	Total time.Duration
+	Mean  time.Duration
+	P95   time.Duration
+	// Rows counts rows changed by writes and rows read through ScanAll and ScanChunks.
+	Rows int64
+}
+
+// queryStats holds the per-fingerprint statistics enabled with WithQueryStats.
+type queryStats struct {
+	mu    sync.Mutex
+	stats map[string]*queryStat
+}
+
+type queryStat struct {
+	QueryStat
+	samples []time.Duration // ring buffer of recent durations
+	next    int
+}
+
+// scannedRows maps the rows returned by QueryContext to the statistics their scanned rows are
+// counted in. Keys are weak, so rows that are never scanned do not keep their entry alive.
+var scannedRows sync.Map // weak.Pointer[sql.Rows] → *trackedRows
+
+// QueryStats returns statistics per query fingerprint, slowest in total first, so the queries
+// worth optimizing come first. Statistics are only collected with the WithQueryStats Init option.
+func QueryStats() []QueryStat {
+	return defaultDB().QueryStats()
+}
+
+// QueryStats returns statistics per query fingerprint, see the package-level QueryStats.
+func (d *DB) QueryStats() []QueryStat {
+	d.stats.mu.Lock()
+	defer d.stats.mu.Unlock()
+
+	result := make([]QueryStat, 0, len(d.stats.stats))
+	for _, stat := range d.stats.stats {
+		s := stat.QueryStat
+		s.Mean = s.Total / time.Duration(s.Count)
+		samples := slices.Clone(stat.samples)
+		slices.Sort(samples)
+		s.P95 = samples[(len(samples)*95+99)/100-1]
+		result = append(result, s)
+	}
+	slices.SortFunc(result, func(a, b QueryStat) int {
+		return cmp.Compare(b.Total, a.Total)
+	})
+	return result
+}
+
+// ResetQueryStats clears the collected statistics.
+func ResetQueryStats() {
+	defaultDB().ResetQueryStats()
+}
+
+// ResetQueryStats clears the collected statistics, see the package-level ResetQueryStats.
+func (d *DB) ResetQueryStats() {
+	d.stats.mu.Lock()
+	defer d.stats.mu.Unlock()
+	d.stats.stats = nil
+}
+
+// record adds a run of query to the statistics of its fingerprint and returns them.
+func (s *queryStats) record(query string, duration time.Duration, err error) *queryStat {
+	fp := fingerprint(query)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats == nil {
+		s.stats = map[string]*queryStat{}
+	}
+	stat, ok := s.stats[fp]
+	if !ok {
+		stat = &queryStat{QueryStat: QueryStat{Fingerprint: fp}}
+		s.stats[fp] = stat
+	}
+
+	stat.Count++
+	stat.Total += duration
+	if err != nil {
+		stat.Errors++
+	}
+	if len(stat.samples) < statsSamples {
+		stat.samples = append(stat.samples, duration)
+	} else {
+		stat.samples[stat.next] = duration
+		stat.next = (stat.next + 1) % statsSamples
+	}
+	return stat
+}
+
+// addRows counts n rows for stat, which may be nil when statistics are disabled.
+func (s *queryStats) addRows(stat *queryStat, n int64) {
+	if stat == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stat.Rows += n
+}
+
+// addResult counts the rows changed by a write.
+func (s *queryStats) addResult(stat *queryStat, result sql.Result) {
+	if stat == nil || result == nil {
+		return
+	}
+	if n, err := result.RowsAffected(); err == nil {
+		s.addRows(stat, n)
+	}
+}
+
+// trackRows counts the rows later read from rows through ScanAll or ScanChunks in stat.
+func (s *queryStats) trackRows(rows *sql.Rows, stat *queryStat) {
+	if stat == nil || rows == nil {
+		return
+	}
+	key := weak.Make(rows)
+	scannedRows.Store(key, &trackedRows{stats: s, stat: stat})
+	runtime.AddCleanup(rows, func(key weak.Pointer[sql.Rows]) {
+		scannedRows.Delete(key)
+	}, key)
+}
+
+type trackedRows struct {
+	stats *queryStats
+	stat  *queryStat
+}
+
+// countScanned records n rows scanned from rows, if they are tracked.
+func countScanned(rows *sql.Rows, n int64) {
+	if tracked, ok := scannedRows.LoadAndDelete(weak.Make(rows)); ok {
+		t := tracked.(*trackedRows)
+		t.stats.addRows(t.stat, n)
+	}
+}
+
+// fingerprint normalizes query so runs with different literals, IN list lengths, whitespace
+// and annotations are counted together.
+func fingerprint(query string) string {
+	var b strings.Builder
+	space := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			// Skip the literal, '' is an escaped quote inside it.
+			for i++; i < len(query); i++ {
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			c = '?'
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end - 1
+			space = true
+			continue
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i:], "*/")
+			if end < 0 {
+				end = len(query) - i - 2
+			}
+			i += end + 1
+			space = true
+			continue
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			continue
+		case c >= '0' && c <= '9' && (space || !isIdentByte(lastByte(&b))):
+			for i+1 < len(query) && (isIdentByte(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			c = '?'
+		}
+
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteByte(c)
+	}
+
+	// Collapse lists of placeholders, e.g. from In, to a single one.
+	fp := b.String()
+	for strings.Contains(fp, "?, ?") || strings.Contains(fp, "?,?") {
+		fp = strings.ReplaceAll(strings.ReplaceAll(fp, "?, ?", "?"), "?,?", "?")
+	}
+	return strings.TrimRight(fp, "; ")
+}
+
+func lastByte(b *strings.Builder) byte {
+	s := b.String()
+	if s == "" {
+		return 0
+	}
+	return s[len(s)-1]
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
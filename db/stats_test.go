@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM users WHERE id = 1", "SELECT * FROM users WHERE id = ?"},
+		{"SELECT * FROM users WHERE id = 42", "SELECT * FROM users WHERE id = ?"},
+		{"SELECT *\n\tFROM users  WHERE id = ?;", "SELECT * FROM users WHERE id = ?"},
+		{"SELECT * FROM users WHERE name = 'it''s' AND score > 1.5e3", "SELECT * FROM users WHERE name = ? AND score > ?"},
+		{"SELECT * FROM users WHERE id IN (1, 2, 3)", "SELECT * FROM users WHERE id IN (?)"},
+		{"SELECT * FROM users WHERE id IN (?, ?,?)", "SELECT * FROM users WHERE id IN (?)"},
+		{"SELECT * FROM users /* app=api */ WHERE id = 1 -- trace", "SELECT * FROM users WHERE id = ?"},
+		{"SELECT * FROM t2 JOIN users_v2 ON t2.a = users_v2.b", "SELECT * FROM t2 JOIN users_v2 ON t2.a = users_v2.b"},
+		{"SELECT x'00', -5 FROM t", "SELECT x?, -? FROM t"},
+	}
+	for _, tt := range tests {
+		if got := fingerprint(tt.query); got != tt.want {
+			t.Errorf("fingerprint(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQueryStatsP95(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []time.Duration // recorded in order
+		wantP95   time.Duration
+		wantMean  time.Duration
+	}{
+		{"one", ms(7, 7), 7 * time.Millisecond, 7 * time.Millisecond},
+		{"twenty", ms(1, 20), 19 * time.Millisecond, 10500 * time.Microsecond},
+		{"hundred", ms(1, 100), 95 * time.Millisecond, 50500 * time.Microsecond},
+		// Only the 1024 most recent runs count for P95, the mean covers all.
+		{"ring", append(ms(1000, 1999), ms(1, 1024)...), 973 * time.Millisecond, 2024300 * time.Millisecond / 2024},
+	}
+	for _, tt := range tests {
+		var d DB
+		for _, duration := range tt.durations {
+			d.stats.record("SELECT 1", duration, nil)
+		}
+		stats := d.QueryStats()
+		if len(stats) != 1 {
+			t.Fatalf("%s: got %d stats, want 1", tt.name, len(stats))
+		}
+		if stats[0].P95 != tt.wantP95 || stats[0].Mean != tt.wantMean {
+			t.Errorf("%s: got P95 %v and mean %v, want %v and %v", tt.name, stats[0].P95, stats[0].Mean, tt.wantP95, tt.wantMean)
+		}
+	}
+}
+
+// ms returns the durations from..to milliseconds.
+func ms(from, to int) []time.Duration {
+	var durations []time.Duration
+	for i := from; i <= to; i++ {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	return durations
+}
+
+func TestQueryStats(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t, WithQueryStats())
+	mustExec(t, d, "CREATE TABLE t (v INTEGER)")
+	d.ResetQueryStats()
+
+	for i := range 3 {
+		if _, err := d.ExecContext(ctx, "INSERT INTO t VALUES (?), (?)", i, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, limit := range []int{1, 4} {
+		rows, err := d.QueryContext(ctx, "SELECT v FROM t LIMIT ?", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, err := range ScanAll[int](rows) {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if _, err := d.ExecContext(ctx, "INSERT INTO missing VALUES (1)"); err == nil {
+		t.Fatal("insert into a missing table succeeded")
+	}
+
+	got := map[string]QueryStat{}
+	for _, stat := range d.QueryStats() {
+		got[stat.Fingerprint] = stat
+	}
+	tests := []struct {
+		fingerprint string
+		count       int64
+		errors      int64
+		rows        int64
+	}{
+		{"INSERT INTO t VALUES (?), (?)", 3, 0, 6},
+		{"SELECT v FROM t LIMIT ?", 2, 0, 5},
+		{"INSERT INTO missing VALUES (?)", 1, 1, 0},
+	}
+	for _, tt := range tests {
+		stat, ok := got[tt.fingerprint]
+		if !ok {
+			t.Errorf("no stats for %q in %v", tt.fingerprint, got)
+			continue
+		}
+		if stat.Count != tt.count || stat.Errors != tt.errors || stat.Rows != tt.rows {
+			t.Errorf("%q: got count %d, errors %d, rows %d, want %d, %d, %d",
+				tt.fingerprint, stat.Count, stat.Errors, stat.Rows, tt.count, tt.errors, tt.rows)
+		}
+	}
+	if len(got) != len(tests) {
+		t.Errorf("got %d fingerprints, want %d", len(got), len(tests))
+	}
+}
@@ -0,0 +1,248 @@
+package db
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose database is picked by Tenants.DB for tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant set with WithTenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
+
+// tenantPattern matches tenant IDs that are safe to use as file names.
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// TenantOptions controls how Tenants opens the database of each tenant.
+type TenantOptions struct {
+	// Dir holds one database file per tenant, named <tenant>.db, "./data/tenants" by default.
+	Dir string
+	// Options are passed to InitNamed for every tenant database. WithPath is ignored.
+	Options []Option
+	// MaxOpen is the number of tenant databases kept open, 100 by default. Opening another one
+	// closes the least recently used.
+	MaxOpen int
+	// Migrate is called once after a tenant database is opened, e.g. to create or migrate its schema.
+	// Its ctx is not cancelled with the request that opened the database, as others may be waiting
+	// for it. If it fails, the database is closed again and the next request retries.
+	Migrate func(ctx context.Context, d *DB) error
+}
+
+// Tenants routes each tenant to its own database file, opened on first use and kept open until Close
+// or until it is the least recently used of more than MaxOpen:
+//
+//	tenants := db.NewTenants(db.TenantOptions{Migrate: migrate})
+//	defer tenants.Close()
+//
+//	// in a middleware
+//	ctx = db.WithTenant(ctx, customerID)
+//
+//	// in a handler
+//	d, err := tenants.DB(ctx)
+//	rows, err := d.QueryContext(ctx, "SELECT * FROM invoices")
+//
+// Tenant databases are registered under "tenant:" followed by their file path without the .db
+// extension, and unregistered once closed. Closing an evicted database waits for its queries in
+// flight like the close function of InitNamed; handles kept past that fail, request the tenant again
+// for a new one.
+type Tenants struct {
+	opts TenantOptions
+
+	mu      sync.Mutex
+	tenants map[string]*tenantEntry
+	// lru orders the tenants from the most to the least recently requested.
+	lru *list.List
+	// evicting holds the entries of evicted tenants until their databases are closed.
+	evicting map[string]*tenantEntry
+	closed   bool
+}
+
+// tenantEntry is a tenant database, ready is closed once it is opened and migrated or has failed.
+type tenantEntry struct {
+	ready chan struct{}
+	db    *DB
+	close func() error
+	err   error
+
+	elem *list.Element
+	// evicted is closed once the database of an evicted entry is closed.
+	evicted chan struct{}
+}
+
+// NewTenants returns a router opening tenant databases according to opts.
+func NewTenants(opts TenantOptions) *Tenants {
+	if opts.Dir == "" {
+		opts.Dir = "./data/tenants"
+	}
+	if opts.MaxOpen <= 0 {
+		opts.MaxOpen = 100
+	}
+	return &Tenants{opts: opts, tenants: map[string]*tenantEntry{}, lru: list.New(), evicting: map[string]*tenantEntry{}}
+}
+
+// DB returns the database of the tenant set on ctx with WithTenant.
+func (t *Tenants) DB(ctx context.Context) (*DB, error) {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("no tenant in context, use WithTenant")
+	}
+	return t.Get(ctx, tenant)
+}
+
+// Get returns the database of tenant, opening and migrating it on first use.
+// Concurrent first requests for a tenant wait for the same open, other tenants are not blocked.
+func (t *Tenants) Get(ctx context.Context, tenant string) (*DB, error) {
+	if !tenantPattern.MatchString(tenant) {
+		return nil, fmt.Errorf("invalid tenant %q", tenant)
+	}
+
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("tenants are closed")
+	}
+	entry, ok := t.tenants[tenant]
+	var previous *tenantEntry
+	var evicted map[string]*tenantEntry
+	if ok {
+		t.lru.MoveToFront(entry.elem)
+	} else {
+		entry = &tenantEntry{ready: make(chan struct{})}
+		entry.elem = t.lru.PushFront(tenant)
+		t.tenants[tenant] = entry
+		previous = t.evicting[tenant]
+		evicted = t.evict()
+	}
+	t.mu.Unlock()
+
+	for name, e := range evicted {
+		go t.closeEvicted(name, e)
+	}
+	if !ok {
+		t.open(ctx, tenant, entry, previous)
+	}
+
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	return entry.db, nil
+}
+
+// evict removes the least recently used tenants over MaxOpen whose databases are open, and returns
+// their entries to be closed with closeEvicted. t.mu must be held.
+func (t *Tenants) evict() map[string]*tenantEntry {
+	evicted := map[string]*tenantEntry{}
+	for elem := t.lru.Back(); elem != nil && t.lru.Len() > t.opts.MaxOpen; {
+		prev := elem.Prev()
+		tenant := elem.Value.(string)
+		entry := t.tenants[tenant]
+		select {
+		case <-entry.ready:
+			// Entries that failed to open are already removed.
+			t.lru.Remove(elem)
+			delete(t.tenants, tenant)
+			entry.evicted = make(chan struct{})
+			t.evicting[tenant] = entry
+			evicted[tenant] = entry
+		default:
+			// Still opening, its requests are waiting for it.
+		}
+		elem = prev
+	}
+	return evicted
+}
+
+// closeEvicted closes the database of an entry of tenant removed by evict. The error is dropped, as
+// there is no caller to return it to.
+func (t *Tenants) closeEvicted(tenant string, entry *tenantEntry) {
+	entry.close()
+	t.mu.Lock()
+	if t.evicting[tenant] == entry {
+		delete(t.evicting, tenant)
+	}
+	t.mu.Unlock()
+	close(entry.evicted)
+}
+
+// open opens and migrates the database of tenant, after the database of the previous entry of the
+// tenant, if it was evicted, is closed. On failure the entry is removed so the next request retries.
+func (t *Tenants) open(ctx context.Context, tenant string, entry, previous *tenantEntry) {
+	defer close(entry.ready)
+
+	if previous != nil {
+		<-previous.evicted
+	}
+
+	// The path keeps tenants of routers with different directories apart.
+	path := filepath.Join(t.opts.Dir, tenant)
+	name := "tenant:" + path
+	opts := append(append([]Option(nil), t.opts.Options...), WithPath(path+".db"))
+
+	d := Use(name)
+	closeDB, err := InitNamed(name, opts...)
+	if err == nil && t.opts.Migrate != nil {
+		if err = t.opts.Migrate(context.WithoutCancel(ctx), d); err != nil {
+			err = errors.Join(fmt.Errorf("failed to migrate tenant %s: %w", tenant, err), closeDB())
+		}
+	}
+
+	if err != nil {
+		unregister(d)
+		entry.err = err
+		t.mu.Lock()
+		if t.tenants[tenant] == entry {
+			t.lru.Remove(entry.elem)
+			delete(t.tenants, tenant)
+		}
+		t.mu.Unlock()
+		return
+	}
+
+	entry.db = d
+	entry.close = func() error {
+		defer unregister(d)
+		return closeDB()
+	}
+}
+
+// Close closes all open tenant databases. Later calls to DB and Get fail.
+func (t *Tenants) Close() error {
+	t.mu.Lock()
+	t.closed = true
+	tenants := t.tenants
+	evicting := t.evicting
+	t.tenants = map[string]*tenantEntry{}
+	t.evicting = map[string]*tenantEntry{}
+	t.lru.Init()
+	t.mu.Unlock()
+
+	var errs []error
+	for _, entry := range tenants {
+		<-entry.ready
+		if entry.close != nil {
+			errs = append(errs, entry.close())
+		}
+	}
+	for _, entry := range evicting {
+		<-entry.evicted
+	}
+	return errors.Join(errs...)
+}
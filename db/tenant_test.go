@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTenants(t *testing.T) {
+	migrated := map[string]int{}
+	tenants := NewTenants(TenantOptions{
+		Dir:     t.TempDir(),
+		MaxOpen: 2,
+		Migrate: func(ctx context.Context, d *DB) error {
+			migrated[filepath.Base(d.config.Load().path)]++
+			_, err := d.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS t (v TEXT)")
+			return err
+		},
+	})
+	defer tenants.Close()
+
+	for _, tenant := range []string{"a", "b", "a", "c", "a", "b"} {
+		ctx := WithTenant(context.Background(), tenant)
+		d, err := tenants.DB(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := d.ExecContext(ctx, "INSERT INTO t VALUES (?)", tenant); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a stays open as the most recently used, b is evicted by c and opened again.
+	want := map[string]int{"a.db": 1, "b.db": 2, "c.db": 1}
+	if fmt.Sprint(migrated) != fmt.Sprint(want) {
+		t.Errorf("migrations %v, want %v", migrated, want)
+	}
+
+	d, err := tenants.Get(context.Background(), "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := d.QueryRowContext(context.Background(), "SELECT count(*) FROM t").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("tenant b has %d rows, want 2", n)
+	}
+
+	if _, err := tenants.Get(context.Background(), "../x"); err == nil {
+		t.Error("Get accepted an invalid tenant")
+	}
+}
+
+func TestTenantsDirs(t *testing.T) {
+	ctx := context.Background()
+	var routers []*Tenants
+	for range 2 {
+		tenants := NewTenants(TenantOptions{
+			Dir: t.TempDir(),
+			Migrate: func(ctx context.Context, d *DB) error {
+				_, err := d.ExecContext(ctx, "CREATE TABLE t (v TEXT)")
+				return err
+			},
+		})
+		defer tenants.Close()
+		routers = append(routers, tenants)
+	}
+
+	// The same tenant of routers with different directories is a different database.
+	for i, tenants := range routers {
+		d, err := tenants.Get(ctx, "acme")
+		if err != nil {
+			t.Fatalf("router %d: %v", i, err)
+		}
+		if _, err := d.ExecContext(ctx, "INSERT INTO t VALUES (?)", fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, tenants := range routers {
+		d, err := tenants.Get(ctx, "acme")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v string
+		if err := d.QueryRowContext(ctx, "SELECT group_concat(v) FROM t").Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		if v != fmt.Sprint(i) {
+			t.Errorf("router %d has rows %q, want only its own", i, v)
+		}
+	}
+}
+
+func TestTenantsUnregister(t *testing.T) {
+	ctx := context.Background()
+	tenants := NewTenants(TenantOptions{Dir: t.TempDir(), MaxOpen: 1})
+
+	registered := func() int {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		n := 0
+		for name := range registry {
+			if strings.HasPrefix(name, "tenant:"+tenants.opts.Dir) {
+				n++
+			}
+		}
+		return n
+	}
+
+	for _, tenant := range []string{"a", "b", "c"} {
+		if _, err := tenants.Get(ctx, tenant); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Evicted databases are closed in the background.
+	for deadline := time.Now().Add(5 * time.Second); registered() > 1 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := registered(); n != 1 {
+		t.Errorf("%d tenant databases registered with MaxOpen 1", n)
+	}
+
+	if err := tenants.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := registered(); n != 0 {
+		t.Errorf("%d tenant databases registered after Close", n)
+	}
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type txKey struct{}
+
+// txState is the transaction carried in a context by WithTx.
+type txState struct {
+	db    *DB
+	tx    *sql.Tx
+	depth int
+}
+
+// TxFromContext returns the transaction opened by an enclosing WithTx call, if any.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return nil, false
+	}
+	return state.tx, true
+}
+
+// WithTx runs fn inside a transaction, committing if fn returns nil and rolling back otherwise.
+// The transaction is also stored in the context passed to fn. When ctx already carries a
+// transaction from an enclosing WithTx, fn runs inside a savepoint of that transaction instead,
+// so only the work done by fn is rolled back on error. This lets library code compose
+// transactional operations without knowing whether a transaction is already open.
+func WithTx(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return defaultDB().WithTx(ctx, fn)
+}
+
+// WithTx runs fn inside a transaction on the database, see the package-level WithTx.
+// Only a transaction on the same database in ctx is joined with a savepoint.
+func (d *DB) WithTx(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return d.WithTxOptions(ctx, nil, fn)
+}
+
+// WithTxOptions runs fn inside a transaction started with opts like WithTx, e.g. with
+// TxImmediate.Options() to take the write lock up front, see BeginTx. When fn runs inside a
+// savepoint of an enclosing transaction, opts are ignored.
+func WithTxOptions(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return defaultDB().WithTxOptions(ctx, opts, fn)
+}
+
+// WithTxOptions runs fn inside a transaction on the database started with opts, see the
+// package-level WithTxOptions.
+func (d *DB) WithTxOptions(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	if state, ok := d.txFromContext(ctx); ok {
+		return withSavepoint(ctx, state, fn)
+	}
+
+	if err := d.checkOpen(); err != nil {
+		return err
+	}
+
+	if w := d.queue(); w != nil {
+		return d.withQueuedTx(ctx, w, opts, fn)
+	}
+
+	tx, err := d.beginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	return d.runTx(ctx, tx, fn)
+}
+
+// txFromContext returns the transaction on d carried by ctx, if any.
+func (d *DB) txFromContext(ctx context.Context) (*txState, bool) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok || state.db != d {
+		return nil, false
+	}
+	return state, true
+}
+
+// withQueuedTx runs the transaction on the write queue connection for the mode selected by opts.
+// A panic in fn is re-raised in the calling goroutine.
+func (d *DB) withQueuedTx(ctx context.Context, w *writeQueue, opts *sql.TxOptions, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	mode, err := txModeOf(opts)
+	if err != nil {
+		return err
+	}
+
+	var panicked any
+	future := w.submit(ctx, func(ctx context.Context, _ *sql.Conn) (sql.Result, error) {
+		defer func() {
+			if p := recover(); p != nil {
+				panicked = p
+			}
+		}()
+
+		conn, err := w.modeConn(ctx, mode, d.txPool)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := conn.BeginTx(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		return nil, d.runTx(ctx, tx, fn)
+	})
+
+	// Wait for the job itself, it observes ctx on its own.
+	<-future.Done()
+	if panicked != nil {
+		panic(panicked)
+	}
+	return future.err
+}
+
+// runTx runs fn in tx, committing if fn returns nil and rolling back otherwise.
+func (d *DB) runTx(ctx context.Context, tx *sql.Tx, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if d.audit.Load() {
+		if err := setActor(ctx, tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, &txState{db: d, tx: tx}), tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if d.audit.Load() {
+		if err := clearActor(ctx, tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func withSavepoint(ctx context.Context, parent *txState, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	state := &txState{db: parent.db, tx: parent.tx, depth: parent.depth + 1}
+	name := fmt.Sprintf("sp_%d", state.depth)
+
+	if err := Savepoint(ctx, state.tx, name); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			RollbackTo(ctx, state.tx, name)
+			Release(ctx, state.tx, name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, state), state.tx); err != nil {
+		// A released savepoint no longer exists, so roll back to it before releasing.
+		RollbackTo(ctx, state.tx, name)
+		Release(ctx, state.tx, name)
+		return err
+	}
+
+	return Release(ctx, state.tx, name)
+}
+
+// Savepoint creates a savepoint with the given name inside tx.
+func Savepoint(ctx context.Context, tx *sql.Tx, name string) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+quoteIdent(name)); err != nil {
+		return fmt.Errorf("failed to create savepoint %s: %w", name, err)
+	}
+	return nil
+}
+
+// RollbackTo undoes all changes made in tx since the named savepoint was created.
+// The savepoint stays active and must still be released.
+func RollbackTo(ctx context.Context, tx *sql.Tx, name string) error {
+	if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+quoteIdent(name)); err != nil {
+		return fmt.Errorf("failed to roll back to savepoint %s: %w", name, err)
+	}
+	return nil
+}
+
+// Release removes the named savepoint, keeping the changes made since it was created
+// as part of the enclosing transaction.
+func Release(ctx context.Context, tx *sql.Tx, name string) error {
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+quoteIdent(name)); err != nil {
+		return fmt.Errorf("failed to release savepoint %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// values returns the v column of table t in d, in rowid order.
+func values(tb testing.TB, d *DB) []int {
+	tb.Helper()
+	rows, err := d.QueryContext(context.Background(), "SELECT v FROM t ORDER BY rowid")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var values []int
+	for v, err := range ScanAll[int](rows) {
+		if err != nil {
+			tb.Fatal(err)
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestWithTxSavepoint(t *testing.T) {
+	ctx := context.Background()
+	errInner := errors.New("inner")
+	insert := func(v int) func(ctx context.Context, tx *sql.Tx) error {
+		return func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO t VALUES (?)", v)
+			return err
+		}
+	}
+
+	tests := []struct {
+		name  string
+		inner error
+		outer error
+		want  []int
+	}{
+		{"both commit", nil, nil, []int{1, 2}},
+		{"inner rolls back to its savepoint", errInner, nil, []int{1}},
+		{"outer rolls back everything", nil, errors.New("outer"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := openTestDB(t)
+			mustExec(t, d, "CREATE TABLE t (v INTEGER)")
+
+			err := d.WithTx(ctx, func(ctx context.Context, tx *sql.Tx) error {
+				if err := insert(1)(ctx, tx); err != nil {
+					return err
+				}
+				err := d.WithTx(ctx, func(ctx context.Context, inner *sql.Tx) error {
+					if inner != tx {
+						t.Error("nested WithTx did not join the transaction")
+					}
+					if err := insert(2)(ctx, inner); err != nil {
+						return err
+					}
+					return tt.inner
+				})
+				if !errors.Is(err, tt.inner) {
+					t.Errorf("nested WithTx returned %v, want %v", err, tt.inner)
+				}
+				return tt.outer
+			})
+			if !errors.Is(err, tt.outer) {
+				t.Fatalf("WithTx returned %v, want %v", err, tt.outer)
+			}
+
+			if got := values(t, d); !slices.Equal(got, tt.want) {
+				t.Errorf("got rows %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
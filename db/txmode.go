@@ -0,0 +1,98 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// TxMode selects how SQLite acquires locks when a transaction begins.
+type TxMode int
+
+const (
+	// TxDeferred acquires locks lazily on the first read or write. This is SQLite's default.
+	TxDeferred TxMode = iota
+	// TxImmediate acquires the write lock when the transaction begins, so later writes
+	// cannot fail with SQLITE_BUSY while upgrading from a read lock.
+	TxImmediate
+	// TxExclusive acquires the write lock when the transaction begins and, outside WAL mode,
+	// also prevents other connections from reading.
+	TxExclusive
+)
+
+func (m TxMode) String() string {
+	switch m {
+	case TxDeferred:
+		return "deferred"
+	case TxImmediate:
+		return "immediate"
+	case TxExclusive:
+		return "exclusive"
+	default:
+		return fmt.Sprintf("TxMode(%d)", int(m))
+	}
+}
+
+// Options returns the transaction options that make BeginTx and WithTxOptions begin in mode m,
+// e.g. db.BeginTx(ctx, db.TxImmediate.Options()).
+func (m TxMode) Options() *sql.TxOptions {
+	switch m {
+	case TxImmediate:
+		return &sql.TxOptions{Isolation: sql.LevelSerializable}
+	case TxExclusive:
+		return &sql.TxOptions{Isolation: sql.LevelLinearizable}
+	default:
+		return &sql.TxOptions{}
+	}
+}
+
+// txModeOf returns the mode a transaction started with opts begins in. SQLite transactions are
+// serializable whatever the isolation level, so the level selects the mode instead:
+// sql.LevelSerializable begins IMMEDIATE, sql.LevelLinearizable EXCLUSIVE and any other level
+// DEFERRED. Read-only transactions always begin DEFERRED, as they never take the write lock.
+func txModeOf(opts *sql.TxOptions) (TxMode, error) {
+	if opts == nil {
+		return TxDeferred, nil
+	}
+	mode := TxDeferred
+	switch opts.Isolation {
+	case sql.LevelSerializable:
+		mode = TxImmediate
+	case sql.LevelLinearizable:
+		mode = TxExclusive
+	}
+	if opts.ReadOnly && mode != TxDeferred {
+		return TxDeferred, fmt.Errorf("read-only transactions cannot begin %v", mode)
+	}
+	return mode, nil
+}
+
+// txPool returns the connection pool whose transactions begin in the given mode.
+// The driver only supports the begin mode per connection, set when it is opened, so pools for
+// non-default modes are opened on first use and closed together with the database.
+func (d *DB) txPool(mode TxMode) (*sql.DB, error) {
+	if mode == TxDeferred {
+		return d.pool.Load(), nil
+	}
+	if mode != TxImmediate && mode != TxExclusive {
+		return nil, fmt.Errorf("unknown transaction mode %v", mode)
+	}
+
+	d.txPoolsMu.Lock()
+	defer d.txPoolsMu.Unlock()
+
+	if pool, ok := d.txPools[mode]; ok {
+		return pool, nil
+	}
+
+	pool, err := openPool(*d.config.Load(), "_txlock", mode.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database for %v transactions: %w", mode, err)
+	}
+
+	if d.txPools == nil {
+		d.txPools = make(map[TxMode]*sql.DB)
+	}
+	d.txPools[mode] = pool
+
+	return pool, nil
+}
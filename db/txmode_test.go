@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestTxModeOf(t *testing.T) {
+	tests := []struct {
+		opts    *sql.TxOptions
+		want    TxMode
+		wantErr bool
+	}{
+		{nil, TxDeferred, false},
+		{&sql.TxOptions{}, TxDeferred, false},
+		{&sql.TxOptions{Isolation: sql.LevelReadCommitted}, TxDeferred, false},
+		{&sql.TxOptions{Isolation: sql.LevelSerializable}, TxImmediate, false},
+		{&sql.TxOptions{Isolation: sql.LevelLinearizable}, TxExclusive, false},
+		{&sql.TxOptions{ReadOnly: true}, TxDeferred, false},
+		{&sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}, TxDeferred, true},
+		{TxImmediate.Options(), TxImmediate, false},
+		{TxExclusive.Options(), TxExclusive, false},
+		{TxDeferred.Options(), TxDeferred, false},
+	}
+	for _, tt := range tests {
+		got, err := txModeOf(tt.opts)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("txModeOf(%+v) = %v, %v, want %v, error %v", tt.opts, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestBeginTxMode(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		mode TxMode
+		// locked reports that other connections cannot write while the transaction is open,
+		// although it has not run a statement yet.
+		locked bool
+	}{
+		{TxDeferred, false},
+		{TxImmediate, true},
+		{TxExclusive, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			d := openTestDB(t)
+			mustExec(t, d, "CREATE TABLE t (v INTEGER)")
+
+			tx, err := d.BeginTx(ctx, tt.mode.Options())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			_, err = d.ExecContext(ctx, "INSERT INTO t VALUES (1)")
+			if locked := IsBusy(err); locked != tt.locked {
+				t.Errorf("write beside the transaction returned %v, want locked %v", err, tt.locked)
+			}
+		})
+	}
+}
+
+func TestWithTxOptionsReadOnly(t *testing.T) {
+	d := openTestDB(t)
+	err := d.WithTxOptions(context.Background(), &sql.TxOptions{Isolation: sql.LevelLinearizable, ReadOnly: true},
+		func(ctx context.Context, tx *sql.Tx) error { return nil })
+	if err == nil {
+		t.Error("read-only EXCLUSIVE transaction was started")
+	}
+}
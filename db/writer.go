@@ -0,0 +1,213 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Future is the pending result of a write submitted to the write queue.
+type Future struct {
+	done   chan struct{}
+	result sql.Result
+	err    error
+}
+
+func newFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+func (f *Future) resolve(result sql.Result, err error) {
+	f.result = result
+	f.err = err
+	close(f.done)
+}
+
+// Done returns a channel that is closed once the write has completed.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the write has completed or ctx is done.
+// If ctx is done first, the write itself is not cancelled.
+func (f *Future) Wait(ctx context.Context) (sql.Result, error) {
+	select {
+	case <-f.done:
+		return f.result, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type writeJob struct {
+	ctx    context.Context
+	run    func(ctx context.Context, conn *sql.Conn) (sql.Result, error)
+	future *Future
+}
+
+// writeQueue runs write jobs one at a time on a single connection.
+type writeQueue struct {
+	jobs    chan writeJob
+	conn    *sql.Conn
+	stopped chan struct{}
+	// modeConns holds the connections reserved for transactions in other modes than TxDeferred,
+	// see TxMode. Only jobs use them, which run one at a time.
+	modeConns map[TxMode]*sql.Conn
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// EnableWriteQueue funnels all writes through a single goroutine and connection with a queue
+// holding up to size pending writes. Once enabled, ExecContext, WithTx and the helpers built on
+// them, as well as Archive, wait for their turn in the queue instead of competing for the SQLite
+// write lock, which gives predictable latency for write-light applications. Submitting blocks while
+// the queue is full. BeginTx then only starts read-only transactions, as the transaction it returns
+// cannot run on the writer goroutine.
+// Functions passed to WithTx run on the writer goroutine and must use the provided
+// transaction, since calling ExecContext from inside them would wait on itself.
+// The queue is drained and stopped by the close function returned from Init.
+func EnableWriteQueue(size int) error {
+	return defaultDB().EnableWriteQueue(size)
+}
+
+// EnableWriteQueue funnels all writes to the database through a single goroutine and connection,
+// see the package-level EnableWriteQueue.
+func (d *DB) EnableWriteQueue(size int) error {
+	if err := d.checkOpen(); err != nil {
+		return err
+	}
+	if size <= 0 {
+		return fmt.Errorf("write queue size must be positive, got %d", size)
+	}
+
+	d.writerMu.Lock()
+	defer d.writerMu.Unlock()
+	if d.writer != nil {
+		return fmt.Errorf("write queue already enabled")
+	}
+
+	conn, err := d.pool.Load().Conn(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to reserve writer connection: %w", err)
+	}
+
+	w := &writeQueue{
+		jobs:    make(chan writeJob, size),
+		conn:    conn,
+		stopped: make(chan struct{}),
+	}
+	go w.loop()
+	d.writer = w
+
+	return nil
+}
+
+// queue returns the write queue, nil if it is not enabled.
+func (d *DB) queue() *writeQueue {
+	d.writerMu.Lock()
+	defer d.writerMu.Unlock()
+	return d.writer
+}
+
+func (q *writeQueue) loop() {
+	defer close(q.stopped)
+	for job := range q.jobs {
+		if err := job.ctx.Err(); err != nil {
+			job.future.resolve(nil, err)
+			continue
+		}
+		job.future.resolve(job.run(job.ctx, q.conn))
+	}
+}
+
+// submit queues run, blocking while the queue is full or until ctx is done.
+func (q *writeQueue) submit(ctx context.Context, run func(ctx context.Context, conn *sql.Conn) (sql.Result, error)) *Future {
+	future := newFuture()
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		future.resolve(nil, fmt.Errorf("write queue closed"))
+		return future
+	}
+
+	select {
+	case q.jobs <- writeJob{ctx: ctx, run: run, future: future}:
+	case <-ctx.Done():
+		future.resolve(nil, ctx.Err())
+	}
+
+	return future
+}
+
+// modeConn returns the connection of the queue for transactions beginning in mode, reserving one
+// from pool on first use. It may only be called by jobs.
+func (q *writeQueue) modeConn(ctx context.Context, mode TxMode, pool func(mode TxMode) (*sql.DB, error)) (*sql.Conn, error) {
+	if mode == TxDeferred {
+		return q.conn, nil
+	}
+	if conn, ok := q.modeConns[mode]; ok {
+		return conn, nil
+	}
+	db, err := pool(mode)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve writer connection for %v transactions: %w", mode, err)
+	}
+	if q.modeConns == nil {
+		q.modeConns = make(map[TxMode]*sql.Conn)
+	}
+	q.modeConns[mode] = conn
+	return conn, nil
+}
+
+// stop rejects new writes, waits for queued writes to finish and releases the connections.
+func (q *writeQueue) stop() error {
+	q.mu.Lock()
+	q.closed = true
+	close(q.jobs)
+	q.mu.Unlock()
+
+	<-q.stopped
+	errs := []error{q.conn.Close()}
+	for _, conn := range q.modeConns {
+		errs = append(errs, conn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// ExecAsync queues a query that does not return rows and returns a Future for its result.
+// The write queue must be enabled with EnableWriteQueue.
+func ExecAsync(ctx context.Context, query string, args ...any) *Future {
+	return defaultDB().ExecAsync(ctx, query, args...)
+}
+
+// ExecAsync queues a query that does not return rows and returns a Future for its result.
+// The write queue must be enabled with EnableWriteQueue.
+func (d *DB) ExecAsync(ctx context.Context, query string, args ...any) *Future {
+	w := d.queue()
+	if w == nil {
+		future := newFuture()
+		future.resolve(nil, fmt.Errorf("write queue not enabled, call EnableWriteQueue() first"))
+		return future
+	}
+	query = annotateQuery(ctx, query)
+	start := time.Now()
+	return w.submit(ctx, func(ctx context.Context, conn *sql.Conn) (sql.Result, error) {
+		result, err := conn.ExecContext(ctx, query, args...)
+		stat := d.observe(ctx, query, args, start, err)
+		if err == nil {
+			d.stats.addResult(stat, result)
+			d.cache.invalidateWrite(query, d.linkedTables)
+		}
+		return result, err
+	})
+}
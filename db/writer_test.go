@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestWriteQueue(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t, WithWriteQueue(4))
+	mustExec(t, d, "CREATE TABLE t (v INTEGER)")
+
+	futures := make([]*Future, 20)
+	for i := range futures {
+		futures[i] = d.ExecAsync(ctx, "INSERT INTO t VALUES (?)", i)
+	}
+	for _, future := range futures {
+		if _, err := future.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, mode := range []TxMode{TxDeferred, TxImmediate, TxExclusive} {
+		err := d.WithTxOptions(ctx, mode.Options(), func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO t VALUES (-1)")
+			return err
+		})
+		if err != nil {
+			t.Errorf("WithTx in mode %v: %v", mode, err)
+		}
+	}
+
+	var n int
+	if err := d.QueryRowContext(ctx, "SELECT count(*) FROM t").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 23 {
+		t.Errorf("got %d rows, want 23", n)
+	}
+}
+
+func TestWriteQueueBeginTx(t *testing.T) {
+	ctx := context.Background()
+	d := openTestDB(t, WithWriteQueue(4))
+
+	if _, err := d.BeginTx(ctx, nil); err == nil {
+		t.Error("BeginTx started a write transaction beside the write queue")
+	}
+	tx, err := d.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("BeginTx of a read-only transaction: %v", err)
+	}
+	tx.Rollback()
+}
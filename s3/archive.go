@@ -0,0 +1,220 @@
+package s3
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveFormat is the format of the archive written by DownloadArchive or read by UploadArchive.
+type ArchiveFormat string
+
+const (
+	ArchiveZip ArchiveFormat = "zip"
+	ArchiveTar ArchiveFormat = "tar"
+)
+
+// DownloadArchive writes the objects whose key starts with prefix to w as a zip or tar archive, at
+// the path of their key after prefix, e.g. for a "download all files" button:
+//
+//	w.Header().Set("Content-Type", "application/zip")
+//	w.Header().Set("Content-Disposition", `attachment; filename="files.zip"`)
+//	if _, err := s3.DownloadArchive(r.Context(), "users/42/", w, s3.ArchiveZip); err != nil {
+//		log.Print(err)
+//	}
+//
+// Objects are downloaded one at a time and streamed into the archive, so nothing is staged on disk,
+// except for gzip-encoded objects in a tar archive, whose size has to be known before their content.
+// Objects are decompressed like by Download. Keys are skipped like by DownloadPrefix. It returns the
+// number of objects written; on error, w has received an incomplete archive.
+func DownloadArchive(ctx context.Context, prefix string, w io.Writer, format ArchiveFormat) (int, error) {
+	return defaultBucket().DownloadArchive(ctx, prefix, w, format)
+}
+
+// DownloadArchive writes the objects under prefix to w as an archive, see the package-level DownloadArchive.
+func (b *Bucket) DownloadArchive(ctx context.Context, prefix string, w io.Writer, format ArchiveFormat) (int, error) {
+	var add func(obj *Object, name string) error
+	var finish func() error
+	switch format {
+	case ArchiveZip:
+		zw := zip.NewWriter(w)
+		add = func(obj *Object, name string) error {
+			fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: obj.LastModified})
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(fw, obj)
+			return err
+		}
+		finish = zw.Close
+	case ArchiveTar:
+		tw := tar.NewWriter(w)
+		add = func(obj *Object, name string) error {
+			var content io.Reader = obj
+			size := obj.Size
+			if _, ok := obj.ReadCloser.(*decompressingReader); ok {
+				spooled, n, err := spool(obj)
+				if err != nil {
+					return err
+				}
+				defer spooled.Close()
+				content, size = spooled, n
+			}
+			err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: size, ModTime: obj.LastModified})
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(tw, content)
+			return err
+		}
+		finish = tw.Close
+	default:
+		return 0, fmt.Errorf("unknown archive format %q, expected zip or tar", format)
+	}
+
+	n := 0
+	for info, err := range b.List(ctx, prefix) {
+		if err != nil {
+			return n, err
+		}
+		rel := strings.TrimPrefix(info.Key, prefix)
+		if rel == "" || strings.HasSuffix(rel, "/") || !filepath.IsLocal(filepath.FromSlash(rel)) {
+			continue
+		}
+
+		obj, err := b.Download(ctx, info.Key)
+		if err != nil {
+			return n, err
+		}
+		err = add(obj, rel)
+		obj.Close()
+		if err != nil {
+			return n, fmt.Errorf("failed to archive %s: %w", info.Key, err)
+		}
+		n++
+	}
+
+	if err := finish(); err != nil {
+		return n, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return n, nil
+}
+
+// UploadArchive uploads the regular files in the zip or tar archive read from r as objects keyed by
+// prefix followed by their path in the archive, e.g. to ingest a bundle uploaded by a user. Files
+// are uploaded one at a time with opts. Directories, links and files whose path would leave prefix,
+// such as ones containing "..", are skipped. A zip archive is read from r directly if it is an
+// io.ReaderAt of known size, such as an *os.File, and staged in a temporary file otherwise. It
+// returns the number of objects uploaded and stops at the first error. Archives from untrusted
+// sources can expand to far more than their size, so limit r or check the sizes first.
+func UploadArchive(ctx context.Context, prefix string, r io.Reader, format ArchiveFormat, opts ...UploadOption) (int, error) {
+	return defaultBucket().UploadArchive(ctx, prefix, r, format, opts...)
+}
+
+// UploadArchive uploads the files in the archive read from r under prefix, see the package-level UploadArchive.
+func (b *Bucket) UploadArchive(ctx context.Context, prefix string, r io.Reader, format ArchiveFormat, opts ...UploadOption) (int, error) {
+	if err := b.checkOpen(); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	upload := func(name string, content io.Reader) error {
+		name = strings.TrimPrefix(name, "./")
+		if !filepath.IsLocal(filepath.FromSlash(name)) {
+			return nil
+		}
+		if err := b.Upload(ctx, prefix+path.Clean(name), content, opts...); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", name, err)
+		}
+		n++
+		return nil
+	}
+
+	switch format {
+	case ArchiveZip:
+		ra, ok := r.(io.ReaderAt)
+		size := readerSize(r)
+		if !ok || size < 0 {
+			spooled, spooledSize, err := spool(r)
+			if err != nil {
+				return 0, fmt.Errorf("failed to read archive: %w", err)
+			}
+			defer spooled.Close()
+			ra, size = spooled, spooledSize
+		}
+		zr, err := zip.NewReader(ra, size)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read archive: %w", err)
+		}
+		for _, f := range zr.File {
+			if !f.Mode().IsRegular() {
+				continue
+			}
+			content, err := f.Open()
+			if err != nil {
+				return n, fmt.Errorf("failed to read archive: %w", err)
+			}
+			err = upload(f.Name, content)
+			content.Close()
+			if err != nil {
+				return n, err
+			}
+		}
+	case ArchiveTar:
+		tr := tar.NewReader(r)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return n, fmt.Errorf("failed to read archive: %w", err)
+			}
+			if !h.FileInfo().Mode().IsRegular() {
+				continue
+			}
+			if err := upload(h.Name, tr); err != nil {
+				return n, err
+			}
+		}
+	default:
+		return 0, fmt.Errorf("unknown archive format %q, expected zip or tar", format)
+	}
+	return n, nil
+}
+
+// spool copies r to a temporary file, which is removed once closed, and returns it rewound
+// with its size.
+func spool(r io.Reader) (*tempFile, int64, error) {
+	file, err := os.CreateTemp("", "s3-archive-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	spooled := &tempFile{file}
+	n, err := io.Copy(file, r)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		spooled.Close()
+		return nil, 0, err
+	}
+	return spooled, n, nil
+}
+
+// tempFile is a temporary file removed when it is closed.
+type tempFile struct {
+	*os.File
+}
+
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
@@ -0,0 +1,77 @@
+package s3
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// UploadAtomic uploads the content of reader to a temporary object and copies it to key only once
+// the upload has succeeded, for well-known keys such as latest.json. S3 itself never exposes partial
+// content: a PUT, and a multipart upload once completed, replace key in one step, and a failed upload
+// leaves the previous object in place, so Upload is enough there and UploadAtomic only costs a copy.
+// It is for S3-compatible services that expose objects while they are being written, where readers
+// of key would otherwise see them incomplete.
+//
+// The copy is done by the service and keeps the content type, metadata and tags of the upload;
+// WithPublicRead, WithRetention and WithLegalHold have no effect on key. WithIfNotExists, WithIfMatch,
+// WithSkipUnchanged and WithIdempotencyKey are rejected, as they would apply to the temporary object.
+// It is stored under ttl/1d/, see UploadTemp, so one left behind by a crash expires a day later, which
+// takes permission to get and put the lifecycle configuration of the bucket on first use. With
+// WithFailover, the upload, copy and delete all go to the same bucket: the secondary one is used for
+// all of them, if the reader is an io.Seeker, when any of them fails in the bucket.
+func UploadAtomic(ctx context.Context, key string, reader io.Reader, opts ...UploadOption) error {
+	return defaultBucket().UploadAtomic(ctx, key, reader, opts...)
+}
+
+// UploadAtomic uploads the content of reader to key through a temporary object, see the package-level UploadAtomic.
+func (b *Bucket) UploadAtomic(ctx context.Context, key string, reader io.Reader, opts ...UploadOption) (err error) {
+	ctx, op := b.startOperation(ctx, "UploadAtomic", attribute.String("aws.s3.key", key))
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+	var o uploadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.ifNotExists || o.ifMatch != "" || o.skipSame || o.idempotencyKey != "" {
+		return errors.New("atomic uploads support neither WithIfNotExists, WithIfMatch, WithSkipUnchanged nor WithIdempotencyKey")
+	}
+
+	if b.failover != nil {
+		return b.writeWithFailover(ctx, reader,
+			func(reader io.Reader) error { return b.publish(ctx, key, reader, opts) },
+			func(reader io.Reader) error { return b.failover.UploadAtomic(ctx, key, reader, opts...) })
+	}
+	return b.publish(ctx, key, reader, opts)
+}
+
+// publish uploads the content of reader to a temporary object and copies it to key, all in this
+// bucket only.
+func (b *Bucket) publish(ctx context.Context, key string, reader io.Reader, opts []UploadOption) error {
+	tmpKey, err := b.tempKey(ctx, "atomic/"+rand.Text()+"/"+key, 24*time.Hour)
+	if err != nil {
+		return err
+	}
+	if err := b.upload(ctx, tmpKey, reader, opts...); err != nil {
+		return err
+	}
+	// Deleting is best effort, the lifecycle rule of UploadTemp removes what is left.
+	defer b.Delete(context.WithoutCancel(ctx), tmpKey)
+
+	src, err := b.Stat(ctx, tmpKey)
+	if err != nil {
+		return fmt.Errorf("failed to publish object: %w", err)
+	}
+	if err := b.copyObject(ctx, src, key); err != nil {
+		return fmt.Errorf("failed to publish object: %w", err)
+	}
+	return nil
+}
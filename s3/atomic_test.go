@@ -0,0 +1,65 @@
+package s3_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/michaldziurowski/one/s3"
+)
+
+func TestUploadAtomic(t *testing.T) {
+	ctx := context.Background()
+	b, prefix := openTestBucket(t)
+	key := prefix + "latest.json"
+
+	for _, want := range []string{`{"v":1}`, `{"v":2}`} {
+		if err := b.UploadAtomic(ctx, key, strings.NewReader(want), s3.WithContentType("application/json")); err != nil {
+			t.Fatal(err)
+		}
+		if got := content(t, b, key); got != want {
+			t.Errorf("key holds %q, want %q", got, want)
+		}
+	}
+	if info, err := b.Stat(ctx, key); err != nil || info.ContentType != "application/json" {
+		t.Errorf("published with content type %q, error %v", info.ContentType, err)
+	}
+
+	// The temporary objects are deleted once published.
+	for info, err := range b.List(ctx, "ttl/1d/atomic/") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(info.Key, "/"+key) {
+			t.Errorf("temporary object %s left behind", info.Key)
+		}
+	}
+
+	if err := b.UploadAtomic(ctx, key, strings.NewReader("x"), s3.WithIfNotExists()); err == nil {
+		t.Error("atomic upload with WithIfNotExists succeeded")
+	}
+}
+
+func TestUploadAtomicFailover(t *testing.T) {
+	ctx := context.Background()
+	// The secondary bucket is the test bucket, the bucket itself is unreachable.
+	secondary, prefix := openTestBucket(t)
+	name := os.Getenv("S3_TEST_BUCKET")
+	if name == "" {
+		name = "one-s3-test"
+	}
+	b, err := s3.OpenBucket(name, s3.WithEndpoint("http://127.0.0.1:1"), s3.WithPathStyle(true), s3.WithRegion("us-east-1"),
+		s3.WithRetry(1, 0), s3.WithFailover(name, s3.WithEndpoint(os.Getenv("S3_TEST_ENDPOINT"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := prefix + "latest.json"
+	if err := b.UploadAtomic(ctx, key, strings.NewReader(`{"v":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := content(t, secondary, key); got != `{"v":1}` {
+		t.Errorf("secondary bucket holds %q", got)
+	}
+}
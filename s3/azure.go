@@ -0,0 +1,185 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// azureBlockSize and azureConcurrency are the size and number of blocks AzureStorage uploads
+	// at once. Blobs are limited to 50000 blocks, so 8MB blocks allow blobs of up to 390GB.
+	azureBlockSize   = 8 * 1024 * 1024
+	azureConcurrency = 4
+)
+
+// AzureStorage is a Storage that keeps objects as block blobs in an Azure Blob Storage container,
+// keyed by blob name. Upload honors WithContentType, WithMetadata, WithTags, WithProgress and
+// WithGzip; the other upload options have no effect.
+type AzureStorage struct {
+	container *container.Client
+}
+
+// OpenAzure opens the container of the storage account given by connectionString, creating it if
+// missing. The connection string is shown in the Access keys of the account in the Azure portal;
+// for the Azurite emulator it is "UseDevelopmentStorage=true".
+func OpenAzure(connectionString, containerName string) (*AzureStorage, error) {
+	if connectionString == "UseDevelopmentStorage=true" {
+		connectionString = azuriteConnectionString
+	}
+	client, err := container.NewClientFromConnectionString(connectionString, containerName, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure client: %w", err)
+	}
+	if _, err := client.Create(context.TODO(), nil); err != nil && !bloberror.HasCode(err, bloberror.ContainerAlreadyExists) {
+		return nil, fmt.Errorf("failed to create container: %w", err)
+	}
+	return &AzureStorage{container: client}, nil
+}
+
+// azuriteConnectionString connects to the Azurite emulator with its well-known development account.
+const azuriteConnectionString = "DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;" +
+	"AccountKey=Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==;" +
+	"BlobEndpoint=http://127.0.0.1:10000/devstoreaccount1;"
+
+// Upload stores the content of reader under key, in blocks uploaded in parallel.
+func (a *AzureStorage) Upload(ctx context.Context, key string, reader io.Reader, opts ...UploadOption) error {
+	var o uploadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// The content type, progress and compression are applied like for S3.
+	input := &s3.PutObjectInput{Key: aws.String(key), Body: reader}
+	if err := applyUploadOptions(input, opts); err != nil {
+		return err
+	}
+	if r, ok := input.Body.(compressingReader); ok {
+		defer r.Close()
+	}
+
+	options := &blockblob.UploadStreamOptions{
+		BlockSize:   azureBlockSize,
+		Concurrency: azureConcurrency,
+		HTTPHeaders: &blob.HTTPHeaders{
+			BlobContentType:     input.ContentType,
+			BlobContentEncoding: input.ContentEncoding,
+		},
+		Tags: o.tags,
+	}
+	if len(o.metadata) > 0 {
+		options.Metadata = make(map[string]*string, len(o.metadata))
+		for name, value := range o.metadata {
+			options.Metadata[name] = to.Ptr(value)
+		}
+	}
+	if _, err := a.container.NewBlockBlobClient(key).UploadStream(ctx, input.Body, options); err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	return nil
+}
+
+// Download returns the content of the object stored under key, decompressed if it was uploaded
+// with WithGzip like by Bucket.Download. A missing object is reported as a *NotFoundError.
+func (a *AzureStorage) Download(ctx context.Context, key string, opts ...DownloadOption) (*Object, error) {
+	var o downloadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	resp, err := a.container.NewBlobClient(key).DownloadStream(ctx, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound, bloberror.ContainerNotFound) {
+		return nil, fmt.Errorf("failed to download object: %w", &NotFoundError{Key: key})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download object: %w", err)
+	}
+
+	info := ObjectInfo{
+		Key:         key,
+		Size:        aws.ToInt64(resp.ContentLength),
+		ContentType: aws.ToString(resp.ContentType),
+		Metadata:    azureMetadata(resp.Metadata),
+	}
+	if resp.ETag != nil {
+		info.ETag = string(*resp.ETag)
+	}
+	if resp.LastModified != nil {
+		info.LastModified = *resp.LastModified
+	}
+
+	var body io.ReadCloser = resp.Body
+	if o.progress != nil {
+		body = struct {
+			io.Reader
+			io.Closer
+		}{&progressReader{Reader: resp.Body, fn: o.progress, total: info.Size}, resp.Body}
+	}
+	if aws.ToString(resp.ContentEncoding) == "gzip" {
+		if body, err = newDecompressingReader(body); err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to decompress object: %w", err)
+		}
+	}
+	return &Object{ReadCloser: body, ObjectInfo: info}, nil
+}
+
+// List returns the objects whose key starts with prefix, in key order.
+func (a *AzureStorage) List(ctx context.Context, prefix string) iter.Seq2[ObjectInfo, error] {
+	return func(yield func(ObjectInfo, error) bool) {
+		pager := a.container.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{Prefix: aws.String(prefix)})
+		for pager.More() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				yield(ObjectInfo{}, fmt.Errorf("failed to list objects: %w", err))
+				return
+			}
+			for _, item := range page.Segment.BlobItems {
+				info := ObjectInfo{Key: aws.ToString(item.Name)}
+				if props := item.Properties; props != nil {
+					info.Size = aws.ToInt64(props.ContentLength)
+					info.ContentType = aws.ToString(props.ContentType)
+					if props.ETag != nil {
+						info.ETag = string(*props.ETag)
+					}
+					if props.LastModified != nil {
+						info.LastModified = *props.LastModified
+					}
+				}
+				if !yield(info, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Delete removes the object stored under key. Deleting an object that does not exist succeeds.
+func (a *AzureStorage) Delete(ctx context.Context, key string) error {
+	_, err := a.container.NewBlobClient(key).Delete(ctx, nil)
+	if err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	return nil
+}
+
+// azureMetadata converts blob metadata to the lowercase names S3 returns.
+func azureMetadata(metadata map[string]*string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(metadata))
+	for name, value := range metadata {
+		result[strings.ToLower(name)] = aws.ToString(value)
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// BatchItem is an object uploaded by UploadBatch.
+type BatchItem struct {
+	Key string
+	// Reader is the content of the object. If it is nil, the file Filename is uploaded like by
+	// UploadFile, which keeps only the files being uploaded open.
+	Reader   io.Reader
+	Filename string
+	// Options are applied to the upload of this item.
+	Options []UploadOption
+}
+
+// BatchError reports the items of UploadBatch that failed. errors.Is and errors.As match the
+// errors of the items.
+type BatchError struct {
+	// Failed holds the errors of the failed items by key.
+	Failed map[string]error
+	Total  int
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("failed to upload %d of %d objects", len(e.Failed), e.Total)
+}
+
+func (e *BatchError) Unwrap() []error {
+	return slices.Collect(maps.Values(e.Failed))
+}
+
+// UploadBatch uploads items, concurrency at a time or 4 if it is not positive, e.g. tens of
+// thousands of small objects, which one at a time take mostly round trips. Unlike UploadDir it does
+// not stop at the first error: errs[i] is the error of items[i], nil once it is uploaded, and err is
+// a *BatchError summarizing the failures, if any. Items not attempted because ctx is done fail with
+// its error.
+func UploadBatch(ctx context.Context, items []BatchItem, concurrency int) (errs []error, err error) {
+	return defaultBucket().UploadBatch(ctx, items, concurrency)
+}
+
+// UploadBatch uploads items concurrently, see the package-level UploadBatch.
+func (b *Bucket) UploadBatch(ctx context.Context, items []BatchItem, concurrency int) (errs []error, err error) {
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	errs = make([]error, len(items))
+	attempted := make([]bool, len(items))
+	indexes := make([]int, len(items))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	// Failed items are recorded rather than returned, so forEachParallel goes on with the others.
+	forEachParallel(ctx, indexes, concurrency, func(ctx context.Context, i int) error {
+		attempted[i] = true
+		item := items[i]
+		if item.Reader != nil {
+			errs[i] = b.Upload(ctx, item.Key, item.Reader, item.Options...)
+		} else {
+			errs[i] = b.UploadFile(ctx, item.Key, item.Filename, item.Options...)
+		}
+		return nil
+	})
+
+	failed := map[string]error{}
+	for i := range errs {
+		if !attempted[i] {
+			errs[i] = fmt.Errorf("failed to upload object: %w", ctx.Err())
+		}
+		if errs[i] != nil {
+			failed[items[i].Key] = errs[i]
+		}
+	}
+	if len(failed) > 0 {
+		return errs, &BatchError{Failed: failed, Total: len(items)}
+	}
+	return errs, nil
+}
@@ -0,0 +1,246 @@
+package s3
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// WithCache makes Download keep the content of downloaded objects in the directory dir, up to
+// maxBytes in total, and serve repeated downloads of unchanged objects from there, e.g. for workers
+// that read the same reference files over and over:
+//
+//	s3.Init(s3.WithCache(filepath.Join(os.TempDir(), "s3cache"), 10<<30))
+//
+// Every Download still asks S3 whether the object has changed since, with the ETag of the cached
+// content, which costs a request but no transfer while it has not. Content is cached once it was
+// read to the end and verified, keyed by ETag, and the least recently used is removed when the
+// cache grows over maxBytes. Objects stored with WithGzip are not cached. Errors of the cache do not
+// fail downloads, which then go to S3. The directory may be shared by the buckets of a process.
+// Temporary files of downloads cut short by the exit of a process are removed when a bucket is opened
+// with the directory once they are an hour old.
+func WithCache(dir string, maxBytes int64) Option {
+	return func(c *config) {
+		c.cacheDir = dir
+		c.cacheMaxBytes = maxBytes
+	}
+}
+
+// cacheOrphanAge is how long a temporary file of the cache is left unwritten before it is taken for
+// one left behind by a process that exited while downloading.
+const cacheOrphanAge = time.Hour
+
+// diskCache is the cache of WithCache. Content is stored under objects/ by the hash of its ETag,
+// and the ObjectInfo of each cached key under keys/ by the hash of bucket and key.
+type diskCache struct {
+	dir      string
+	maxBytes int64
+	// mu serializes evictions.
+	mu sync.Mutex
+}
+
+// newDiskCache returns the cache in dir, creating the directory if missing and removing the
+// temporary files of downloads that were never finished.
+func newDiskCache(dir string, maxBytes int64) (*diskCache, error) {
+	for _, sub := range []string{"objects", "keys"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+	}
+	c := &diskCache{dir: dir, maxBytes: maxBytes}
+	c.removeOrphans()
+	return c, nil
+}
+
+// removeOrphans removes the temporary files of store and commit that have not been written for
+// cacheOrphanAge, as those of downloads in progress in other processes sharing dir are kept.
+func (c *diskCache) removeOrphans() {
+	for _, pattern := range []string{"download-*", "info-*"} {
+		names, _ := filepath.Glob(filepath.Join(c.dir, pattern))
+		for _, name := range names {
+			if info, err := os.Stat(name); err == nil && time.Since(info.ModTime()) > cacheOrphanAge {
+				os.Remove(name)
+			}
+		}
+	}
+}
+
+func (c *diskCache) contentPath(etag string) string {
+	return filepath.Join(c.dir, "objects", fmt.Sprintf("%x", sha256.Sum256([]byte(etag))))
+}
+
+func (c *diskCache) infoPath(bucket, key string) string {
+	return filepath.Join(c.dir, "keys", fmt.Sprintf("%x.json", sha256.Sum256([]byte(bucket+"/"+key))))
+}
+
+// lookup returns the ObjectInfo of the content cached for key, or nil if there is none.
+func (c *diskCache) lookup(bucket, key string) *ObjectInfo {
+	data, err := os.ReadFile(c.infoPath(bucket, key))
+	if err != nil {
+		return nil
+	}
+	var info ObjectInfo
+	if err := json.Unmarshal(data, &info); err != nil || info.Key != key {
+		return nil
+	}
+	if _, err := os.Stat(c.contentPath(info.ETag)); err != nil {
+		return nil
+	}
+	return &info
+}
+
+// open returns the content cached for info, marking it as recently used.
+func (c *diskCache) open(info ObjectInfo) (*os.File, error) {
+	path := c.contentPath(info.ETag)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return file, nil
+}
+
+// store returns obj with its content written to the cache as it is read, and recorded once it was
+// read to the end. Objects larger than the cache are returned as they are.
+func (c *diskCache) store(bucket string, obj *Object) *Object {
+	if obj.Size > c.maxBytes {
+		return obj
+	}
+	file, err := os.CreateTemp(c.dir, "download-*")
+	if err != nil {
+		return obj
+	}
+	return &Object{
+		ReadCloser: &cachingReader{ReadCloser: obj.ReadCloser, file: file, cache: c, bucket: bucket, info: obj.ObjectInfo},
+		ObjectInfo: obj.ObjectInfo,
+	}
+}
+
+// commit moves the content downloaded to file into the cache as that of info and evicts the least
+// recently used content if the cache has grown too large.
+func (c *diskCache) commit(file *os.File, bucket string, info ObjectInfo) error {
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(file.Name(), c.contentPath(info.ETag)); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, "info-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.infoPath(bucket, info.Key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	c.evict()
+	return nil
+}
+
+// evict removes the least recently used content until the cache holds at most maxBytes. Entries of
+// keys whose content was removed are ignored by lookup.
+func (c *diskCache) evict() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(filepath.Join(c.dir, "objects"))
+	if err != nil {
+		return
+	}
+	var files []fs.FileInfo
+	var total int64
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil {
+			files = append(files, info)
+			total += info.Size()
+		}
+	}
+	if total <= c.maxBytes {
+		return
+	}
+
+	slices.SortFunc(files, func(a, b fs.FileInfo) int { return a.ModTime().Compare(b.ModTime()) })
+	for _, info := range files {
+		if total <= c.maxBytes {
+			return
+		}
+		if err := os.Remove(filepath.Join(c.dir, "objects", info.Name())); err == nil {
+			total -= info.Size()
+		}
+	}
+}
+
+// cachingReader copies the content of a download to file and commits it to the cache once it was
+// read to the end without error. Content closed early is discarded.
+type cachingReader struct {
+	io.ReadCloser
+	file   *os.File
+	cache  *diskCache
+	bucket string
+	info   ObjectInfo
+	failed bool
+	done   bool
+}
+
+func (r *cachingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 && !r.failed {
+		if _, werr := r.file.Write(p[:n]); werr != nil {
+			r.failed = true
+		}
+	}
+	if err == io.EOF && !r.failed && !r.done {
+		r.done = true
+		if r.cache.commit(r.file, r.bucket, r.info) != nil {
+			os.Remove(r.file.Name())
+		}
+	} else if err != nil && err != io.EOF {
+		r.failed = true
+	}
+	return n, err
+}
+
+func (r *cachingReader) Close() error {
+	if !r.done {
+		r.done = true
+		r.file.Close()
+		os.Remove(r.file.Name())
+	}
+	return r.ReadCloser.Close()
+}
+
+// cachedObject returns the cached content body of info as an Object, applying o like download.
+func cachedObject(body io.ReadCloser, info ObjectInfo, o downloadOptions) (*Object, error) {
+	if err := checkEncryptionContext(info.Key, info.Metadata, o.encryptionContext); err != nil {
+		body.Close()
+		return nil, err
+	}
+	if o.progress != nil {
+		body = struct {
+			io.Reader
+			io.Closer
+		}{&progressReader{Reader: body, fn: o.progress, total: info.Size}, body}
+	}
+	return &Object{ReadCloser: body, ObjectInfo: info}, nil
+}
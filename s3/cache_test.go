@@ -0,0 +1,109 @@
+package s3
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cacheDownload stores content as a download of key through c, reading it like Download's caller.
+func cacheDownload(t *testing.T, c *diskCache, key, etag, content string) {
+	t.Helper()
+	obj := c.store("bucket", &Object{
+		ReadCloser: io.NopCloser(strings.NewReader(content)),
+		ObjectInfo: ObjectInfo{Key: key, ETag: etag, Size: int64(len(content))},
+	})
+	if _, err := io.ReadAll(obj); err != nil {
+		t.Fatal(err)
+	}
+	obj.Close()
+}
+
+func TestDiskCache(t *testing.T) {
+	c, err := newDiskCache(t.TempDir(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.lookup("bucket", "a") != nil {
+		t.Fatal("found a key that was never cached")
+	}
+
+	cacheDownload(t, c, "a", `"1"`, "aaaa")
+	info := c.lookup("bucket", "a")
+	if info == nil || info.ETag != `"1"` {
+		t.Fatalf("got %+v after caching a", info)
+	}
+	if c.lookup("other", "a") != nil {
+		t.Error("found a in another bucket")
+	}
+	file, err := c.open(*info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(file)
+	file.Close()
+	if string(data) != "aaaa" {
+		t.Errorf("got cached content %q", data)
+	}
+
+	// Content closed before the end is not cached.
+	obj := c.store("bucket", &Object{
+		ReadCloser: io.NopCloser(strings.NewReader("bbbb")),
+		ObjectInfo: ObjectInfo{Key: "b", ETag: `"2"`, Size: 4},
+	})
+	obj.Read(make([]byte, 2))
+	obj.Close()
+	if c.lookup("bucket", "b") != nil {
+		t.Error("cached content that was not read to the end")
+	}
+
+	// Objects larger than the cache are not cached.
+	cacheDownload(t, c, "large", `"3"`, strings.Repeat("x", 11))
+	if c.lookup("bucket", "large") != nil {
+		t.Error("cached an object larger than the cache")
+	}
+
+	// The least recently used content is evicted once the cache is over maxBytes.
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(c.contentPath(`"1"`), old, old)
+	cacheDownload(t, c, "c", `"4"`, "cccc")
+	cacheDownload(t, c, "d", `"5"`, "dddd")
+	if c.lookup("bucket", "a") != nil {
+		t.Error("a was not evicted")
+	}
+	if c.lookup("bucket", "c") == nil || c.lookup("bucket", "d") == nil {
+		t.Error("recently cached content was evicted")
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(c.dir, "download-*"))
+	if len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+}
+
+func TestDiskCacheRemovesOrphans(t *testing.T) {
+	dir := t.TempDir()
+	orphan := filepath.Join(dir, "download-1")
+	recent := filepath.Join(dir, "info-2")
+	for _, name := range []string{orphan, recent} {
+		if err := os.WriteFile(name, []byte("partial"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * cacheOrphanAge)
+	os.Chtimes(orphan, old, old)
+
+	if _, err := newDiskCache(dir, 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Error("orphaned download was not removed")
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Error("recent temporary file was removed")
+	}
+}
@@ -0,0 +1,86 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// contentHashMetadata is the metadata in which WithSkipUnchanged stores the SHA-256 of the content.
+const contentHashMetadata = "sha256"
+
+// casPrefix is the prefix under which PutCAS stores objects, followed by the SHA-256 of their content.
+const casPrefix = "cas/"
+
+// PutCAS stores the content of reader under a key derived from its SHA-256, cas/<hex digest>, and
+// returns the key, e.g. for attachments, which are then stored once however often they are
+// attached. Content already stored is not uploaded again, so objects under cas/ are immutable and
+// never overwritten. The content is read twice, from the start again if reader is an io.Seeker and
+// from a temporary file otherwise. It is uploaded with a SHA-256 checksum, see WithChecksum.
+func PutCAS(ctx context.Context, reader io.Reader, opts ...UploadOption) (string, error) {
+	return defaultBucket().PutCAS(ctx, reader, opts...)
+}
+
+// PutCAS stores the content of reader under a key derived from its hash, see the package-level PutCAS.
+func (b *Bucket) PutCAS(ctx context.Context, reader io.Reader, opts ...UploadOption) (key string, err error) {
+	ctx, op := b.startOperation(ctx, "PutCAS")
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return "", err
+	}
+
+	sum, body, done, err := hashContent(reader)
+	if err != nil {
+		return "", err
+	}
+	defer done()
+	key = casPrefix + hex.EncodeToString(sum)
+	op.setKey(key)
+
+	_, err = b.Stat(ctx, key)
+	var notFound *NotFoundError
+	if err == nil {
+		return key, nil
+	}
+	if !errors.As(err, &notFound) {
+		return "", err
+	}
+
+	opts = append(opts, WithChecksum(ChecksumSHA256), WithIfNotExists())
+	err = b.Upload(ctx, key, body, opts...)
+	// Someone else stored the same content in the meantime.
+	var preconditionFailed *PreconditionFailedError
+	if err != nil && !errors.As(err, &preconditionFailed) {
+		return "", err
+	}
+	return key, nil
+}
+
+// hashContent returns the SHA-256 of the content of r and a reader of the same content, rewound
+// if r is an io.Seeker and read from a temporary file otherwise, which done removes.
+func hashContent(r io.Reader) (sum []byte, body io.Reader, done func(), err error) {
+	hash := sha256.New()
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			_, err = io.Copy(hash, seeker)
+		}
+		if err == nil {
+			_, err = seeker.Seek(start, io.SeekStart)
+		}
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to hash content: %w", err)
+		}
+		return hash.Sum(nil), r, func() {}, nil
+	}
+
+	spooled, _, err := spool(io.TeeReader(r, hash))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to hash content: %w", err)
+	}
+	return hash.Sum(nil), spooled, func() { spooled.Close() }, nil
+}
@@ -0,0 +1,51 @@
+package s3_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPutCAS(t *testing.T) {
+	ctx := context.Background()
+	b, _ := openTestBucket(t)
+	// Random content, so objects of earlier runs are not found.
+	data := rand.Text()
+	sum := sha256.Sum256([]byte(data))
+	want := "cas/" + hex.EncodeToString(sum[:])
+	t.Cleanup(func() { b.Delete(context.Background(), want) })
+
+	tests := []struct {
+		name   string
+		reader io.Reader
+	}{
+		{"seeker", strings.NewReader(data)},
+		{"stored already", strings.NewReader(data)},
+		{"not a seeker", io.MultiReader(strings.NewReader(data[:5]), strings.NewReader(data[5:]))},
+	}
+	for _, tt := range tests {
+		key, err := b.PutCAS(ctx, tt.reader)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if key != want {
+			t.Errorf("%s: stored under %s, want %s", tt.name, key, want)
+		}
+	}
+	if got := content(t, b, want); got != data {
+		t.Errorf("object holds %q, want %q", got, data)
+	}
+
+	other, err := b.PutCAS(ctx, strings.NewReader(data+"!"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Delete(context.Background(), other) })
+	if other == want {
+		t.Error("different content stored under the same key")
+	}
+}
@@ -0,0 +1,223 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// ChecksumAlgorithm is a checksum S3 computes and stores with an object, see WithChecksum.
+type ChecksumAlgorithm string
+
+const (
+	ChecksumCRC32  ChecksumAlgorithm = "CRC32"
+	ChecksumSHA256 ChecksumAlgorithm = "SHA256"
+)
+
+// ChecksumError reports that downloaded content does not match the checksum stored with the
+// object, or the MD5 in its ETag, i.e. it was corrupted in transit.
+type ChecksumError struct {
+	Key       string
+	Algorithm ChecksumAlgorithm
+	Expected  string
+	Actual    string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s: %s is %s, expected %s", e.Key, e.Algorithm, e.Actual, e.Expected)
+}
+
+// WithChecksum computes a checksum of the uploaded content, which S3 verifies on receipt and stores
+// with the object. Download and DownloadToFile verify objects that have one and report a mismatch
+// as a *ChecksumError. Objects uploaded in parts have a checksum per part. Objects without one are
+// verified against their ETag, which is the MD5 of the content unless they were uploaded in parts
+// or encrypted with SSE-KMS or SSE-C.
+func WithChecksum(algorithm ChecksumAlgorithm) UploadOption {
+	return func(o *uploadOptions) {
+		o.checksum = algorithm
+	}
+}
+
+// objectChecksum is the checksum stored with an object, one per part for multipart uploads.
+type objectChecksum struct {
+	algorithm ChecksumAlgorithm
+	parts     []partChecksum
+}
+
+type partChecksum struct {
+	size     int64
+	checksum string
+}
+
+// storedChecksums holds the checksum fields of a response, of which S3 sets at most one.
+type storedChecksums struct {
+	crc32, crc32c, sha1, sha256 *string
+}
+
+// stored returns the checksum that is set and its algorithm.
+func (s storedChecksums) stored() (ChecksumAlgorithm, string) {
+	switch {
+	case aws.ToString(s.crc32) != "":
+		return ChecksumCRC32, *s.crc32
+	case aws.ToString(s.crc32c) != "":
+		return "CRC32C", *s.crc32c
+	case aws.ToString(s.sha1) != "":
+		return "SHA1", *s.sha1
+	case aws.ToString(s.sha256) != "":
+		return ChecksumSHA256, *s.sha256
+	}
+	return "", ""
+}
+
+// checksumOf returns the checksum stored with the object key of the given size and version,
+// or nil if it has none. Checksums of multipart uploads cover the concatenated part checksums,
+// so the part checksums are fetched to verify the content part by part.
+func (b *Bucket) checksumOf(ctx context.Context, key string, versionID *string, size int64, stored storedChecksums) (*objectChecksum, error) {
+	algorithm, value := stored.stored()
+	if value == "" {
+		return nil, nil
+	}
+	if !strings.Contains(value, "-") {
+		return &objectChecksum{algorithm: algorithm, parts: []partChecksum{{size: size, checksum: value}}}, nil
+	}
+
+	sum := &objectChecksum{algorithm: algorithm}
+	input := &s3.GetObjectAttributesInput{
+		Bucket:           aws.String(b.name),
+		Key:              aws.String(key),
+		VersionId:        versionID,
+		ObjectAttributes: []types.ObjectAttributes{types.ObjectAttributesObjectParts},
+		MaxParts:         aws.Int32(1000),
+	}
+	for {
+		out, err := b.client.GetObjectAttributes(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get part checksums: %w", err)
+		}
+		if out.ObjectParts == nil {
+			return nil, nil
+		}
+		for _, part := range out.ObjectParts.Parts {
+			partAlgorithm, checksum := storedChecksums{part.ChecksumCRC32, part.ChecksumCRC32C, part.ChecksumSHA1, part.ChecksumSHA256}.stored()
+			if partAlgorithm != algorithm {
+				return nil, nil
+			}
+			sum.parts = append(sum.parts, partChecksum{size: aws.ToInt64(part.Size), checksum: checksum})
+		}
+		if !aws.ToBool(out.ObjectParts.IsTruncated) {
+			return sum, nil
+		}
+		input.PartNumberMarker = out.ObjectParts.NextPartNumberMarker
+	}
+}
+
+// etagChecksum returns the MD5 in the ETag of an object of the given size as its checksum, or nil
+// if the ETag is no MD5 of the content, as for objects uploaded in parts or encrypted with SSE-KMS or
+// SSE-C.
+func etagChecksum(etag string, size int64, sse types.ServerSideEncryption, customerAlgorithm *string) *objectChecksum {
+	if sse == types.ServerSideEncryptionAwsKms || sse == types.ServerSideEncryptionAwsKmsDsse || customerAlgorithm != nil {
+		return nil
+	}
+	digest, err := hex.DecodeString(strings.Trim(etag, `"`))
+	if err != nil || len(digest) != md5.Size {
+		return nil
+	}
+	return &objectChecksum{algorithm: "MD5", parts: []partChecksum{{size: size, checksum: base64.StdEncoding.EncodeToString(digest)}}}
+}
+
+func newChecksumHash(algorithm ChecksumAlgorithm) hash.Hash {
+	switch algorithm {
+	case "MD5":
+		return md5.New()
+	case ChecksumCRC32:
+		return crc32.NewIEEE()
+	case "CRC32C":
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case "SHA1":
+		return sha1.New()
+	default:
+		return sha256.New()
+	}
+}
+
+// verifyingReader verifies the content read through it against sum, part by part,
+// and fails the read that completes a part with a mismatch.
+type verifyingReader struct {
+	io.ReadCloser
+	key       string
+	sum       *objectChecksum
+	hash      hash.Hash
+	part      int
+	remaining int64 // bytes left in the current part
+}
+
+func newVerifyingReader(r io.ReadCloser, key string, sum *objectChecksum) *verifyingReader {
+	return &verifyingReader{
+		ReadCloser: r,
+		key:        key,
+		sum:        sum,
+		hash:       newChecksumHash(sum.algorithm),
+		remaining:  sum.parts[0].size,
+	}
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	b := p[:n]
+	for len(b) > 0 && r.part < len(r.sum.parts) {
+		chunk := b[:min(int64(len(b)), r.remaining)]
+		r.hash.Write(chunk)
+		b = b[len(chunk):]
+		r.remaining -= int64(len(chunk))
+		if r.remaining == 0 {
+			if verr := r.finishPart(); verr != nil {
+				return n, verr
+			}
+		}
+	}
+	if err == io.EOF && r.part < len(r.sum.parts) {
+		// Content that ends early fails the checksum of the part it ends in.
+		if verr := r.finishPart(); verr != nil {
+			return n, verr
+		}
+	}
+	return n, err
+}
+
+func (r *verifyingReader) finishPart() error {
+	expected := r.sum.parts[r.part].checksum
+	actual := base64.StdEncoding.EncodeToString(r.hash.Sum(nil))
+	if actual != expected {
+		return &ChecksumError{Key: r.key, Algorithm: r.sum.algorithm, Expected: expected, Actual: actual}
+	}
+
+	r.part++
+	r.hash.Reset()
+	if r.part < len(r.sum.parts) {
+		r.remaining = r.sum.parts[r.part].size
+	}
+	return nil
+}
+
+// withoutChecksumValidation stops the SDK from validating downloads itself, as its error is not
+// typed and it skips multipart uploads; verifyingReader validates them instead.
+func withoutChecksumValidation(o *s3.Options) {
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		// The middleware is only added to operations returning checksums, others have nothing to remove.
+		stack.Deserialize.Remove("AWSChecksum:ValidateOutputPayloadChecksum")
+		return nil
+	})
+}
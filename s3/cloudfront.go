@@ -0,0 +1,164 @@
+package s3
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithCloudFront sets the CloudFront distribution serving the bucket, by its domain such as
+// d111111abcdef8.cloudfront.net or cdn.example.com, and the key that signs URLs and cookies for it,
+// see CloudFrontURL and CloudFrontCookies: the ID of its public key in CloudFront and the PEM of
+// its RSA private key. Defaults to $CLOUDFRONT_DOMAIN, $CLOUDFRONT_KEY_PAIR_ID and
+// $CLOUDFRONT_PRIVATE_KEY.
+func WithCloudFront(domain, keyPairID string, privateKeyPEM []byte) Option {
+	return func(c *config) {
+		c.cloudFrontDomain = domain
+		c.cloudFrontKeyPairID = keyPairID
+		c.cloudFrontPrivateKeyPEM = privateKeyPEM
+	}
+}
+
+// CloudFrontURL returns a URL that downloads the object stored under key through the CloudFront
+// distribution set with WithCloudFront until expiry has passed, so downloads are cached by the CDN,
+// unlike those through PresignGet. The distribution has to restrict viewer access to the key group
+// of the key.
+func CloudFrontURL(key string, expiry time.Duration) (string, error) {
+	return defaultBucket().CloudFrontURL(key, expiry)
+}
+
+// CloudFrontURL returns a signed CloudFront URL of the object stored under key, see the package-level CloudFrontURL.
+func (b *Bucket) CloudFrontURL(key string, expiry time.Duration) (string, error) {
+	if err := b.checkOpen(); err != nil {
+		return "", err
+	}
+	if err := b.checkCloudFront(expiry); err != nil {
+		return "", err
+	}
+
+	resource := "https://" + b.settings.cloudFrontDomain + "/" + escapeKey(key)
+	expires := time.Now().Add(expiry).Unix()
+	// The canned policy is not sent, CloudFront rebuilds it from the URL and Expires.
+	policy := fmt.Sprintf(`{"Statement":[{"Resource":"%s","Condition":{"DateLessThan":{"AWS:EpochTime":%d}}}]}`, resource, expires)
+	signature, err := b.signCloudFrontPolicy(policy)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"Expires":     {strconv.FormatInt(expires, 10)},
+		"Signature":   {signature},
+		"Key-Pair-Id": {b.settings.cloudFrontKeyPairID},
+	}
+	return resource + "?" + query.Encode(), nil
+}
+
+// CloudFrontCookies returns the cookies that allow downloading every object whose key starts
+// with prefix through the CloudFront distribution set with WithCloudFront until expiry has passed,
+// e.g. for a video streamed in many segments or a whole gallery. Set their Domain so browsers send
+// them to the distribution, which has to be a subdomain of the site's domain, e.g. cdn.example.com
+// for example.com:
+//
+//	cookies, err := s3.CloudFrontCookies("users/42/", time.Hour)
+//	for _, cookie := range cookies {
+//		cookie.Domain = "example.com"
+//		http.SetCookie(w, cookie)
+//	}
+func CloudFrontCookies(prefix string, expiry time.Duration) ([]*http.Cookie, error) {
+	return defaultBucket().CloudFrontCookies(prefix, expiry)
+}
+
+// CloudFrontCookies returns signed CloudFront cookies for the objects under prefix, see the package-level CloudFrontCookies.
+func (b *Bucket) CloudFrontCookies(prefix string, expiry time.Duration) ([]*http.Cookie, error) {
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := b.checkCloudFront(expiry); err != nil {
+		return nil, err
+	}
+
+	expires := time.Now().Add(expiry)
+	policy, err := json.Marshal(map[string]any{
+		"Statement": []any{map[string]any{
+			"Resource": "https://" + b.settings.cloudFrontDomain + "/" + escapeKey(prefix) + "*",
+			"Condition": map[string]any{
+				"DateLessThan": map[string]int64{"AWS:EpochTime": expires.Unix()},
+			},
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode policy: %w", err)
+	}
+	signature, err := b.signCloudFrontPolicy(string(policy))
+	if err != nil {
+		return nil, err
+	}
+
+	cookie := func(name, value string) *http.Cookie {
+		return &http.Cookie{Name: name, Value: value, Path: "/", Expires: expires, Secure: true, HttpOnly: true}
+	}
+	return []*http.Cookie{
+		cookie("CloudFront-Policy", cloudFrontBase64(policy)),
+		cookie("CloudFront-Signature", signature),
+		cookie("CloudFront-Key-Pair-Id", b.settings.cloudFrontKeyPairID),
+	}, nil
+}
+
+// checkCloudFront returns an error if signing for CloudFront is not configured or expiry is not positive.
+func (b *Bucket) checkCloudFront(expiry time.Duration) error {
+	if b.settings.cloudFrontKey == nil {
+		return errors.New("no CloudFront distribution configured, see WithCloudFront")
+	}
+	if expiry <= 0 {
+		return fmt.Errorf("expiry must be positive, got %v", expiry)
+	}
+	return nil
+}
+
+// signCloudFrontPolicy returns the signature of policy in the encoding CloudFront expects.
+func (b *Bucket) signCloudFrontPolicy(policy string) (string, error) {
+	digest := sha1.Sum([]byte(policy))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, b.settings.cloudFrontKey, crypto.SHA1, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign policy: %w", err)
+	}
+	return cloudFrontBase64(signature), nil
+}
+
+// cloudFrontBase64 encodes b in base64 with the characters invalid in URLs replaced as CloudFront
+// expects.
+func cloudFrontBase64(b []byte) string {
+	return strings.NewReplacer("+", "-", "=", "_", "/", "~").Replace(base64.StdEncoding.EncodeToString(b))
+}
+
+// parseCloudFrontKey parses the PEM of an RSA private key in PKCS #1 or PKCS #8 form.
+func parseCloudFrontKey(privateKeyPEM []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an RSA key, got %T", key)
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,231 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// composeSegment is the range of a source object, from first to last inclusive, in a part of Compose.
+type composeSegment struct {
+	src         ObjectInfo
+	first, last int64
+}
+
+// composePart is a part of Compose, either one range copied by S3 or ranges downloaded and uploaded
+// together, as every part but the last has to be at least 5MB.
+type composePart struct {
+	segments []composeSegment
+	copy     bool
+}
+
+// Compose concatenates the objects stored under srcKeys, in order, into dstKey, e.g. to stitch log
+// segments into one file:
+//
+//	err := s3.Compose(ctx, "logs/2024-06-01.log", "logs/2024-06-01/00.log", "logs/2024-06-01/01.log")
+//
+// The data is copied by S3 itself in the parts of a multipart upload, as many at a time as uploads.
+// As parts other than the last have to be at least 5MB, sources smaller than that are downloaded and
+// uploaded together with the start of the next source instead, which takes up to 5MB of memory per
+// part. dstKey gets the content type of the first source and no metadata; it may be one of srcKeys.
+// A missing source is reported as a *NotFoundError, and sources changed while they are composed
+// fail the compose, which leaves dstKey as it was.
+func Compose(ctx context.Context, dstKey string, srcKeys ...string) error {
+	return defaultBucket().Compose(ctx, dstKey, srcKeys...)
+}
+
+// Compose concatenates the objects stored under srcKeys into dstKey, see the package-level Compose.
+func (b *Bucket) Compose(ctx context.Context, dstKey string, srcKeys ...string) (err error) {
+	ctx, op := b.startOperation(ctx, "Compose", attribute.String("aws.s3.key", dstKey))
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+	if len(srcKeys) == 0 {
+		return fmt.Errorf("no objects to compose into %s", dstKey)
+	}
+
+	srcs := make([]ObjectInfo, len(srcKeys))
+	indexes := make([]int, len(srcKeys))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	err = forEachParallel(ctx, indexes, b.settings.concurrency, func(ctx context.Context, i int) error {
+		src, err := b.Stat(ctx, srcKeys[i])
+		srcs[i] = src
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to compose object: %w", err)
+	}
+
+	var size int64
+	for _, src := range srcs {
+		size += src.Size
+	}
+	op.setBytes(size)
+
+	parts := planCompose(srcs)
+	if len(parts) > int(manager.MaxUploadParts) {
+		return fmt.Errorf("failed to compose object: %d parts needed, at most %d are allowed", len(parts), manager.MaxUploadParts)
+	}
+	if err := b.composeMultipart(ctx, dstKey, srcs[0].ContentType, parts); err != nil {
+		return fmt.Errorf("failed to compose object: %w", err)
+	}
+	return nil
+}
+
+// planCompose splits srcs into the parts of Compose. Ranges of at least 5MB are copied in parts of up
+// to copyPartSize, smaller ones are gathered into parts of 5MB, taking the start of the next source
+// if needed.
+func planCompose(srcs []ObjectInfo) []composePart {
+	var parts []composePart
+	var pending []composeSegment
+	var pendingSize int64
+	flush := func() {
+		parts = append(parts, composePart{segments: pending})
+		pending, pendingSize = nil, 0
+	}
+
+	for _, src := range srcs {
+		offset := int64(0)
+		if pendingSize > 0 {
+			n := min(manager.MinUploadPartSize-pendingSize, src.Size)
+			if n > 0 {
+				pending = append(pending, composeSegment{src: src, first: 0, last: n - 1})
+				pendingSize += n
+				offset = n
+			}
+			if pendingSize >= manager.MinUploadPartSize {
+				flush()
+			}
+		}
+
+		if remaining := src.Size - offset; remaining < manager.MinUploadPartSize {
+			if remaining > 0 {
+				pending = append(pending, composeSegment{src: src, first: offset, last: src.Size - 1})
+				pendingSize += remaining
+			}
+			continue
+		}
+		for offset < src.Size {
+			end := min(offset+copyPartSize, src.Size)
+			// A rest too small for a part of its own is copied with this one.
+			if src.Size-end < manager.MinUploadPartSize {
+				end = src.Size
+			}
+			parts = append(parts, composePart{segments: []composeSegment{{src: src, first: offset, last: end - 1}}, copy: true})
+			offset = end
+		}
+	}
+
+	if pendingSize > 0 || len(parts) == 0 {
+		flush()
+	}
+	return parts
+}
+
+// composeMultipart uploads parts to dstKey in a multipart upload, aborting it on failure.
+func (b *Bucket) composeMultipart(ctx context.Context, dstKey, contentType string, parts []composePart) error {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(dstKey),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	input.ServerSideEncryption, input.SSEKMSKeyId = b.encryption()
+	upload, err := b.client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	completed := make([]types.CompletedPart, len(parts))
+	indexes := make([]int, len(parts))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	err = forEachParallel(ctx, indexes, b.settings.concurrency, func(ctx context.Context, i int) error {
+		partNumber := aws.Int32(int32(i + 1))
+		part := parts[i]
+
+		if part.copy {
+			seg := part.segments[0]
+			out, err := b.client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+				Bucket:            aws.String(b.name),
+				Key:               aws.String(dstKey),
+				UploadId:          upload.UploadId,
+				PartNumber:        partNumber,
+				CopySource:        aws.String(b.copySource(seg.src.Key)),
+				CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", seg.first, seg.last)),
+				CopySourceIfMatch: aws.String(seg.src.ETag),
+			})
+			if err != nil {
+				return notFound(err, seg.src.Key)
+			}
+			completed[i] = types.CompletedPart{ETag: out.CopyPartResult.ETag, PartNumber: partNumber}
+			return nil
+		}
+
+		var buf bytes.Buffer
+		for _, seg := range part.segments {
+			if err := b.readRange(ctx, &buf, seg); err != nil {
+				return err
+			}
+		}
+		out, err := b.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(b.name),
+			Key:        aws.String(dstKey),
+			UploadId:   upload.UploadId,
+			PartNumber: partNumber,
+			Body:       bytes.NewReader(buf.Bytes()),
+		})
+		if err != nil {
+			return err
+		}
+		completed[i] = types.CompletedPart{ETag: out.ETag, PartNumber: partNumber}
+		return nil
+	})
+	if err != nil {
+		b.abortUpload(dstKey, upload.UploadId)
+		return err
+	}
+
+	_, err = b.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(b.name),
+		Key:             aws.String(dstKey),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		b.abortUpload(dstKey, upload.UploadId)
+		return err
+	}
+	return nil
+}
+
+// readRange appends the range of seg to buf, failing if the source has changed since it was described.
+func (b *Bucket) readRange(ctx context.Context, buf *bytes.Buffer, seg composeSegment) error {
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket:  aws.String(b.name),
+		Key:     aws.String(seg.src.Key),
+		Range:   aws.String(fmt.Sprintf("bytes=%d-%d", seg.first, seg.last)),
+		IfMatch: aws.String(seg.src.ETag),
+	})
+	if err != nil {
+		return notFound(err, seg.src.Key)
+	}
+	defer out.Body.Close()
+	if _, err := io.Copy(buf, out.Body); err != nil {
+		return fmt.Errorf("failed to read %s: %w", seg.src.Key, err)
+	}
+	return nil
+}
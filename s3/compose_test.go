@@ -0,0 +1,55 @@
+package s3
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+)
+
+func TestPlanCompose(t *testing.T) {
+	const mb = 1024 * 1024
+	tests := []struct {
+		name  string
+		sizes []int64
+		// parts holds the size of each part, negative for parts copied by S3.
+		parts []int64
+	}{
+		{"small", []int64{1024}, []int64{1024}},
+		{"small sources share parts", []int64{3 * mb, 3 * mb}, []int64{5 * mb, 1 * mb}},
+		{"large source is copied", []int64{1200 * mb}, []int64{-512 * mb, -512 * mb, -176 * mb}},
+		{"small rest joins the last copied part", []int64{1027 * mb}, []int64{-512 * mb, -515 * mb}},
+		{"small source before a large one", []int64{1 * mb, 20 * mb}, []int64{5 * mb, -16 * mb}},
+		{"empty", []int64{0}, []int64{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srcs := make([]ObjectInfo, len(tt.sizes))
+			for i, size := range tt.sizes {
+				srcs[i] = ObjectInfo{Key: string(rune('a' + i)), Size: size}
+			}
+
+			parts := planCompose(srcs)
+			var got []int64
+			for i, part := range parts {
+				var size int64
+				for _, seg := range part.segments {
+					size += seg.last - seg.first + 1
+				}
+				if part.copy {
+					size = -size
+				} else if i < len(parts)-1 && size < manager.MinUploadPartSize {
+					t.Errorf("part %d is %d bytes, less than the minimum", i, size)
+				}
+				got = append(got, size)
+			}
+			if len(got) != len(tt.parts) {
+				t.Fatalf("got parts %v, want %v", got, tt.parts)
+			}
+			for i := range got {
+				if got[i] != tt.parts[i] {
+					t.Fatalf("got parts %v, want %v", got, tt.parts)
+				}
+			}
+		})
+	}
+}
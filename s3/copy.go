@@ -0,0 +1,232 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// maxCopySize is the largest object a single CopyObject request can copy.
+	maxCopySize = 5 * 1024 * 1024 * 1024
+	// copyPartSize is the part size of multipart copies of larger objects.
+	copyPartSize = 512 * 1024 * 1024
+)
+
+// Copy copies the object stored under srcKey to dstKey within the bucket, including its content
+// type, content encoding, user metadata and tags, but not its ACL. The data is copied by S3 itself,
+// so nothing is downloaded. Objects over 5GB are copied in parts, as many at a time as uploads.
+// A missing source is reported as a *NotFoundError.
+func Copy(ctx context.Context, srcKey, dstKey string) error {
+	return defaultBucket().Copy(ctx, srcKey, dstKey)
+}
+
+// Copy copies the object stored under srcKey to dstKey within the bucket, see the package-level Copy.
+func (b *Bucket) Copy(ctx context.Context, srcKey, dstKey string) (err error) {
+	ctx, op := b.startOperation(ctx, "Copy", attribute.String("aws.s3.key", dstKey), attribute.String("aws.s3.copy_source", srcKey))
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+
+	src, err := b.Stat(ctx, srcKey)
+	if err != nil {
+		return fmt.Errorf("failed to copy object: %w", err)
+	}
+	op.setBytes(src.Size)
+
+	return b.copyObject(ctx, src, dstKey)
+}
+
+// Move moves the object stored under srcKey to dstKey within the bucket, e.g. to mark a file
+// as processed. The object is copied like by Copy, and the source is only deleted once the copy
+// has been checked against it, so a failed move leaves the source in place.
+func Move(ctx context.Context, srcKey, dstKey string) error {
+	return defaultBucket().Move(ctx, srcKey, dstKey)
+}
+
+// Move moves the object stored under srcKey to dstKey within the bucket, see the package-level Move.
+func (b *Bucket) Move(ctx context.Context, srcKey, dstKey string) (err error) {
+	ctx, op := b.startOperation(ctx, "Move", attribute.String("aws.s3.key", dstKey), attribute.String("aws.s3.copy_source", srcKey))
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+
+	src, err := b.Stat(ctx, srcKey)
+	if err != nil {
+		return fmt.Errorf("failed to move object: %w", err)
+	}
+	if err := b.copyObject(ctx, src, dstKey); err != nil {
+		return fmt.Errorf("failed to move object: %w", err)
+	}
+
+	dst, err := b.Stat(ctx, dstKey)
+	if err != nil {
+		return fmt.Errorf("failed to verify copy: %w", err)
+	}
+	// ETags of multipart objects depend on the part sizes, so only single-part ones are comparable,
+	// and not at all in directory buckets.
+	singlePart := !strings.Contains(src.ETag, "-") && !strings.Contains(dst.ETag, "-")
+	comparable := singlePart && b.settings.directoryZone == ""
+	if dst.Size != src.Size || comparable && dst.ETag != src.ETag {
+		return fmt.Errorf("failed to verify copy: %s does not match %s", dstKey, srcKey)
+	}
+
+	if err := b.Delete(ctx, srcKey); err != nil {
+		return fmt.Errorf("failed to move object: %w", err)
+	}
+	return nil
+}
+
+// copyObject copies the object described by src to dstKey.
+func (b *Bucket) copyObject(ctx context.Context, src ObjectInfo, dstKey string) error {
+	var err error
+	if src.Size <= maxCopySize {
+		input := &s3.CopyObjectInput{
+			Bucket:     aws.String(b.name),
+			Key:        aws.String(dstKey),
+			CopySource: aws.String(b.copySource(src.Key)),
+		}
+		input.ServerSideEncryption, input.SSEKMSKeyId = b.encryption()
+		var metadata map[string]string
+		input.SSEKMSEncryptionContext, metadata = copiedEncryptionContext(src.Metadata, input.ServerSideEncryption)
+		if len(metadata) != len(src.Metadata) {
+			err = b.replaceMetadata(ctx, src, metadata, input)
+		}
+		if err == nil {
+			_, err = b.client.CopyObject(ctx, input)
+		}
+	} else {
+		err = b.copyMultipart(ctx, src, dstKey)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to copy object: %w", notFound(err, src.Key))
+	}
+
+	return nil
+}
+
+// copyMultipart copies src to dstKey with UploadPartCopy, aborting the upload on failure. The
+// headers, user metadata and tags of src are copied like by CopyObject.
+func (b *Bucket) copyMultipart(ctx context.Context, src ObjectInfo, dstKey string) error {
+	head, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:  aws.String(b.name),
+		Key:     aws.String(src.Key),
+		IfMatch: aws.String(src.ETag),
+	})
+	if err != nil {
+		return err
+	}
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:             aws.String(b.name),
+		Key:                aws.String(dstKey),
+		ContentType:        head.ContentType,
+		ContentEncoding:    head.ContentEncoding,
+		ContentDisposition: head.ContentDisposition,
+		ContentLanguage:    head.ContentLanguage,
+		CacheControl:       head.CacheControl,
+	}
+	// Directory buckets do not support tags.
+	if b.settings.directoryZone == "" {
+		tags, err := b.GetTags(ctx, src.Key)
+		if err != nil {
+			return err
+		}
+		if len(tags) > 0 {
+			input.Tagging = aws.String(encodeTags(tags))
+		}
+	}
+	input.ServerSideEncryption, input.SSEKMSKeyId = b.encryption()
+	input.SSEKMSEncryptionContext, input.Metadata = copiedEncryptionContext(head.Metadata, input.ServerSideEncryption)
+	upload, err := b.client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	parts := make([]types.CompletedPart, (src.Size+copyPartSize-1)/copyPartSize)
+	indexes := make([]int, len(parts))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	// The first failure cancels the part copies still running.
+	err = forEachParallel(ctx, indexes, b.settings.concurrency, func(ctx context.Context, i int) error {
+		first := int64(i) * copyPartSize
+		last := min(first+copyPartSize, src.Size) - 1
+		partNumber := aws.Int32(int32(i + 1))
+
+		out, err := b.client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:            aws.String(b.name),
+			Key:               aws.String(dstKey),
+			UploadId:          upload.UploadId,
+			PartNumber:        partNumber,
+			CopySource:        aws.String(b.copySource(src.Key)),
+			CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", first, last)),
+			CopySourceIfMatch: aws.String(src.ETag),
+		})
+		if err != nil {
+			return err
+		}
+		parts[i] = types.CompletedPart{ETag: out.CopyPartResult.ETag, PartNumber: partNumber}
+		return nil
+	})
+	if err != nil {
+		b.abortUpload(dstKey, upload.UploadId)
+		return err
+	}
+
+	_, err = b.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(b.name),
+		Key:             aws.String(dstKey),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		b.abortUpload(dstKey, upload.UploadId)
+		return err
+	}
+	return nil
+}
+
+// replaceMetadata makes input replace the user metadata of the copy of src with metadata, keeping
+// the other headers of src.
+func (b *Bucket) replaceMetadata(ctx context.Context, src ObjectInfo, metadata map[string]string, input *s3.CopyObjectInput) error {
+	head, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:  aws.String(b.name),
+		Key:     aws.String(src.Key),
+		IfMatch: aws.String(src.ETag),
+	})
+	if err != nil {
+		return err
+	}
+	input.MetadataDirective = types.MetadataDirectiveReplace
+	input.Metadata = metadata
+	input.ContentType = head.ContentType
+	input.ContentEncoding = head.ContentEncoding
+	input.ContentDisposition = head.ContentDisposition
+	input.ContentLanguage = head.ContentLanguage
+	input.CacheControl = head.CacheControl
+	return nil
+}
+
+// abortUpload discards the parts of a failed multipart upload so they are not billed.
+// It uses its own context, as the upload usually fails because ctx was cancelled.
+func (b *Bucket) abortUpload(key string, uploadID *string) {
+	b.client.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(b.name),
+		Key:      aws.String(key),
+		UploadId: uploadID,
+	})
+}
+
+// copySource returns the URL-encoded CopySource value of key in the bucket.
+func (b *Bucket) copySource(key string) string {
+	return b.name + "/" + escapeKey(key)
+}
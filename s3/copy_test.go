@@ -0,0 +1,97 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/michaldziurowski/one/s3"
+)
+
+// content returns the content of the object stored under key.
+func content(t *testing.T, b *s3.Bucket, key string) string {
+	t.Helper()
+	obj, err := b.Download(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Close()
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	b, prefix := openTestBucket(t)
+	src := prefix + "report.csv"
+	err := b.Upload(ctx, src, strings.NewReader("a,b\n1,2\n"), s3.WithMetadata(map[string]string{"owner": "test"}),
+		s3.WithTags(map[string]string{"kind": "report"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.Copy(ctx, src, prefix+"copy.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if got := content(t, b, prefix+"copy.csv"); got != "a,b\n1,2\n" {
+		t.Errorf("copy holds %q", got)
+	}
+	srcInfo, err := b.Stat(ctx, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := b.Stat(ctx, prefix+"copy.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ContentType != srcInfo.ContentType || !maps.Equal(info.Metadata, srcInfo.Metadata) {
+		t.Errorf("copy has %+v, want the content type and metadata of %+v", info, srcInfo)
+	}
+	srcTags, err := b.GetTags(ctx, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags, err := b.GetTags(ctx, prefix+"copy.csv"); err != nil || !maps.Equal(tags, srcTags) {
+		t.Errorf("copy has tags %v, error %v, want %v", tags, err, srcTags)
+	}
+	if exists, err := b.Exists(ctx, src); err != nil || !exists {
+		t.Errorf("source exists %v, error %v, want kept", exists, err)
+	}
+
+	var notFound *s3.NotFoundError
+	if err := b.Copy(ctx, prefix+"missing", prefix+"copy.csv"); !errors.As(err, &notFound) {
+		t.Errorf("copy of a missing object returned %v, want a *NotFoundError", err)
+	}
+}
+
+func TestMove(t *testing.T) {
+	ctx := context.Background()
+	b, prefix := openTestBucket(t)
+	if err := b.Upload(ctx, prefix+"inbox/a.csv", strings.NewReader("a,b\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.Move(ctx, prefix+"inbox/a.csv", prefix+"done/a.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := b.Exists(ctx, prefix+"inbox/a.csv"); err != nil || exists {
+		t.Errorf("source of the move exists %v, error %v", exists, err)
+	}
+	if got := content(t, b, prefix+"done/a.csv"); got != "a,b\n" {
+		t.Errorf("moved object holds %q", got)
+	}
+
+	var notFound *s3.NotFoundError
+	if err := b.Move(ctx, prefix+"inbox/a.csv", prefix+"done/b.csv"); !errors.As(err, &notFound) {
+		t.Errorf("move of a missing object returned %v, want a *NotFoundError", err)
+	}
+	if exists, err := b.Exists(ctx, prefix+"done/b.csv"); err != nil || exists {
+		t.Errorf("failed move created its destination: %v, error %v", exists, err)
+	}
+}
@@ -0,0 +1,48 @@
+package s3
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// WithCredentials accesses the bucket with credentials from provider instead of the default chain
+// of environment variables, shared files and instance roles, e.g. rotating ones issued by Vault.
+// Credentials are cached until they are about to expire, as set by the provider. With WithAssumeRole,
+// they are used to assume the role.
+func WithCredentials(provider aws.CredentialsProvider) Option {
+	return func(c *config) {
+		c.credentialsProvider = provider
+	}
+}
+
+// WithAssumeRole accesses the bucket with temporary credentials of the IAM role roleARN, e.g. one
+// in another account, assumed with the credentials of the AWS configuration. externalID is passed
+// to STS if the trust policy of the role requires one, otherwise it is empty. The credentials are
+// refreshed before they expire. Defaults to $S3_ROLE_ARN and $S3_ROLE_EXTERNAL_ID.
+func WithAssumeRole(roleARN, externalID string) Option {
+	return func(c *config) {
+		c.roleARN = roleARN
+		c.externalID = externalID
+	}
+}
+
+// credentials returns the credentials of clients created from awsCfg with the options of c applied.
+func (c config) credentials(awsCfg aws.Config) aws.CredentialsProvider {
+	if c.credentialsProvider != nil {
+		awsCfg.Credentials = c.credentialsProvider
+		if _, ok := c.credentialsProvider.(*aws.CredentialsCache); !ok {
+			awsCfg.Credentials = aws.NewCredentialsCache(c.credentialsProvider)
+		}
+	}
+	if c.roleARN == "" {
+		return awsCfg.Credentials
+	}
+
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(awsCfg), c.roleARN, func(o *stscreds.AssumeRoleOptions) {
+		if c.externalID != "" {
+			o.ExternalID = aws.String(c.externalID)
+		}
+	})
+	return aws.NewCredentialsCache(provider)
+}
@@ -0,0 +1,230 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// NotFoundError reports that no object is stored under Key.
+type NotFoundError struct {
+	Key string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("object %s not found", e.Key)
+}
+
+// Delete removes the object stored under key. Deleting an object that does not exist succeeds,
+// so retried cleanups do not fail.
+func Delete(ctx context.Context, key string) error {
+	return defaultBucket().Delete(ctx, key)
+}
+
+// Delete removes the object stored under key, see the package-level Delete.
+func (b *Bucket) Delete(ctx context.Context, key string) (err error) {
+	ctx, op := b.startOperation(ctx, "Delete", attribute.String("aws.s3.key", key))
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+
+	_, err = b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(key),
+	})
+	// S3 itself reports success for missing objects, some compatible services do not.
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	return nil
+}
+
+// notFound returns a *NotFoundError for key if err reports a missing object, err otherwise.
+func notFound(err error, key string) error {
+	if isNotFound(err) {
+		return &NotFoundError{Key: key}
+	}
+	return err
+}
+
+// PreconditionFailedError is returned when an upload does not meet its condition, see WithIfNotExists
+// and WithIfMatch.
+type PreconditionFailedError struct {
+	Key string
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return fmt.Sprintf("object %s does not meet the upload condition", e.Key)
+}
+
+// preconditionFailed returns a *PreconditionFailedError for key if err reports a failed condition,
+// err otherwise. S3 reports a conflict with a concurrent conditional upload of the key as such.
+func preconditionFailed(err error, key string) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "PreconditionFailed" || apiErr.ErrorCode() == "ConditionalRequestConflict") {
+		return &PreconditionFailedError{Key: key}
+	}
+	return err
+}
+
+func isNotFound(err error) bool {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return true
+	}
+	// Operations such as GetObjectTagging do not model the error, only its code identifies it.
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && (apiErr.ErrorCode() == "NoSuchKey" || apiErr.ErrorCode() == "NotFound")
+}
+
+// deleteBatchSize is the most keys a single DeleteObjects request accepts.
+const deleteBatchSize = 1000
+
+// DeleteMany removes the objects stored under keys in batches of 1000. Objects that could not be
+// deleted are returned with their error, so a cleanup job can retry or log just those; err is
+// only set when a whole batch fails, in which case the remaining keys are not attempted.
+func DeleteMany(ctx context.Context, keys []string) (failed map[string]error, err error) {
+	return defaultBucket().DeleteMany(ctx, keys)
+}
+
+// DeleteMany removes the objects stored under keys in batches, see the package-level DeleteMany.
+func (b *Bucket) DeleteMany(ctx context.Context, keys []string) (failed map[string]error, err error) {
+	ctx, op := b.startOperation(ctx, "DeleteMany", attribute.Int("aws.s3.objects", len(keys)))
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	failed = map[string]error{}
+	for batch := range slices.Chunk(keys, deleteBatchSize) {
+		objects := make([]types.ObjectIdentifier, len(batch))
+		for i, key := range batch {
+			objects[i] = types.ObjectIdentifier{Key: aws.String(key)}
+		}
+		if _, err := b.deleteObjects(ctx, objects, failed); err != nil {
+			return failed, err
+		}
+	}
+
+	return failed, nil
+}
+
+// DeletePrefixOptions configures DeletePrefix.
+type DeletePrefixOptions struct {
+	// DryRun counts the objects that would be deleted without deleting them.
+	DryRun bool
+	// AllVersions also deletes every noncurrent version and delete marker in a versioned bucket,
+	// so the objects are gone for good rather than hidden behind a new delete marker.
+	AllVersions bool
+}
+
+// DeletePrefix removes every object whose key starts with prefix, e.g. "tmp/", and returns how many
+// were (or, with DryRun, would be) deleted. With AllVersions the count is of versions and delete markers.
+func DeletePrefix(ctx context.Context, prefix string, opts DeletePrefixOptions) (int, error) {
+	return defaultBucket().DeletePrefix(ctx, prefix, opts)
+}
+
+// DeletePrefix removes every object whose key starts with prefix, see the package-level DeletePrefix.
+func (b *Bucket) DeletePrefix(ctx context.Context, prefix string, opts DeletePrefixOptions) (deleted int, err error) {
+	ctx, op := b.startOperation(ctx, "DeletePrefix", attribute.String("aws.s3.prefix", prefix))
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return 0, err
+	}
+
+	failed := map[string]error{}
+	deletePage := func(objects []types.ObjectIdentifier) error {
+		if opts.DryRun || len(objects) == 0 {
+			deleted += len(objects)
+			return nil
+		}
+		n, err := b.deleteObjects(ctx, objects, failed)
+		deleted += n
+		return err
+	}
+
+	if opts.AllVersions {
+		paginator := s3.NewListObjectVersionsPaginator(b.client, &s3.ListObjectVersionsInput{
+			Bucket: aws.String(b.name),
+			Prefix: aws.String(prefix),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return deleted, fmt.Errorf("failed to list object versions: %w", err)
+			}
+			var objects []types.ObjectIdentifier
+			for _, v := range page.Versions {
+				objects = append(objects, types.ObjectIdentifier{Key: v.Key, VersionId: v.VersionId})
+			}
+			for _, m := range page.DeleteMarkers {
+				objects = append(objects, types.ObjectIdentifier{Key: m.Key, VersionId: m.VersionId})
+			}
+			// A page holds up to 1000 versions and as many delete markers.
+			for batch := range slices.Chunk(objects, deleteBatchSize) {
+				if err := deletePage(batch); err != nil {
+					return deleted, err
+				}
+			}
+		}
+	} else {
+		paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+			Bucket: aws.String(b.name),
+			Prefix: aws.String(prefix),
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return deleted, fmt.Errorf("failed to list objects: %w", err)
+			}
+			objects := make([]types.ObjectIdentifier, len(page.Contents))
+			for i, o := range page.Contents {
+				objects[i] = types.ObjectIdentifier{Key: o.Key}
+			}
+			if err := deletePage(objects); err != nil {
+				return deleted, err
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return deleted, fmt.Errorf("failed to delete %d objects under %s: %w", len(failed), prefix, errors.Join(slices.Collect(maps.Values(failed))...))
+	}
+	return deleted, nil
+}
+
+// deleteObjects deletes up to 1000 objects in one request, adds the ones that failed to failed, by
+// key and, for versions, "?versionId=" and the version ID, and returns how many were deleted.
+func (b *Bucket) deleteObjects(ctx context.Context, objects []types.ObjectIdentifier, failed map[string]error) (int, error) {
+	out, err := b.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(b.name),
+		Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete objects: %w", err)
+	}
+
+	for _, e := range out.Errors {
+		// Versions of the same key fail separately.
+		object := aws.ToString(e.Key)
+		if e.VersionId != nil {
+			object += "?versionId=" + aws.ToString(e.VersionId)
+		}
+		failed[object] = fmt.Errorf("failed to delete object %s: %s: %s", object, aws.ToString(e.Code), aws.ToString(e.Message))
+	}
+	return len(objects) - len(out.Errors), nil
+}
@@ -0,0 +1,50 @@
+package s3_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/michaldziurowski/one/s3"
+)
+
+func TestDeletePrefix(t *testing.T) {
+	ctx := context.Background()
+	b, prefix := openTestBucket(t)
+	for _, key := range []string{"logs/a", "logs/b", "logs/sub/c", "logsx", "other/d"} {
+		if err := b.Upload(ctx, prefix+key, strings.NewReader(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		opts  s3.DeletePrefixOptions
+		want  int
+		gone  []string
+		stays []string
+	}{
+		{name: "dry run", opts: s3.DeletePrefixOptions{DryRun: true}, want: 3, stays: []string{"logs/a", "logs/b", "logs/sub/c", "logsx"}},
+		{name: "delete", want: 3, gone: []string{"logs/a", "logs/b", "logs/sub/c"}, stays: []string{"logsx", "other/d"}},
+		{name: "nothing left", want: 0, stays: []string{"logsx", "other/d"}},
+	}
+	for _, tt := range tests {
+		deleted, err := b.DeletePrefix(ctx, prefix+"logs/", tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if deleted != tt.want {
+			t.Errorf("%s: deleted %d objects, want %d", tt.name, deleted, tt.want)
+		}
+		for _, key := range tt.gone {
+			if exists, err := b.Exists(ctx, prefix+key); err != nil || exists {
+				t.Errorf("%s: %s exists %v, error %v, want deleted", tt.name, key, exists, err)
+			}
+		}
+		for _, key := range tt.stays {
+			if exists, err := b.Exists(ctx, prefix+key); err != nil || !exists {
+				t.Errorf("%s: %s exists %v, error %v, want kept", tt.name, key, exists, err)
+			}
+		}
+	}
+}
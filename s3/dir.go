@@ -0,0 +1,180 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// defaultFileConcurrency is the number of files transferred at once by directory operations.
+// Each file is transferred in up to WithConcurrency parts itself.
+const defaultFileConcurrency = 4
+
+// UploadDirOptions configures UploadDir.
+type UploadDirOptions struct {
+	// Include uploads only files matching one of the patterns, if given, and Exclude skips files
+	// matching one. Patterns use path.Match syntax; patterns containing a / are matched against
+	// the slash-separated path relative to the directory, others against the file name, so
+	// "*.tmp" excludes temporary files anywhere and "cache/*" excludes only the top-level cache.
+	Include []string
+	Exclude []string
+	// Concurrency is the number of files uploaded at once, 4 by default.
+	Concurrency int
+	// Options are applied to every upload.
+	Options []UploadOption
+}
+
+// UploadDir uploads the regular files in dir and its subdirectories, keyed by prefix followed by
+// their slash-separated path relative to dir, e.g. prefix "site/" uploads dir/css/app.css as
+// site/css/app.css. It returns the number of files uploaded and stops at the first error.
+func UploadDir(ctx context.Context, prefix, dir string, opts UploadDirOptions) (int, error) {
+	return defaultBucket().UploadDir(ctx, prefix, dir, opts)
+}
+
+// UploadDir uploads the regular files in dir under prefix, see the package-level UploadDir.
+func (b *Bucket) UploadDir(ctx context.Context, prefix, dir string, opts UploadDirOptions) (int, error) {
+	files, err := walkFiles(dir, opts.Include, opts.Exclude)
+	if err != nil {
+		return 0, err
+	}
+
+	err = forEachParallel(ctx, files, opts.Concurrency, func(ctx context.Context, rel string) error {
+		return b.UploadFile(ctx, prefix+rel, filepath.Join(dir, filepath.FromSlash(rel)), opts.Options...)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(files), nil
+}
+
+// DownloadPrefixOptions configures DownloadPrefix.
+type DownloadPrefixOptions struct {
+	// Concurrency is the number of objects downloaded at once, 4 by default.
+	Concurrency int
+	// Options are applied to every download.
+	Options []DownloadOption
+}
+
+// DownloadPrefix downloads the objects whose key starts with prefix into dir, at the path of their
+// key after prefix, creating directories as needed, e.g. prefix "backup/" downloads backup/db/1.sql
+// to dir/db/1.sql. Existing files are overwritten. Keys ending in / and keys that would leave dir,
+// such as ones containing "..", are skipped. It returns the number of objects downloaded and stops
+// at the first error.
+func DownloadPrefix(ctx context.Context, prefix, dir string, opts DownloadPrefixOptions) (int, error) {
+	return defaultBucket().DownloadPrefix(ctx, prefix, dir, opts)
+}
+
+// DownloadPrefix downloads the objects under prefix into dir, see the package-level DownloadPrefix.
+func (b *Bucket) DownloadPrefix(ctx context.Context, prefix, dir string, opts DownloadPrefixOptions) (int, error) {
+	var keys []string
+	for obj, err := range b.List(ctx, prefix) {
+		if err != nil {
+			return 0, err
+		}
+		rel := strings.TrimPrefix(obj.Key, prefix)
+		if rel != "" && !strings.HasSuffix(rel, "/") && filepath.IsLocal(filepath.FromSlash(rel)) {
+			keys = append(keys, obj.Key)
+		}
+	}
+
+	err := forEachParallel(ctx, keys, opts.Concurrency, func(ctx context.Context, key string) error {
+		name := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(key, prefix)))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		_, err := b.DownloadToFile(ctx, key, name, opts.Options...)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
+
+// walkFiles returns the slash-separated paths relative to dir of the regular files in dir and
+// its subdirectories that pass the include and exclude patterns.
+func walkFiles(dir string, include, exclude []string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); includeFile(rel, include, exclude) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list directory: %w", err)
+	}
+	return files, nil
+}
+
+// includeFile reports whether the slash-separated relative path rel passes the include and
+// exclude patterns, see UploadDirOptions.
+func includeFile(rel string, include, exclude []string) bool {
+	matches := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			name := path.Base(rel)
+			if strings.Contains(pattern, "/") {
+				name = rel
+			}
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return (len(include) == 0 || matches(include)) && !matches(exclude)
+}
+
+// forEachParallel calls fn for every item, concurrency at a time or defaultFileConcurrency if it
+// is not positive. The first error cancels the ctx of running calls, stops starting new ones and
+// is returned.
+func forEachParallel[T any](ctx context.Context, items []T, concurrency int, fn func(ctx context.Context, item T) error) error {
+	if concurrency <= 0 {
+		concurrency = defaultFileConcurrency
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	work := make(chan T)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				if err := fn(ctx, item); err != nil {
+					cancel(err)
+				}
+			}
+		}()
+	}
+
+	for _, item := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case work <- item:
+		case <-ctx.Done():
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	return context.Cause(ctx)
+}
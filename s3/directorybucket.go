@@ -0,0 +1,53 @@
+package s3
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// directoryBucketSuffix ends the names of directory buckets, after their zone ID.
+const directoryBucketSuffix = "--x-s3"
+
+// WithDirectoryBucket opens the bucket as a directory bucket of S3 Express One Zone in the
+// Availability Zone zoneID, such as use1-az4, creating it there if missing, for workloads that need
+// single-digit millisecond latency, e.g. training data or intermediate files of analytics jobs read
+// from compute in the same zone. The name gets the suffix --<zoneID>--x-s3 that directory buckets
+// require, unless it has it already, so APP_NAME myapp opens myapp--use1-az4--x-s3. The zone has to
+// be in the region, see WithRegion.
+//
+// Requests are authorized with the short-lived sessions directory buckets require, which the AWS SDK
+// creates and renews as needed, so the credentials need s3express:CreateSession on the bucket.
+// Directory buckets support neither WithObjectLock, WithPublicPrefixes, WithSecureDefaults,
+// WithNotifications nor WithLifecycleRules, nor the operations built on lifecycle rules such as
+// UploadTemp. Their ETags are no MD5 of the content, so downloads are verified with checksums only,
+// which uploads add by default, and Sync compares files by modification time. They list objects in
+// no particular order.
+func WithDirectoryBucket(zoneID string) Option {
+	return func(c *config) {
+		c.directoryZone = zoneID
+	}
+}
+
+// directoryBucketName returns name with the suffix of a directory bucket in zoneID, unless it has one.
+func directoryBucketName(name, zoneID string) string {
+	if strings.HasSuffix(name, directoryBucketSuffix) {
+		return name
+	}
+	return name + "--" + zoneID + directoryBucketSuffix
+}
+
+// directoryBucketConfiguration returns the configuration that creates a directory bucket in zoneID.
+func directoryBucketConfiguration(zoneID string) *types.CreateBucketConfiguration {
+	return &types.CreateBucketConfiguration{
+		Location: &types.LocationInfo{
+			Type: types.LocationTypeAvailabilityZone,
+			Name: aws.String(zoneID),
+		},
+		Bucket: &types.BucketInfo{
+			Type:           types.BucketTypeDirectory,
+			DataRedundancy: types.DataRedundancySingleAvailabilityZone,
+		},
+	}
+}
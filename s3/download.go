@@ -0,0 +1,330 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+	// Metadata is the user metadata stored with the object, keyed by lowercase name.
+	// Listings do not include it.
+	Metadata map[string]string
+}
+
+// Object is the content of a downloaded object together with its metadata.
+// The caller must close it.
+type Object struct {
+	io.ReadCloser
+	ObjectInfo
+}
+
+// DownloadOption configures Download and DownloadToFile.
+type DownloadOption func(*downloadOptions)
+
+type downloadOptions struct {
+	progress          func(bytesReceived, total int64)
+	retryOnMismatch   bool
+	encryptionContext map[string]string
+}
+
+// WithDownloadProgress calls fn as the content is received, with the bytes received so far and
+// the size of the object, so long downloads can show progress. For Download, bytes count as received
+// when they are read from the Object. For DownloadToFile, fn is called from the goroutines downloading
+// parts, one at a time. fn should return quickly.
+func WithDownloadProgress(fn func(bytesReceived, total int64)) DownloadOption {
+	return func(o *downloadOptions) {
+		o.progress = fn
+	}
+}
+
+// WithRetryOnMismatch makes DownloadToFile download the object once more when its content does not
+// match its checksum, e.g. after a flaky connection, and only report a second mismatch as a
+// *ChecksumError. Progress starts over for the second download. Download reports the first mismatch,
+// as the caller has read the content by then.
+func WithRetryOnMismatch() DownloadOption {
+	return func(o *downloadOptions) {
+		o.retryOnMismatch = true
+	}
+}
+
+// Download returns the content of the object stored under key. The content is streamed,
+// so large objects are never held in memory. A missing object is reported as a *NotFoundError.
+// Objects with a checksum or an MD5 ETag are verified while they are read, see WithChecksum, and
+// gzip-encoded objects are decompressed, see WithGzip. Size is the size as stored.
+func Download(ctx context.Context, key string, opts ...DownloadOption) (*Object, error) {
+	return defaultBucket().Download(ctx, key, opts...)
+}
+
+// Download returns the content of the object stored under key, see the package-level Download.
+func (b *Bucket) Download(ctx context.Context, key string, opts ...DownloadOption) (*Object, error) {
+	obj, err := b.download(ctx, "Download", &s3.GetObjectInput{Key: aws.String(key)}, opts)
+	if err == nil || b.failover == nil || !shouldFailOver(ctx, err) {
+		return obj, err
+	}
+	// The error of the bucket is more telling, e.g. when the failover bucket is not replicated.
+	if obj, failoverErr := b.failover.Download(ctx, key, opts...); failoverErr == nil {
+		return obj, nil
+	}
+	return nil, err
+}
+
+// ErrNotModified is returned by DownloadIfModified when the object has not changed.
+var ErrNotModified = errors.New("object not modified")
+
+// DownloadIfModified returns the content of the object stored under key like Download, unless it
+// still has the ETag etag and, if lastModified is not zero, was not modified after it, in which case
+// it returns ErrNotModified without transferring the content. Either condition may be left empty;
+// S3 ignores lastModified for an object whose ETag differs. Passing the ETag and LastModified of the
+// previous download refreshes a local copy cheaply:
+//
+//	obj, err := s3.DownloadIfModified(ctx, "config.json", cached.ETag, cached.LastModified)
+//	if errors.Is(err, s3.ErrNotModified) {
+//		return cached, nil
+//	}
+func DownloadIfModified(ctx context.Context, key, etag string, lastModified time.Time, opts ...DownloadOption) (*Object, error) {
+	return defaultBucket().DownloadIfModified(ctx, key, etag, lastModified, opts...)
+}
+
+// DownloadIfModified returns the content of the object stored under key unless it has not changed,
+// see the package-level DownloadIfModified.
+func (b *Bucket) DownloadIfModified(ctx context.Context, key, etag string, lastModified time.Time, opts ...DownloadOption) (*Object, error) {
+	input := &s3.GetObjectInput{Key: aws.String(key)}
+	if etag != "" {
+		input.IfNoneMatch = aws.String(etag)
+	}
+	if !lastModified.IsZero() {
+		input.IfModifiedSince = aws.Time(lastModified)
+	}
+	return b.download(ctx, "DownloadIfModified", input, opts)
+}
+
+// download gets the object input selects, whose Bucket and ChecksumMode are set here.
+func (b *Bucket) download(ctx context.Context, opName string, input *s3.GetObjectInput, opts []DownloadOption) (_ *Object, err error) {
+	key := aws.ToString(input.Key)
+	// The span ends once the response starts, reading the content is up to the caller.
+	ctx, op := b.startOperation(ctx, opName, attribute.String("aws.s3.key", key))
+	defer func() { op.end(err) }()
+	// The timeout covers reading the content too, so it is released once the Object is closed.
+	ctx, cancel := withTimeout(ctx, b.settings.timeouts.Download)
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	var o downloadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	// Unconditional downloads are served from the cache while the object is unchanged.
+	cacheable := b.cache != nil && input.IfNoneMatch == nil && input.IfModifiedSince == nil
+	var cached *ObjectInfo
+	if cacheable {
+		if cached = b.cache.lookup(b.name, key); cached != nil {
+			input.IfNoneMatch = aws.String(cached.ETag)
+		}
+	}
+
+	input.Bucket = aws.String(b.name)
+	input.ChecksumMode = types.ChecksumModeEnabled
+	out, err := b.client.GetObject(ctx, input, withoutChecksumValidation)
+	if isNotModified(err) && cached != nil {
+		if file, err := b.cache.open(*cached); err == nil {
+			op.span.SetAttributes(attribute.Bool("aws.s3.cached", true))
+			return cachedObject(&cancelOnClose{ReadCloser: file, cancel: cancel}, *cached, o)
+		}
+		// The content was evicted since it was looked up.
+		input.IfNoneMatch = nil
+		out, err = b.client.GetObject(ctx, input, withoutChecksumValidation)
+	}
+	if isNotModified(err) {
+		return nil, ErrNotModified
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download object: %w", notFound(err, key))
+	}
+	if err := checkEncryptionContext(key, out.Metadata, o.encryptionContext); err != nil {
+		out.Body.Close()
+		return nil, err
+	}
+
+	var body io.ReadCloser = &cancelOnClose{ReadCloser: out.Body, cancel: cancel}
+	sum, err := b.checksumOf(ctx, key, out.VersionId, aws.ToInt64(out.ContentLength),
+		storedChecksums{out.ChecksumCRC32, out.ChecksumCRC32C, out.ChecksumSHA1, out.ChecksumSHA256})
+	if err != nil {
+		out.Body.Close()
+		return nil, fmt.Errorf("failed to download object: %w", err)
+	}
+	if sum == nil && b.settings.directoryZone == "" {
+		sum = etagChecksum(aws.ToString(out.ETag), aws.ToInt64(out.ContentLength), out.ServerSideEncryption, out.SSECustomerAlgorithm)
+	}
+	if sum != nil {
+		body = newVerifyingReader(body, key, sum)
+	}
+	if o.progress != nil {
+		body = struct {
+			io.Reader
+			io.Closer
+		}{&progressReader{Reader: body, fn: o.progress, total: aws.ToInt64(out.ContentLength)}, body}
+	}
+	if aws.ToString(out.ContentEncoding) == "gzip" {
+		if body, err = newDecompressingReader(body); err != nil {
+			out.Body.Close()
+			return nil, fmt.Errorf("failed to decompress object: %w", err)
+		}
+	}
+
+	op.setBytes(aws.ToInt64(out.ContentLength))
+	op.span.SetAttributes(attribute.Int("aws.s3.parts", 1))
+	obj := &Object{
+		ReadCloser: body,
+		ObjectInfo: ObjectInfo{
+			Key:          key,
+			Size:         aws.ToInt64(out.ContentLength),
+			ContentType:  aws.ToString(out.ContentType),
+			ETag:         aws.ToString(out.ETag),
+			LastModified: aws.ToTime(out.LastModified),
+			Metadata:     out.Metadata,
+		},
+	}
+	// Decompressed content does not match the size of the object, see WithCache.
+	if cacheable && aws.ToString(out.ContentEncoding) != "gzip" {
+		obj = b.cache.store(b.name, obj)
+	}
+	return obj, nil
+}
+
+// isNotModified reports whether err is the response to a conditional request whose object has not changed.
+func isNotModified(err error) bool {
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotModified
+}
+
+// DownloadToFile downloads the object stored under key to the file at path, fetching large objects
+// in parallel byte ranges like Upload sends them. The file is written next to path and renamed
+// into place once complete, so path never holds a partial download or, for objects with a checksum,
+// a corrupted one, see WithRetryOnMismatch. A missing object is reported as a *NotFoundError. It
+// returns the number of bytes written.
+func DownloadToFile(ctx context.Context, key, path string, opts ...DownloadOption) (int64, error) {
+	return defaultBucket().DownloadToFile(ctx, key, path, opts...)
+}
+
+// DownloadToFile downloads the object stored under key to the file at path, see the package-level DownloadToFile.
+func (b *Bucket) DownloadToFile(ctx context.Context, key, path string, opts ...DownloadOption) (_ int64, err error) {
+	ctx, op := b.startOperation(ctx, "DownloadToFile", attribute.String("aws.s3.key", key))
+	defer func() { op.end(err) }()
+	ctx, cancel := withTimeout(ctx, b.settings.timeouts.Download)
+	defer cancel()
+
+	var o downloadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := b.checkOpen(); err != nil {
+		return 0, err
+	}
+
+	// Ranged requests return no checksums, so they are taken from the object, whose version
+	// and ETag then pin the download to that same content.
+	head, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(b.name),
+		Key:          aws.String(key),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to download object: %w", notFound(err, key))
+	}
+	if err := checkEncryptionContext(key, head.Metadata, o.encryptionContext); err != nil {
+		return 0, err
+	}
+	sum, err := b.checksumOf(ctx, key, head.VersionId, aws.ToInt64(head.ContentLength),
+		storedChecksums{head.ChecksumCRC32, head.ChecksumCRC32C, head.ChecksumSHA1, head.ChecksumSHA256})
+	if err != nil {
+		return 0, fmt.Errorf("failed to download object: %w", err)
+	}
+	if sum == nil && b.settings.directoryZone == "" {
+		sum = etagChecksum(aws.ToString(head.ETag), aws.ToInt64(head.ContentLength), head.ServerSideEncryption, head.SSECustomerAlgorithm)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	n, err := b.downloadTo(ctx, file, key, head, sum, o.progress)
+	var checksumErr *ChecksumError
+	if o.retryOnMismatch && errors.As(err, &checksumErr) {
+		op.span.AddEvent("checksum mismatch, retrying")
+		n, err = b.downloadTo(ctx, file, key, head, sum, o.progress)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return 0, fmt.Errorf("failed to move file into place: %w", err)
+	}
+
+	parts := max((n+b.settings.partSize-1)/b.settings.partSize, 1)
+	op.setBytes(n)
+	op.span.SetAttributes(attribute.Int64("aws.s3.parts", parts))
+	return n, nil
+}
+
+// downloadTo downloads the object stored under key, as head describes it, into file from its start
+// and verifies the file against sum, if not nil.
+func (b *Bucket) downloadTo(ctx context.Context, file *os.File, key string, head *s3.HeadObjectOutput,
+	sum *objectChecksum, progress func(bytesReceived, total int64)) (int64, error) {
+	var w io.WriterAt = file
+	if progress != nil {
+		w = &progressWriterAt{WriterAt: file, fn: progress, total: aws.ToInt64(head.ContentLength)}
+	}
+	n, err := b.downloader.Download(ctx, w, &s3.GetObjectInput{
+		Bucket:    aws.String(b.name),
+		Key:       aws.String(key),
+		VersionId: head.VersionId,
+		IfMatch:   head.ETag,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to download object: %w", notFound(err, key))
+	}
+
+	if sum != nil {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return 0, fmt.Errorf("failed to verify file: %w", err)
+		}
+		if _, err := io.Copy(io.Discard, newVerifyingReader(file, key, sum)); err != nil {
+			return 0, fmt.Errorf("failed to verify file: %w", err)
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,117 @@
+package s3
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// encryptionContextMetadata is the metadata in which WithEncryptionContext records the context on
+// the object, as S3 does not return it.
+const encryptionContextMetadata = "encryption-context"
+
+// EncryptionContextError reports that a downloaded object was not encrypted with the encryption
+// context expected by WithExpectedEncryptionContext, e.g. it belongs to another tenant.
+type EncryptionContextError struct {
+	Key      string
+	Expected map[string]string
+	// Actual is the context the object was uploaded with, nil if it has none.
+	Actual map[string]string
+}
+
+func (e *EncryptionContextError) Error() string {
+	return fmt.Sprintf("encryption context mismatch for %s: got %v, expected %v", e.Key, e.Actual, e.Expected)
+}
+
+// WithEncryptionContext encrypts the uploaded object with the KMS encryption context
+// encryptionContext, e.g. the ID of the tenant it belongs to, which KMS key policies can require
+// through the condition kms:EncryptionContext:<name> so each tenant's role only decrypts its own
+// objects:
+//
+//	err := s3.Upload(ctx, key, reader, s3.WithEncryptionContext(map[string]string{"tenant": tenantID}))
+//
+// S3 adds the ARN of the bucket to the context itself. It requires WithSSEKMS, and is recorded in the
+// metadata "encryption-context" of the object for WithExpectedEncryptionContext. Copies made by this
+// package, e.g. by Copy, Move or UploadAtomic, are encrypted with the recorded context as well, or
+// lose the record if the bucket is not configured with WithSSEKMS.
+func WithEncryptionContext(encryptionContext map[string]string) UploadOption {
+	return func(o *uploadOptions) {
+		o.encryptionContext = maps.Clone(encryptionContext)
+	}
+}
+
+// WithExpectedEncryptionContext makes Download and DownloadToFile fail with an
+// *EncryptionContextError, before any content is returned or written, unless the object was uploaded
+// with WithEncryptionContext and a context holding every pair of encryptionContext. It guards against
+// handing one tenant's object to another when a role may decrypt both.
+//
+// The check is advisory: S3 does not return the context an object was encrypted with, so it is made
+// against the record in the metadata of the object, which anyone allowed to upload it can write.
+// Isolating tenants from each other is up to KMS key policies requiring the context.
+func WithExpectedEncryptionContext(encryptionContext map[string]string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.encryptionContext = maps.Clone(encryptionContext)
+	}
+}
+
+// applyEncryptionContext sets the encryption context of input, which has to be encrypted with SSE-KMS.
+func applyEncryptionContext(input *s3.PutObjectInput, encryptionContext map[string]string) error {
+	if input.ServerSideEncryption != types.ServerSideEncryptionAwsKms && input.ServerSideEncryption != types.ServerSideEncryptionAwsKmsDsse {
+		return errors.New("an encryption context requires SSE-KMS, see WithSSEKMS")
+	}
+	encoded, err := json.Marshal(encryptionContext)
+	if err != nil {
+		return fmt.Errorf("failed to encode encryption context: %w", err)
+	}
+	value := base64.StdEncoding.EncodeToString(encoded)
+	input.SSEKMSEncryptionContext = aws.String(value)
+	if input.Metadata == nil {
+		input.Metadata = map[string]string{}
+	}
+	input.Metadata[encryptionContextMetadata] = value
+	return nil
+}
+
+// checkEncryptionContext returns an *EncryptionContextError unless the context recorded in the
+// metadata of the object stored under key holds every pair of expected.
+func checkEncryptionContext(key string, metadata, expected map[string]string) error {
+	if expected == nil {
+		return nil
+	}
+	var actual map[string]string
+	if value, ok := metadata[encryptionContextMetadata]; ok {
+		// A context that cannot be decoded matches nothing.
+		if decoded, err := base64.StdEncoding.DecodeString(value); err == nil {
+			json.Unmarshal(decoded, &actual)
+		}
+	}
+	for name, value := range expected {
+		if got, ok := actual[name]; !ok || got != value {
+			return &EncryptionContextError{Key: key, Expected: expected, Actual: actual}
+		}
+	}
+	return nil
+}
+
+// copiedEncryptionContext returns the encryption context to encrypt the copy of an object with
+// metadata with, and the metadata of the copy. The context recorded in metadata is kept if the copy
+// is encrypted with SSE-KMS in sse; otherwise the record is removed, as it would not be true of
+// the copy.
+func copiedEncryptionContext(metadata map[string]string, sse types.ServerSideEncryption) (*string, map[string]string) {
+	value, ok := metadata[encryptionContextMetadata]
+	if !ok {
+		return nil, metadata
+	}
+	if sse == types.ServerSideEncryptionAwsKms || sse == types.ServerSideEncryptionAwsKmsDsse {
+		return aws.String(value), metadata
+	}
+	metadata = maps.Clone(metadata)
+	delete(metadata, encryptionContextMetadata)
+	return nil, metadata
+}
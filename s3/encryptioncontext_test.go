@@ -0,0 +1,73 @@
+package s3
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestEncryptionContext(t *testing.T) {
+	input := &s3.PutObjectInput{ServerSideEncryption: types.ServerSideEncryptionAwsKms}
+	if err := applyEncryptionContext(input, map[string]string{"tenant": "a", "kind": "export"}); err != nil {
+		t.Fatal(err)
+	}
+	if aws.ToString(input.SSEKMSEncryptionContext) != input.Metadata[encryptionContextMetadata] {
+		t.Errorf("recorded context %q, sent %q", input.Metadata[encryptionContextMetadata], aws.ToString(input.SSEKMSEncryptionContext))
+	}
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		expected map[string]string
+		ok       bool
+	}{
+		{"nothing expected", nil, nil, true},
+		{"subset", input.Metadata, map[string]string{"tenant": "a"}, true},
+		{"all pairs", input.Metadata, map[string]string{"tenant": "a", "kind": "export"}, true},
+		{"different value", input.Metadata, map[string]string{"tenant": "b"}, false},
+		{"missing pair", input.Metadata, map[string]string{"owner": "a"}, false},
+		{"no context", map[string]string{}, map[string]string{"tenant": "a"}, false},
+		{"invalid context", map[string]string{encryptionContextMetadata: "%%%"}, map[string]string{"tenant": "a"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkEncryptionContext("key", tt.metadata, tt.expected)
+			var contextErr *EncryptionContextError
+			if tt.ok && err != nil || !tt.ok && !errors.As(err, &contextErr) {
+				t.Errorf("got %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestApplyEncryptionContextRequiresKMS(t *testing.T) {
+	input := &s3.PutObjectInput{ServerSideEncryption: types.ServerSideEncryptionAes256}
+	if err := applyEncryptionContext(input, map[string]string{"tenant": "a"}); err == nil {
+		t.Error("expected an error without SSE-KMS")
+	}
+}
+
+func TestCopiedEncryptionContext(t *testing.T) {
+	metadata := map[string]string{encryptionContextMetadata: "e30=", "owner": "a"}
+
+	context, copied := copiedEncryptionContext(metadata, types.ServerSideEncryptionAwsKms)
+	if aws.ToString(context) != "e30=" || len(copied) != 2 {
+		t.Errorf("SSE-KMS copy: got context %v and metadata %v", aws.ToString(context), copied)
+	}
+
+	context, copied = copiedEncryptionContext(metadata, types.ServerSideEncryptionAes256)
+	if context != nil || len(copied) != 1 || copied["owner"] != "a" {
+		t.Errorf("SSE-S3 copy: got context %v and metadata %v", aws.ToString(context), copied)
+	}
+	if len(metadata) != 2 {
+		t.Errorf("metadata of the source was changed to %v", metadata)
+	}
+
+	context, copied = copiedEncryptionContext(map[string]string{"owner": "a"}, types.ServerSideEncryptionAwsKms)
+	if context != nil || len(copied) != 1 {
+		t.Errorf("copy without context: got context %v and metadata %v", aws.ToString(context), copied)
+	}
+}
@@ -0,0 +1,106 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// WithFailover sets a secondary bucket, typically a replica in another region, that Upload and
+// Download fall back to when the bucket fails, for artifacts that have to stay available through a
+// regional outage. It is opened like the bucket with opts applied on top, e.g.
+// WithRegion("eu-west-1") or WithEndpoint for another service, and created if missing:
+//
+//	s3.Init(s3.WithFailover("myapp-eu", s3.WithRegion("eu-west-1")))
+//
+// Upload falls back only for readers that are io.Seekers, such as files, as the content is read again;
+// Download also for objects missing in the bucket, which may have been uploaded during an outage.
+// Conditional uploads that fail their condition and operations whose ctx is done do not fall back.
+// Other operations, apart from those built on Upload and Download such as UploadFile, use only the
+// bucket. Replicating the objects uploaded before an outage, e.g. with S3 Replication, is up to the
+// deployment.
+//
+// Opening succeeds as long as one of the two buckets can be created and set up, so a process starts
+// during an outage; the failure to set up the other is reported to the operation hook as EnsureBucket
+// and retried when the bucket is opened again.
+func WithFailover(bucket string, opts ...Option) Option {
+	return func(c *config) {
+		c.failoverBucket = bucket
+		c.failoverOptions = opts
+	}
+}
+
+// WithDualWrite makes Upload store every object in the secondary bucket set with WithFailover as
+// well, after the bucket, so it does not depend on replication. The upload succeeds if one of them
+// stores the object; the failure of the other is reported to the operation hook. Readers that are not
+// io.Seekers are staged in a temporary file to be read twice.
+func WithDualWrite() Option {
+	return func(c *config) {
+		c.dualWrite = true
+	}
+}
+
+// withoutFailover clears the failover settings, so the secondary bucket has none of its own.
+func withoutFailover() Option {
+	return func(c *config) {
+		c.failoverBucket = ""
+		c.failoverOptions = nil
+		c.dualWrite = false
+	}
+}
+
+// uploadWithFailover uploads like upload to the bucket and, if it fails or with WithDualWrite, to
+// the secondary bucket.
+func (b *Bucket) uploadWithFailover(ctx context.Context, key string, reader io.Reader, opts []UploadOption) error {
+	return b.writeWithFailover(ctx, reader,
+		func(reader io.Reader) error { return b.upload(ctx, key, reader, opts...) },
+		func(reader io.Reader) error { return b.failover.Upload(ctx, key, reader, opts...) })
+}
+
+// writeWithFailover stores the content of reader with write, which stores it in the bucket only,
+// and, if that fails or with WithDualWrite, with writeFailover, which stores it in the secondary
+// bucket. Each runs all its requests against its own bucket.
+func (b *Bucket) writeWithFailover(ctx context.Context, reader io.Reader, write, writeFailover func(reader io.Reader) error) error {
+	seeker, seekable := reader.(io.ReadSeeker)
+	if b.settings.dualWrite && !seekable {
+		spooled, _, err := spool(reader)
+		if err != nil {
+			return fmt.Errorf("failed to upload object: %w", err)
+		}
+		defer spooled.Close()
+		reader, seeker, seekable = spooled, spooled, true
+	}
+	var start int64
+	if seekable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			seekable = false
+		}
+	}
+
+	err := write(reader)
+	if err != nil && !shouldFailOver(ctx, err) || err == nil && !b.settings.dualWrite {
+		return err
+	}
+	if !seekable {
+		return err
+	}
+	if _, seekErr := seeker.Seek(start, io.SeekStart); seekErr != nil {
+		return err
+	}
+
+	failoverErr := writeFailover(seeker)
+	if err == nil || failoverErr == nil {
+		return nil
+	}
+	return fmt.Errorf("%w; failover to %s: %w", err, b.failover.name, failoverErr)
+}
+
+// shouldFailOver reports whether an operation that failed with err may succeed on the secondary bucket.
+func shouldFailOver(ctx context.Context, err error) bool {
+	var preconditionFailed *PreconditionFailedError
+	var duplicate *DuplicateUploadError
+	return ctx.Err() == nil && !errors.Is(err, ErrNotModified) &&
+		!errors.As(err, &preconditionFailed) && !errors.As(err, &duplicate)
+}
@@ -0,0 +1,53 @@
+package s3
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// WithGzip compresses the content while uploading it and stores the object with Content-Encoding
+// gzip. Download decompresses such objects transparently, and so do browsers; Stat, List and
+// DownloadToFile see the object as stored, compressed. The content is never buffered whole.
+func WithGzip() UploadOption {
+	return func(o *uploadOptions) {
+		o.gzip = true
+	}
+}
+
+// compressingReader reads the gzip-compressed content of a reader, compressed in a goroutine.
+// Closing it stops the goroutine if the upload ends before reading everything.
+type compressingReader struct {
+	*io.PipeReader
+}
+
+func newCompressingReader(r io.Reader) compressingReader {
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		_, err := io.Copy(zw, r)
+		if err == nil {
+			err = zw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return compressingReader{pr}
+}
+
+// decompressingReader reads the decompressed content of a gzip-encoded body and closes the body.
+type decompressingReader struct {
+	*gzip.Reader
+	body io.Closer
+}
+
+func newDecompressingReader(body io.ReadCloser) (*decompressingReader, error) {
+	zr, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, err
+	}
+	return &decompressingReader{Reader: zr, body: body}, nil
+}
+
+func (r *decompressingReader) Close() error {
+	r.Reader.Close()
+	return r.body.Close()
+}
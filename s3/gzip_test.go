@@ -0,0 +1,39 @@
+package s3
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestGzipRoundtrip(t *testing.T) {
+	data := strings.Repeat("compressible content ", 10000)
+
+	compressed, err := io.ReadAll(newCompressingReader(strings.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compressed) >= len(data) {
+		t.Errorf("compressed %d bytes to %d", len(data), len(compressed))
+	}
+
+	r, err := newDecompressingReader(io.NopCloser(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != data {
+		t.Errorf("got %d bytes back, want %d", len(got), len(data))
+	}
+}
+
+func TestDecompressingReaderInvalid(t *testing.T) {
+	if _, err := newDecompressingReader(io.NopCloser(strings.NewReader("plain text"))); err == nil {
+		t.Error("expected an error for content that is not gzip")
+	}
+}
@@ -0,0 +1,92 @@
+package s3
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OperationEvent describes a finished operation on a bucket.
+type OperationEvent struct {
+	// Op is the name of the method, e.g. "Upload".
+	Op     string
+	Bucket string
+	// Key is the key of the object, the destination for Copy, or the prefix for List and ListPage.
+	// It is empty for DeleteMany.
+	Key string
+	// Duration is the time until the operation returned. For Download it excludes reading the
+	// content, for List and the selects it includes the time the caller spent per record.
+	Duration time.Duration
+	// Bytes is the size of the content transferred by Upload, Download and DownloadToFile, or
+	// of the records returned by a select that was read to the end.
+	Bytes int64
+	Err   error
+}
+
+// WithOperationHook calls hook after every Upload, UploadAtomic, PutCAS, Download,
+// DownloadIfModified, DownloadToFile, Stat, List, ListPage, Copy, Move, Compose, Delete, DeleteMany,
+// DeletePrefix, SetTags, GetTags, SelectJSON, SelectCSV, PresignGet, PresignPut and PresignPost, and
+// after setting up a bucket when it is opened, as EnsureBucket, e.g. to log them with slog. These
+// also report each call they make to the others, as do operations built on them such as UploadFile
+// or Sync. hook is called on the goroutine of the operation and should return quickly.
+//
+//	s3.WithOperationHook(func(ctx context.Context, e s3.OperationEvent) {
+//		slog.InfoContext(ctx, "s3", "op", e.Op, "key", e.Key, "duration", e.Duration, "bytes", e.Bytes, "err", e.Err)
+//	})
+func WithOperationHook(hook func(ctx context.Context, event OperationEvent)) Option {
+	return func(c *config) {
+		c.operationHook = hook
+	}
+}
+
+// operation is a running operation, traced as a span and reported to the operation hook when it ends.
+type operation struct {
+	ctx    context.Context
+	bucket *Bucket
+	event  OperationEvent
+	start  time.Time
+	span   trace.Span
+}
+
+// startOperation starts the operation op with the span attributes attrs, whose "aws.s3.key" or
+// "aws.s3.prefix" is the Key of its event.
+func (b *Bucket) startOperation(ctx context.Context, op string, attrs ...attribute.KeyValue) (context.Context, *operation) {
+	ctx, span := b.startSpan(ctx, op, attrs...)
+	o := &operation{
+		ctx:    ctx,
+		bucket: b,
+		event:  OperationEvent{Op: op, Bucket: b.name},
+		start:  time.Now(),
+		span:   span,
+	}
+	for _, attr := range attrs {
+		if attr.Key == "aws.s3.key" || attr.Key == "aws.s3.prefix" {
+			o.event.Key = attr.Value.AsString()
+		}
+	}
+	return ctx, o
+}
+
+// setKey records the key of an operation that is only known once it has started.
+func (o *operation) setKey(key string) {
+	o.event.Key = key
+	o.span.SetAttributes(attribute.String("aws.s3.key", key))
+}
+
+// setBytes records the size of the content transferred.
+func (o *operation) setBytes(n int64) {
+	o.event.Bytes = n
+	o.span.SetAttributes(attribute.Int64("aws.s3.bytes", n))
+}
+
+// end ends the operation with its result err.
+func (o *operation) end(err error) {
+	endSpan(o.span, err)
+	if hook := o.bucket.settings.operationHook; hook != nil {
+		o.event.Duration = time.Since(o.start)
+		o.event.Err = err
+		hook(o.ctx, o.event)
+	}
+}
@@ -0,0 +1,42 @@
+package s3_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/michaldziurowski/one/s3"
+)
+
+func TestOperationHook(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var events []s3.OperationEvent
+	b, prefix := openTestBucket(t, s3.WithOperationHook(func(ctx context.Context, event s3.OperationEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}))
+	// Opening the bucket is reported too.
+	mu.Lock()
+	events = nil
+	mu.Unlock()
+
+	if err := b.Upload(ctx, prefix+"a", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	b.Stat(ctx, prefix+"missing")
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 {
+		t.Fatalf("got events %+v, want Upload and Stat", events)
+	}
+	if e := events[0]; e.Op != "Upload" || e.Key != prefix+"a" || e.Bytes != 5 || e.Err != nil {
+		t.Errorf("got %+v for the upload", e)
+	}
+	if e := events[1]; e.Op != "Stat" || e.Err == nil {
+		t.Errorf("got %+v for the Stat of a missing object", e)
+	}
+}
@@ -0,0 +1,120 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// idempotencyDays is how long UploadTemp keeps the markers of idempotency keys.
+	idempotencyDays = 7
+	// idempotencyKeyMetadata is the metadata in which WithIdempotencyKey records the key on the object.
+	idempotencyKeyMetadata = "idempotency-key"
+)
+
+// DuplicateUploadError is returned by an upload with an idempotency key that an earlier upload
+// already stored under Key, see WithIdempotencyKey.
+type DuplicateUploadError struct {
+	IdempotencyKey string
+	Key            string
+}
+
+func (e *DuplicateUploadError) Error() string {
+	return fmt.Sprintf("idempotency key %s was already uploaded as %s", e.IdempotencyKey, e.Key)
+}
+
+// WithIdempotencyKey makes repeated uploads with the same idempotency key store the object only
+// once, so a retried operation, e.g. a webhook delivered twice, does not create a second object
+// under a newly generated key:
+//
+//	key := "exports/" + uuid.NewString() + ".csv"
+//	err := s3.Upload(ctx, key, export, s3.WithIdempotencyKey(event.ID))
+//	var duplicate *s3.DuplicateUploadError
+//	if errors.As(err, &duplicate) {
+//		key, err = duplicate.Key, nil
+//	}
+//
+// The idempotency key is recorded in the metadata "idempotency-key" of the object and remembered for
+// at least 7 days in a marker under ttl/7d/idempotency/, see UploadTemp. An upload that failed or
+// whose object was deleted since does not count. Of uploads running at the same time, the first to
+// record the marker stores the object and the others fail with a *DuplicateUploadError, even if the
+// first fails later. It only has effect on a Bucket.
+func WithIdempotencyKey(idempotencyKey string) UploadOption {
+	return func(o *uploadOptions) {
+		o.idempotencyKey = idempotencyKey
+	}
+}
+
+// claimIdempotencyKey records that idempotencyKey is uploaded under key, unless an object uploaded
+// earlier with it still exists, which is returned as a *DuplicateUploadError.
+func (b *Bucket) claimIdempotencyKey(ctx context.Context, idempotencyKey, key string) error {
+	if err := b.ensureTTLRule(ctx, idempotencyDays); err != nil {
+		return err
+	}
+	marker := idempotencyMarker(idempotencyKey)
+
+	head, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(marker),
+	})
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to check idempotency key: %w", err)
+	}
+	if err == nil {
+		if earlier := head.Metadata["key"]; earlier != "" {
+			_, err := b.Stat(ctx, earlier)
+			var notFound *NotFoundError
+			if err == nil {
+				return &DuplicateUploadError{IdempotencyKey: idempotencyKey, Key: earlier}
+			}
+			if !errors.As(err, &notFound) {
+				return fmt.Errorf("failed to check idempotency key: %w", err)
+			}
+		}
+	}
+
+	// The marker is only written if no other upload wrote or replaced it since it was checked.
+	input := &s3.PutObjectInput{
+		Bucket:   aws.String(b.name),
+		Key:      aws.String(marker),
+		Metadata: map[string]string{"key": key},
+	}
+	if err == nil {
+		input.IfMatch = head.ETag
+	} else {
+		input.IfNoneMatch = aws.String("*")
+	}
+	_, err = b.client.PutObject(ctx, input)
+	var preconditionErr *PreconditionFailedError
+	if errors.As(preconditionFailed(err, marker), &preconditionErr) {
+		var concurrent string
+		if head, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(b.name), Key: aws.String(marker)}); err == nil {
+			concurrent = head.Metadata["key"]
+		}
+		return &DuplicateUploadError{IdempotencyKey: idempotencyKey, Key: concurrent}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record idempotency key: %w", err)
+	}
+	return nil
+}
+
+// releaseIdempotencyKey forgets idempotencyKey after its upload failed, so a retry uploads again.
+// It uses its own context, as the upload usually fails because ctx was cancelled.
+func (b *Bucket) releaseIdempotencyKey(idempotencyKey string) {
+	b.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(idempotencyMarker(idempotencyKey)),
+	})
+}
+
+// idempotencyMarker returns the key of the marker of idempotencyKey, which is hashed as it may be
+// longer than a key or contain any characters.
+func idempotencyMarker(idempotencyKey string) string {
+	return fmt.Sprintf("%s%dd/idempotency/%x", ttlPrefix, idempotencyDays, sha256.Sum256([]byte(idempotencyKey)))
+}
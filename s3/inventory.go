@@ -0,0 +1,152 @@
+package s3
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// InventoryFormat is the format of an inventory written by WriteInventory.
+type InventoryFormat string
+
+const (
+	// InventoryCSV writes a header line followed by one line per object.
+	InventoryCSV InventoryFormat = "csv"
+	// InventoryJSON writes one JSON object per line, as JSON Lines.
+	InventoryJSON InventoryFormat = "json"
+)
+
+// inventoryRecord is an object as written to an inventory.
+type inventoryRecord struct {
+	Key               string    `json:"key"`
+	Size              int64     `json:"size"`
+	LastModified      time.Time `json:"last_modified"`
+	StorageClass      string    `json:"storage_class"`
+	ETag              string    `json:"etag"`
+	ChecksumAlgorithm string    `json:"checksum_algorithm,omitempty"`
+}
+
+var inventoryColumns = []string{"key", "size", "last_modified", "storage_class", "etag", "checksum_algorithm"}
+
+// WriteInventory lists the objects whose key starts with prefix and writes their key, size,
+// modification time, storage class, ETag and checksum algorithm to w, e.g. to reconcile the bucket
+// against a database without setting up S3 Inventory:
+//
+//	file, err := os.Create("inventory.csv")
+//	// ...
+//	n, err := s3.WriteInventory(ctx, "", file, s3.InventoryCSV)
+//
+// The ETag stands in for the checksum, as listings do not include checksum values; it is the MD5 of
+// the content for objects uploaded in one part without SSE-KMS. The inventory is streamed as the
+// listing proceeds, so it takes no memory however many objects there are. It returns the number of
+// objects written.
+func WriteInventory(ctx context.Context, prefix string, w io.Writer, format InventoryFormat) (int, error) {
+	return defaultBucket().WriteInventory(ctx, prefix, w, format)
+}
+
+// WriteInventory writes an inventory of the objects under prefix to w, see the package-level WriteInventory.
+func (b *Bucket) WriteInventory(ctx context.Context, prefix string, w io.Writer, format InventoryFormat) (int, error) {
+	if err := b.checkOpen(); err != nil {
+		return 0, err
+	}
+
+	var write func(r inventoryRecord) error
+	var flush func() error
+	switch format {
+	case InventoryCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(inventoryColumns); err != nil {
+			return 0, fmt.Errorf("failed to write inventory: %w", err)
+		}
+		write = func(r inventoryRecord) error {
+			return cw.Write([]string{r.Key, strconv.FormatInt(r.Size, 10), r.LastModified.Format(time.RFC3339),
+				r.StorageClass, r.ETag, r.ChecksumAlgorithm})
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case InventoryJSON:
+		enc := json.NewEncoder(w)
+		write = func(r inventoryRecord) error { return enc.Encode(r) }
+		flush = func() error { return nil }
+	default:
+		return 0, fmt.Errorf("unknown inventory format %q, expected csv or json", format)
+	}
+
+	n := 0
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.name),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return n, fmt.Errorf("failed to list objects: %w", err)
+		}
+		for _, o := range page.Contents {
+			r := inventoryRecord{
+				Key:          aws.ToString(o.Key),
+				Size:         aws.ToInt64(o.Size),
+				LastModified: aws.ToTime(o.LastModified).UTC(),
+				StorageClass: string(o.StorageClass),
+				ETag:         strings.Trim(aws.ToString(o.ETag), `"`),
+			}
+			if r.StorageClass == "" {
+				r.StorageClass = "STANDARD"
+			}
+			if len(o.ChecksumAlgorithm) > 0 {
+				r.ChecksumAlgorithm = string(o.ChecksumAlgorithm[0])
+			}
+			if err := write(r); err != nil {
+				return n, fmt.Errorf("failed to write inventory: %w", err)
+			}
+			n++
+		}
+	}
+
+	if err := flush(); err != nil {
+		return n, fmt.Errorf("failed to write inventory: %w", err)
+	}
+	return n, nil
+}
+
+// UploadInventory writes an inventory of the objects whose key starts with prefix like
+// WriteInventory and uploads it under reportKey as it is written, e.g. from a nightly job. It returns
+// the number of objects in the inventory.
+func UploadInventory(ctx context.Context, prefix, reportKey string, format InventoryFormat) (int, error) {
+	return defaultBucket().UploadInventory(ctx, prefix, reportKey, format)
+}
+
+// UploadInventory uploads an inventory of the objects under prefix, see the package-level UploadInventory.
+func (b *Bucket) UploadInventory(ctx context.Context, prefix, reportKey string, format InventoryFormat) (int, error) {
+	var contentType string
+	switch format {
+	case InventoryCSV:
+		contentType = "text/csv"
+	case InventoryJSON:
+		contentType = "application/x-ndjson"
+	default:
+		return 0, fmt.Errorf("unknown inventory format %q, expected csv or json", format)
+	}
+
+	pr, pw := io.Pipe()
+	result := make(chan int, 1)
+	go func() {
+		n, err := b.WriteInventory(ctx, prefix, pw, format)
+		pw.CloseWithError(err)
+		result <- n
+	}()
+	err := b.Upload(ctx, reportKey, pr, WithContentType(contentType))
+	// Unblocks the inventory if the upload stopped reading.
+	pr.CloseWithError(err)
+	return <-result, err
+}
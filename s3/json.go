@@ -0,0 +1,54 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// UploadJSON stores v as a JSON document under key, with content type application/json
+// unless opts set another one.
+func UploadJSON(ctx context.Context, key string, v any, opts ...UploadOption) error {
+	return defaultBucket().UploadJSON(ctx, key, v, opts...)
+}
+
+// UploadJSON stores v as a JSON document under key, see the package-level UploadJSON.
+func (b *Bucket) UploadJSON(ctx context.Context, key string, v any, opts ...UploadOption) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	opts = append([]UploadOption{WithContentType("application/json")}, opts...)
+	return b.Upload(ctx, key, bytes.NewReader(data), opts...)
+}
+
+// DownloadJSON decodes the JSON document stored under key into a T, e.g.
+//
+//	cfg, err := s3.DownloadJSON[Config](ctx, "config/app.json")
+//
+// A missing object is reported as a *NotFoundError.
+func DownloadJSON[T any](ctx context.Context, key string, opts ...DownloadOption) (T, error) {
+	return DownloadJSONFrom[T](ctx, defaultBucket(), key, opts...)
+}
+
+// DownloadJSONFrom is DownloadJSON from the bucket b, e.g. one opened with OpenBucket.
+func DownloadJSONFrom[T any](ctx context.Context, b *Bucket, key string, opts ...DownloadOption) (T, error) {
+	var v T
+	obj, err := b.Download(ctx, key, opts...)
+	if err != nil {
+		return v, err
+	}
+	defer obj.Close()
+
+	if err := json.NewDecoder(obj).Decode(&v); err != nil {
+		return v, fmt.Errorf("failed to decode JSON of %s: %w", key, err)
+	}
+	// Read to the end, so the content is verified and can be cached.
+	if _, err := io.Copy(io.Discard, obj); err != nil {
+		return v, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,46 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/michaldziurowski/one/s3"
+)
+
+func TestJSON(t *testing.T) {
+	ctx := context.Background()
+	b, prefix := openTestBucket(t)
+
+	type state struct {
+		Version int
+		Names   []string
+	}
+	want := state{Version: 3, Names: []string{"a", "b"}}
+	if err := b.UploadJSON(ctx, prefix+"state.json", want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s3.DownloadJSONFrom[state](ctx, b, prefix+"state.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if info, err := b.Stat(ctx, prefix+"state.json"); err != nil || info.ContentType != "application/json" {
+		t.Errorf("stored with content type %q, error %v", info.ContentType, err)
+	}
+
+	var notFound *s3.NotFoundError
+	if _, err := s3.DownloadJSONFrom[state](ctx, b, prefix+"missing.json"); !errors.As(err, &notFound) {
+		t.Errorf("download of a missing object returned %v, want a *NotFoundError", err)
+	}
+	if err := b.Upload(ctx, prefix+"broken.json", strings.NewReader("{")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s3.DownloadJSONFrom[state](ctx, b, prefix+"broken.json"); err == nil {
+		t.Error("download of invalid JSON succeeded")
+	}
+}
@@ -0,0 +1,146 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// StorageClass is a storage class objects can transition to, see LifecycleRule.
+type StorageClass string
+
+const (
+	StorageClassStandardIA         StorageClass = "STANDARD_IA"
+	StorageClassOneZoneIA          StorageClass = "ONEZONE_IA"
+	StorageClassIntelligentTiering StorageClass = "INTELLIGENT_TIERING"
+	StorageClassGlacierIR          StorageClass = "GLACIER_IR"
+	StorageClassGlacier            StorageClass = "GLACIER"
+	StorageClassDeepArchive        StorageClass = "DEEP_ARCHIVE"
+)
+
+// LifecycleRule is a retention rule S3 applies in the background to the objects under Prefix,
+// e.g. to expire tmp/ after 7 days:
+//
+//	s3.LifecycleRule{Prefix: "tmp/", ExpireAfterDays: 7}
+//
+// Days count from the creation of each object. S3 applies rules once a day, so objects can
+// outlive them by a day or two.
+type LifecycleRule struct {
+	// Prefix selects the objects the rule applies to, all objects if empty.
+	Prefix string
+	// ExpireAfterDays deletes objects this many days after they were created, if positive.
+	ExpireAfterDays int32
+	// TransitionAfterDays moves objects to TransitionTo this many days after they were created, if positive.
+	TransitionAfterDays int32
+	TransitionTo        StorageClass
+}
+
+// WithLifecycleRules makes rules the lifecycle configuration of the bucket, so retention policy is
+// declared in code next to the uploads that depend on it. Init applies them every time, replacing
+// rules set elsewhere except those of UploadTemp; without this option the lifecycle configuration
+// is left as it is.
+func WithLifecycleRules(rules ...LifecycleRule) Option {
+	return func(c *config) {
+		c.lifecycleRules = rules
+	}
+}
+
+// SetLifecycleRules replaces the lifecycle configuration of the bucket with rules, see LifecycleRule.
+// The rules UploadTemp adds are kept. Setting the same rules again changes nothing, and setting none
+// removes the configuration.
+func SetLifecycleRules(ctx context.Context, rules ...LifecycleRule) error {
+	return defaultBucket().SetLifecycleRules(ctx, rules...)
+}
+
+// SetLifecycleRules replaces the lifecycle configuration of the bucket, see the package-level SetLifecycleRules.
+func (b *Bucket) SetLifecycleRules(ctx context.Context, rules ...LifecycleRule) error {
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+
+	var lifecycleRules []types.LifecycleRule
+	for _, rule := range rules {
+		r, err := lifecycleRule(rule)
+		if err != nil {
+			return err
+		}
+		lifecycleRules = append(lifecycleRules, r)
+	}
+
+	current, err := b.currentLifecycleRules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set lifecycle rules: %w", err)
+	}
+	for _, r := range current {
+		if isTTLRule(r) {
+			lifecycleRules = append(lifecycleRules, r)
+		}
+	}
+
+	if err := b.putLifecycleRules(ctx, lifecycleRules); err != nil {
+		return fmt.Errorf("failed to set lifecycle rules: %w", err)
+	}
+	return nil
+}
+
+// currentLifecycleRules returns the lifecycle rules of the bucket.
+func (b *Bucket) currentLifecycleRules(ctx context.Context) ([]types.LifecycleRule, error) {
+	out, err := b.client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(b.name),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchLifecycleConfiguration" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return out.Rules, nil
+}
+
+// putLifecycleRules makes rules the lifecycle configuration of the bucket, removing it if there are none.
+func (b *Bucket) putLifecycleRules(ctx context.Context, rules []types.LifecycleRule) error {
+	if len(rules) == 0 {
+		_, err := b.client.DeleteBucketLifecycle(ctx, &s3.DeleteBucketLifecycleInput{
+			Bucket: aws.String(b.name),
+		})
+		return err
+	}
+
+	_, err := b.client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(b.name),
+		LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: rules},
+	})
+	return err
+}
+
+func lifecycleRule(rule LifecycleRule) (types.LifecycleRule, error) {
+	if rule.ExpireAfterDays <= 0 && rule.TransitionAfterDays <= 0 {
+		return types.LifecycleRule{}, fmt.Errorf("lifecycle rule for prefix %q neither expires nor transitions objects", rule.Prefix)
+	}
+
+	r := types.LifecycleRule{
+		// IDs identify rules in the console and must be unique, as prefixes of rules are.
+		ID:     aws.String("prefix:" + rule.Prefix),
+		Status: types.ExpirationStatusEnabled,
+		Filter: &types.LifecycleRuleFilter{Prefix: aws.String(rule.Prefix)},
+	}
+	if rule.ExpireAfterDays > 0 {
+		r.Expiration = &types.LifecycleExpiration{Days: aws.Int32(rule.ExpireAfterDays)}
+	}
+	if rule.TransitionAfterDays > 0 {
+		if rule.TransitionTo == "" {
+			return types.LifecycleRule{}, fmt.Errorf("lifecycle rule for prefix %q transitions objects without a storage class", rule.Prefix)
+		}
+		r.Transitions = []types.Transition{{
+			Days:         aws.Int32(rule.TransitionAfterDays),
+			StorageClass: types.TransitionStorageClass(rule.TransitionTo),
+		}}
+	}
+	return r, nil
+}
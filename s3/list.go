@@ -0,0 +1,122 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"iter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// List returns the objects whose key starts with prefix, in key order except in directory buckets,
+// which list in no particular order. Further pages are fetched as the iteration reaches them, so
+// stopping early skips the remaining requests:
+//
+//	for obj, err := range s3.List(ctx, "reports/") {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(obj.Key, obj.Size)
+//	}
+//
+// ContentType is not part of a listing and is left empty.
+func List(ctx context.Context, prefix string) iter.Seq2[ObjectInfo, error] {
+	return defaultBucket().List(ctx, prefix)
+}
+
+// List returns the objects whose key starts with prefix, see the package-level List.
+func (b *Bucket) List(ctx context.Context, prefix string) iter.Seq2[ObjectInfo, error] {
+	return func(yield func(ObjectInfo, error) bool) {
+		// The span covers the whole iteration, including the time the caller spends per object.
+		ctx, op := b.startOperation(ctx, "List", attribute.String("aws.s3.prefix", prefix))
+		var objects int
+		var err error
+		defer func() {
+			op.span.SetAttributes(attribute.Int("aws.s3.objects", objects))
+			op.end(err)
+		}()
+
+		if err = b.checkOpen(); err != nil {
+			yield(ObjectInfo{}, err)
+			return
+		}
+
+		paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+			Bucket: aws.String(b.name),
+			Prefix: aws.String(prefix),
+		})
+		for paginator.HasMorePages() {
+			var page *s3.ListObjectsV2Output
+			pageCtx, cancel := withTimeout(ctx, b.settings.timeouts.List)
+			page, err = paginator.NextPage(pageCtx)
+			cancel()
+			if err != nil {
+				err = fmt.Errorf("failed to list objects: %w", err)
+				yield(ObjectInfo{}, err)
+				return
+			}
+			for _, o := range page.Contents {
+				objects++
+				if !yield(objectInfo(o), nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// objectInfo converts a listed object.
+func objectInfo(o types.Object) ObjectInfo {
+	return ObjectInfo{
+		Key:          aws.ToString(o.Key),
+		Size:         aws.ToInt64(o.Size),
+		ETag:         aws.ToString(o.ETag),
+		LastModified: aws.ToTime(o.LastModified),
+	}
+}
+
+// ListPage returns up to max objects whose key starts with prefix, starting at token, and the token
+// of the next page, empty after the last one. Pass an empty token for the first page. S3 returns at
+// most 1000 objects per page, which is also used when max is not positive. Tokens are opaque and
+// only valid for the same prefix, which makes ListPage suitable for paged APIs over a bucket.
+func ListPage(ctx context.Context, prefix, token string, max int) ([]ObjectInfo, string, error) {
+	return defaultBucket().ListPage(ctx, prefix, token, max)
+}
+
+// ListPage returns a page of the objects whose key starts with prefix, see the package-level ListPage.
+func (b *Bucket) ListPage(ctx context.Context, prefix, token string, max int) (_ []ObjectInfo, _ string, err error) {
+	ctx, op := b.startOperation(ctx, "ListPage", attribute.String("aws.s3.prefix", prefix))
+	defer func() { op.end(err) }()
+	ctx, cancel := withTimeout(ctx, b.settings.timeouts.List)
+	defer cancel()
+
+	if err := b.checkOpen(); err != nil {
+		return nil, "", err
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.name),
+		Prefix: aws.String(prefix),
+	}
+	if token != "" {
+		input.ContinuationToken = aws.String(token)
+	}
+	if max > 0 {
+		input.MaxKeys = aws.Int32(int32(min(max, 1000)))
+	}
+
+	out, err := b.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	objects := make([]ObjectInfo, len(out.Contents))
+	for i, o := range out.Contents {
+		objects[i] = objectInfo(o)
+	}
+	op.span.SetAttributes(attribute.Int("aws.s3.objects", len(objects)))
+	return objects, aws.ToString(out.NextContinuationToken), nil
+}
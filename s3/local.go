@@ -0,0 +1,212 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// localTempPrefix starts the names of files LocalStorage is still writing, which are not listed.
+const localTempPrefix = ".upload-"
+
+// LocalStorage is a Storage that keeps objects as files in a local directory, keyed by their path
+// relative to it, e.g. for development and small deployments without S3. Only the content is stored:
+// ContentType is derived from the extension of the key and the upload options other than WithProgress
+// have no effect.
+type LocalStorage struct {
+	root string
+}
+
+// OpenLocal opens the directory root as a LocalStorage, creating it if missing.
+func OpenLocal(root string) (*LocalStorage, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &LocalStorage{root: root}, nil
+}
+
+// Upload stores the content of reader under key. The file is written next to its final path and
+// renamed into place once complete, so readers never see a partial object.
+func (l *LocalStorage) Upload(ctx context.Context, key string, reader io.Reader, opts ...UploadOption) error {
+	var o uploadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(name), localTempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if o.progress != nil {
+		reader = &progressReader{Reader: reader, fn: o.progress, total: readerSize(reader)}
+	}
+	if _, err := io.Copy(file, contextReader{ctx, reader}); err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	if err := os.Rename(file.Name(), name); err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	return nil
+}
+
+// Download returns the content of the object stored under key. A missing object is reported
+// as a *NotFoundError.
+func (l *LocalStorage) Download(ctx context.Context, key string, opts ...DownloadOption) (*Object, error) {
+	var o downloadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	name, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to download object: %w", &NotFoundError{Key: key})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download object: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to download object: %w", err)
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, fmt.Errorf("failed to download object: %w", &NotFoundError{Key: key})
+	}
+
+	var body io.ReadCloser = file
+	if o.progress != nil {
+		body = struct {
+			io.Reader
+			io.Closer
+		}{&progressReader{Reader: file, fn: o.progress, total: info.Size()}, file}
+	}
+	return &Object{ReadCloser: body, ObjectInfo: l.objectInfo(key, info)}, nil
+}
+
+// List returns the objects whose key starts with prefix, in key order.
+func (l *LocalStorage) List(ctx context.Context, prefix string) iter.Seq2[ObjectInfo, error] {
+	return func(yield func(ObjectInfo, error) bool) {
+		// Only the directory the prefix ends in can contain matching files.
+		start := filepath.Join(l.root, filepath.FromSlash(path.Dir("/"+prefix+"x")))
+		var objects []ObjectInfo
+		err := filepath.WalkDir(start, func(name string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && name == start {
+				return filepath.SkipAll
+			}
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), localTempPrefix) {
+				return nil
+			}
+			rel, err := filepath.Rel(l.root, name)
+			if err != nil {
+				return err
+			}
+			key := filepath.ToSlash(rel)
+			if !strings.HasPrefix(key, prefix) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			objects = append(objects, l.objectInfo(key, info))
+			return nil
+		})
+		if err != nil {
+			yield(ObjectInfo{}, fmt.Errorf("failed to list objects: %w", err))
+			return
+		}
+
+		// Directories are walked in name order, which differs from key order where names
+		// sort before "/", e.g. "a-b" before "a/b".
+		slices.SortFunc(objects, func(a, b ObjectInfo) int { return strings.Compare(a.Key, b.Key) })
+		for _, obj := range objects {
+			if !yield(obj, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Delete removes the object stored under key, and the directories left empty by it.
+// Deleting an object that does not exist succeeds.
+func (l *LocalStorage) Delete(ctx context.Context, key string) error {
+	name, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	root := filepath.Clean(l.root)
+	for dir := filepath.Dir(name); dir != root; dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// path returns the file name of key, which must not leave the directory.
+func (l *LocalStorage) path(key string) (string, error) {
+	if strings.HasSuffix(key, "/") || !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid key %q for local storage", key)
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
+
+func (l *LocalStorage) objectInfo(key string, info fs.FileInfo) ObjectInfo {
+	return ObjectInfo{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  mime.TypeByExtension(path.Ext(key)),
+		LastModified: info.ModTime(),
+	}
+}
+
+// contextReader stops reading once ctx is done.
+type contextReader struct {
+	ctx context.Context
+	io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.Reader.Read(p)
+}
@@ -0,0 +1,179 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// notificationIDPrefix starts the IDs of the notification configurations managed by SetNotifications.
+const notificationIDPrefix = "notify:"
+
+// Notification sends S3 events about the objects under Prefix ending in Suffix to an SQS queue or
+// SNS topic, e.g. to process uploads as they arrive:
+//
+//	s3.Notification{QueueARN: "arn:aws:sqs:eu-west-1:123456789012:thumbnails", Prefix: "images/"}
+//
+// The access policy of the queue or topic has to allow s3.amazonaws.com to send to it.
+type Notification struct {
+	// QueueARN or TopicARN is the destination of the events, exactly one has to be set.
+	QueueARN string
+	TopicARN string
+	// Prefix and Suffix select the objects by key, all objects if empty.
+	Prefix string
+	Suffix string
+	// Events are the S3 event types, e.g. "s3:ObjectRemoved:*". Defaults to "s3:ObjectCreated:*".
+	Events []string
+}
+
+// WithNotifications makes notifications the queue and topic notifications of the bucket, so the
+// processors of its events are wired in code. Init applies them every time like WithLifecycleRules;
+// without this option the notification configuration is left as it is.
+func WithNotifications(notifications ...Notification) Option {
+	return func(c *config) {
+		c.notifications = notifications
+	}
+}
+
+// SetNotifications replaces the notifications of the bucket set by an earlier SetNotifications
+// with notifications, see Notification. Notifications set elsewhere, such as Lambda functions,
+// EventBridge and queues or topics configured in the console, are kept. The configuration is only
+// written when it changes, as S3 then sends a test event to every destination.
+func SetNotifications(ctx context.Context, notifications ...Notification) error {
+	return defaultBucket().SetNotifications(ctx, notifications...)
+}
+
+// SetNotifications replaces the notifications of the bucket, see the package-level SetNotifications.
+func (b *Bucket) SetNotifications(ctx context.Context, notifications ...Notification) error {
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+
+	var queues []types.QueueConfiguration
+	var topics []types.TopicConfiguration
+	for _, n := range notifications {
+		if (n.QueueARN == "") == (n.TopicARN == "") {
+			return fmt.Errorf("notification for prefix %q needs either a queue or a topic", n.Prefix)
+		}
+		events := []types.Event{types.EventS3ObjectCreated}
+		if len(n.Events) > 0 {
+			events = make([]types.Event, len(n.Events))
+			for i, event := range n.Events {
+				events[i] = types.Event(event)
+			}
+		}
+		if n.QueueARN != "" {
+			queues = append(queues, types.QueueConfiguration{
+				Id:       aws.String(notificationID(n.QueueARN, n)),
+				QueueArn: aws.String(n.QueueARN),
+				Events:   events,
+				Filter:   notificationFilter(n),
+			})
+		} else {
+			topics = append(topics, types.TopicConfiguration{
+				Id:       aws.String(notificationID(n.TopicARN, n)),
+				TopicArn: aws.String(n.TopicARN),
+				Events:   events,
+				Filter:   notificationFilter(n),
+			})
+		}
+	}
+
+	current, err := b.client.GetBucketNotificationConfiguration(ctx, &s3.GetBucketNotificationConfigurationInput{
+		Bucket: aws.String(b.name),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set notifications: %w", err)
+	}
+
+	var currentManaged, managed []string
+	for _, q := range current.QueueConfigurations {
+		if isManagedNotification(q.Id) {
+			currentManaged = append(currentManaged, notificationKey(q.Id, q.QueueArn, q.Events, q.Filter))
+		} else {
+			queues = append(queues, q)
+		}
+	}
+	for _, t := range current.TopicConfigurations {
+		if isManagedNotification(t.Id) {
+			currentManaged = append(currentManaged, notificationKey(t.Id, t.TopicArn, t.Events, t.Filter))
+		} else {
+			topics = append(topics, t)
+		}
+	}
+	for _, q := range queues {
+		if isManagedNotification(q.Id) {
+			managed = append(managed, notificationKey(q.Id, q.QueueArn, q.Events, q.Filter))
+		}
+	}
+	for _, t := range topics {
+		if isManagedNotification(t.Id) {
+			managed = append(managed, notificationKey(t.Id, t.TopicArn, t.Events, t.Filter))
+		}
+	}
+	slices.Sort(currentManaged)
+	slices.Sort(managed)
+	if slices.Equal(currentManaged, managed) {
+		return nil
+	}
+
+	_, err = b.client.PutBucketNotificationConfiguration(ctx, &s3.PutBucketNotificationConfigurationInput{
+		Bucket: aws.String(b.name),
+		NotificationConfiguration: &types.NotificationConfiguration{
+			QueueConfigurations:          queues,
+			TopicConfigurations:          topics,
+			LambdaFunctionConfigurations: current.LambdaFunctionConfigurations,
+			EventBridgeConfiguration:     current.EventBridgeConfiguration,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set notifications: %w", err)
+	}
+	return nil
+}
+
+// notificationID identifies the notification n to arn in the console. IDs have to be unique, as
+// destinations with overlapping filters have to be.
+func notificationID(arn string, n Notification) string {
+	name := arn[strings.LastIndex(arn, ":")+1:]
+	return notificationIDPrefix + name + ":" + n.Prefix + "*" + n.Suffix
+}
+
+func isManagedNotification(id *string) bool {
+	return strings.HasPrefix(aws.ToString(id), notificationIDPrefix)
+}
+
+func notificationFilter(n Notification) *types.NotificationConfigurationFilter {
+	var rules []types.FilterRule
+	if n.Prefix != "" {
+		rules = append(rules, types.FilterRule{Name: types.FilterRuleNamePrefix, Value: aws.String(n.Prefix)})
+	}
+	if n.Suffix != "" {
+		rules = append(rules, types.FilterRule{Name: types.FilterRuleNameSuffix, Value: aws.String(n.Suffix)})
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return &types.NotificationConfigurationFilter{Key: &types.S3KeyFilter{FilterRules: rules}}
+}
+
+// notificationKey returns a comparable form of a notification configuration. S3 returns filter
+// rule names capitalized and events and rules in any order.
+func notificationKey(id, arn *string, events []types.Event, filter *types.NotificationConfigurationFilter) string {
+	parts := []string{aws.ToString(id), aws.ToString(arn)}
+	for _, event := range events {
+		parts = append(parts, string(event))
+	}
+	if filter != nil && filter.Key != nil {
+		for _, rule := range filter.Key.FilterRules {
+			parts = append(parts, strings.ToLower(string(rule.Name))+"="+aws.ToString(rule.Value))
+		}
+	}
+	slices.Sort(parts[2:])
+	return strings.Join(parts, "|")
+}
@@ -0,0 +1,178 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// RetentionMode is how strictly S3 protects an object under retention, see WithRetention.
+type RetentionMode string
+
+const (
+	// RetentionGovernance protects the object from users without the
+	// s3:BypassGovernanceRetention permission, who can still shorten or remove the retention.
+	RetentionGovernance RetentionMode = "GOVERNANCE"
+	// RetentionCompliance protects the object from everyone, including the root user of the
+	// account, until the retention expires. It cannot be shortened or removed.
+	RetentionCompliance RetentionMode = "COMPLIANCE"
+)
+
+// WithObjectLock enables S3 Object Lock on the bucket, so objects can be protected from being
+// overwritten or deleted with WithRetention and WithLegalHold, e.g. for WORM archives. A new bucket
+// is created with it; an existing bucket gets versioning enabled first, which Object Lock requires.
+// Object Lock cannot be disabled again.
+func WithObjectLock() Option {
+	return func(c *config) {
+		c.objectLock = true
+	}
+}
+
+// WithDefaultRetention enables Object Lock like WithObjectLock and protects every new object in
+// mode for days after its upload, unless the upload sets its own retention. Init applies it every
+// time, replacing a default retention set elsewhere; with WithObjectLock alone, that one is kept.
+func WithDefaultRetention(mode RetentionMode, days int32) Option {
+	return func(c *config) {
+		c.objectLock = true
+		c.defaultRetentionMode = mode
+		c.defaultRetentionDays = days
+	}
+}
+
+// WithRetention protects the uploaded object in mode until until, so neither it nor, in a
+// versioned bucket, this version of it can be deleted or overwritten before then. The bucket has to
+// have Object Lock enabled, see WithObjectLock.
+func WithRetention(mode RetentionMode, until time.Time) UploadOption {
+	return func(o *uploadOptions) {
+		o.retentionMode = mode
+		o.retainUntil = until
+	}
+}
+
+// WithLegalHold protects the uploaded object until the hold is removed with SetLegalHold,
+// independently of any retention. The bucket has to have Object Lock enabled, see WithObjectLock.
+func WithLegalHold() UploadOption {
+	return func(o *uploadOptions) {
+		o.legalHold = true
+	}
+}
+
+// SetRetention protects the object stored under key in mode until until, see WithRetention.
+// Retention in RetentionCompliance mode can only be extended. A missing object is reported
+// as a *NotFoundError.
+func SetRetention(ctx context.Context, key string, mode RetentionMode, until time.Time) error {
+	return defaultBucket().SetRetention(ctx, key, mode, until)
+}
+
+// SetRetention protects the object stored under key until until, see the package-level SetRetention.
+func (b *Bucket) SetRetention(ctx context.Context, key string, mode RetentionMode, until time.Time) error {
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+
+	_, err := b.client.PutObjectRetention(ctx, &s3.PutObjectRetentionInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(key),
+		Retention: &types.ObjectLockRetention{
+			Mode:            types.ObjectLockRetentionMode(mode),
+			RetainUntilDate: aws.Time(until),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set retention: %w", notFound(err, key))
+	}
+	return nil
+}
+
+// SetLegalHold places or, if on is false, removes a legal hold on the object stored under key,
+// see WithLegalHold. A missing object is reported as a *NotFoundError.
+func SetLegalHold(ctx context.Context, key string, on bool) error {
+	return defaultBucket().SetLegalHold(ctx, key, on)
+}
+
+// SetLegalHold places or removes a legal hold on the object stored under key, see the package-level SetLegalHold.
+func (b *Bucket) SetLegalHold(ctx context.Context, key string, on bool) error {
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+
+	status := types.ObjectLockLegalHoldStatusOff
+	if on {
+		status = types.ObjectLockLegalHoldStatusOn
+	}
+	_, err := b.client.PutObjectLegalHold(ctx, &s3.PutObjectLegalHoldInput{
+		Bucket:    aws.String(b.name),
+		Key:       aws.String(key),
+		LegalHold: &types.ObjectLockLegalHold{Status: status},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set legal hold: %w", notFound(err, key))
+	}
+	return nil
+}
+
+// ensureObjectLock enables Object Lock on the existing bucket, unless it already is, and sets the
+// configured default retention if it differs. A default retention set elsewhere is left alone unless
+// one is configured.
+func (b *Bucket) ensureObjectLock(ctx context.Context) error {
+	var rule *types.ObjectLockRule
+	if b.settings.defaultRetentionDays > 0 {
+		rule = &types.ObjectLockRule{DefaultRetention: &types.DefaultRetention{
+			Mode: types.ObjectLockRetentionMode(b.settings.defaultRetentionMode),
+			Days: aws.Int32(b.settings.defaultRetentionDays),
+		}}
+	}
+
+	out, err := b.client.GetObjectLockConfiguration(ctx, &s3.GetObjectLockConfigurationInput{
+		Bucket: aws.String(b.name),
+	})
+	var apiErr smithy.APIError
+	if err != nil && !(errors.As(err, &apiErr) && apiErr.ErrorCode() == "ObjectLockConfigurationNotFoundError") {
+		return fmt.Errorf("failed to get object lock configuration: %w", err)
+	}
+
+	if err == nil && out.ObjectLockConfiguration != nil &&
+		out.ObjectLockConfiguration.ObjectLockEnabled == types.ObjectLockEnabledEnabled {
+		// Without a default retention of its own, one set elsewhere is kept, as replacing it would
+		// lift the protection of every object uploaded from then on.
+		if rule == nil || sameObjectLockRule(out.ObjectLockConfiguration.Rule, rule) {
+			return nil
+		}
+	} else {
+		_, err = b.client.PutBucketVersioning(ctx, &s3.PutBucketVersioningInput{
+			Bucket:                  aws.String(b.name),
+			VersioningConfiguration: &types.VersioningConfiguration{Status: types.BucketVersioningStatusEnabled},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to enable versioning: %w", err)
+		}
+	}
+
+	_, err = b.client.PutObjectLockConfiguration(ctx, &s3.PutObjectLockConfigurationInput{
+		Bucket: aws.String(b.name),
+		ObjectLockConfiguration: &types.ObjectLockConfiguration{
+			ObjectLockEnabled: types.ObjectLockEnabledEnabled,
+			Rule:              rule,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enable object lock: %w", err)
+	}
+	return nil
+}
+
+// sameObjectLockRule reports whether the rule a of the bucket is the configured rule b, which is not nil.
+func sameObjectLockRule(a, b *types.ObjectLockRule) bool {
+	if a == nil || a.DefaultRetention == nil {
+		return false
+	}
+	return a.DefaultRetention.Mode == b.DefaultRetention.Mode &&
+		aws.ToInt32(a.DefaultRetention.Days) == aws.ToInt32(b.DefaultRetention.Days) &&
+		a.DefaultRetention.Years == nil
+}
@@ -0,0 +1,311 @@
+package s3
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// config holds the settings applied by Init and OpenBucket.
+type config struct {
+	bucket     string
+	endpoint   string
+	pathStyle  *bool
+	disableSSL bool
+	region     string
+	awsConfig  *aws.Config
+
+	requesterPays bool
+	directoryZone string
+
+	credentialsProvider aws.CredentialsProvider
+	roleARN             string
+	externalID          string
+
+	sse      types.ServerSideEncryption
+	kmsKeyID string
+
+	partSize       int64
+	concurrency    int
+	maxUploadParts int32
+
+	timeouts Timeouts
+
+	cacheDir      string
+	cacheMaxBytes int64
+
+	maxAttempts int
+	maxBackoff  time.Duration
+	retryable   func(err error) bool
+	retryHook   func(attempt int, delay time.Duration, err error)
+
+	lifecycleRules []LifecycleRule
+	publicPrefixes []string
+	notifications  []Notification
+	secureDefaults bool
+
+	cloudFrontDomain        string
+	cloudFrontKeyPairID     string
+	cloudFrontPrivateKeyPEM []byte
+	cloudFrontKey           *rsa.PrivateKey
+
+	failoverBucket  string
+	failoverOptions []Option
+	failover        *config
+	dualWrite       bool
+
+	objectLock           bool
+	defaultRetentionMode RetentionMode
+	defaultRetentionDays int32
+
+	tracerProvider trace.TracerProvider
+	operationHook  func(ctx context.Context, event OperationEvent)
+}
+
+// Option configures Init and OpenBucket. Settings that are not given fall back to environment variables.
+type Option func(*config)
+
+// WithBucket sets the bucket opened by Init instead of $APP_NAME.
+// OpenBucket takes the bucket as an argument and ignores it.
+func WithBucket(name string) Option {
+	return func(c *config) {
+		c.bucket = name
+	}
+}
+
+// WithEndpoint sends requests to the S3-compatible service at url, e.g. LocalStack, MinIO or
+// Cloudflare R2, addressing buckets by path unless WithPathStyle(false) is given. A url without
+// scheme, such as "minio.internal:9000", uses HTTPS unless WithDisableSSL is given.
+// Defaults to $AWS_ENDPOINT_URL or AWS itself.
+func WithEndpoint(url string) Option {
+	return func(c *config) {
+		c.endpoint = url
+	}
+}
+
+// WithPathStyle sets whether buckets are addressed by path, as in https://host/bucket/key, or by
+// virtual host, as in https://bucket.host/key. Services such as MinIO without a wildcard domain and
+// Ceph need path style, while AWS deprecates it. Defaults to $S3_PATH_STYLE, or to path style with
+// a custom endpoint and virtual hosts with AWS.
+func WithPathStyle(enabled bool) Option {
+	return func(c *config) {
+		c.pathStyle = &enabled
+	}
+}
+
+// WithDisableSSL sends requests over plain HTTP, e.g. to a MinIO or Ceph gateway without TLS in a
+// private network. The secret key is never sent, but requests and content can be read and altered
+// on the way. Defaults to $S3_DISABLE_SSL.
+func WithDisableSSL() Option {
+	return func(c *config) {
+		c.disableSSL = true
+	}
+}
+
+// WithRegion sets the region of the bucket. Defaults to the region of the AWS configuration,
+// i.e. $AWS_REGION or the shared config file.
+func WithRegion(region string) Option {
+	return func(c *config) {
+		c.region = region
+	}
+}
+
+// WithConfig uses cfg instead of loading the AWS configuration from the environment and shared
+// files, e.g. to share credentials and HTTP settings with other AWS clients. The other options
+// still apply on top of it.
+func WithConfig(cfg aws.Config) Option {
+	return func(c *config) {
+		c.awsConfig = &cfg
+	}
+}
+
+// WithSSES3 encrypts every uploaded and copied object with S3-managed keys (SSE-S3), and makes
+// it the default encryption of the bucket so objects written by other clients are covered too.
+func WithSSES3() Option {
+	return func(c *config) {
+		c.sse = types.ServerSideEncryptionAes256
+		c.kmsKeyID = ""
+	}
+}
+
+// WithSSEKMS encrypts every uploaded and copied object with the KMS key keyID (SSE-KMS), given as
+// key ID, alias or ARN, and makes it the default encryption of the bucket like WithSSES3. An empty
+// keyID uses the AWS managed key of S3. Downloads need kms:Decrypt permission on the key.
+func WithSSEKMS(keyID string) Option {
+	return func(c *config) {
+		c.sse = types.ServerSideEncryptionAwsKms
+		c.kmsKeyID = keyID
+	}
+}
+
+// WithPartSize sets the size of the parts large objects are uploaded and downloaded in, at least 5MB.
+// Larger parts mean fewer requests, but memory use grows with part size times concurrency.
+// Defaults to $S3_PART_SIZE bytes or 10MB.
+func WithPartSize(bytes int64) Option {
+	return func(c *config) {
+		c.partSize = bytes
+	}
+}
+
+// WithConcurrency sets how many parts of one object are uploaded, downloaded or copied at once.
+// Defaults to $S3_CONCURRENCY or 5.
+func WithConcurrency(n int) Option {
+	return func(c *config) {
+		c.concurrency = n
+	}
+}
+
+// WithMaxUploadParts sets the most parts an upload is split into, at most 10000. Uploads of streams
+// larger than the part size times this fail, so it bounds object size together with WithPartSize.
+// Defaults to $S3_MAX_UPLOAD_PARTS or 10000.
+func WithMaxUploadParts(n int32) Option {
+	return func(c *config) {
+		c.maxUploadParts = n
+	}
+}
+
+// loadConfig applies opts and falls back to environment variables and defaults for the rest.
+func loadConfig(opts []Option) (config, error) {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if c.pathStyle == nil {
+		if v := os.Getenv("S3_PATH_STYLE"); v != "" {
+			enabled, err := strconv.ParseBool(v)
+			if err != nil {
+				return c, fmt.Errorf("invalid S3_PATH_STYLE: %w", err)
+			}
+			c.pathStyle = &enabled
+		}
+	}
+	if !c.disableSSL {
+		if v := os.Getenv("S3_DISABLE_SSL"); v != "" {
+			disabled, err := strconv.ParseBool(v)
+			if err != nil {
+				return c, fmt.Errorf("invalid S3_DISABLE_SSL: %w", err)
+			}
+			c.disableSSL = disabled
+		}
+	}
+	if c.endpoint != "" && !strings.Contains(c.endpoint, "://") {
+		if c.disableSSL {
+			c.endpoint = "http://" + c.endpoint
+		} else {
+			c.endpoint = "https://" + c.endpoint
+		}
+	}
+
+	if c.roleARN == "" {
+		c.roleARN = os.Getenv("S3_ROLE_ARN")
+		c.externalID = os.Getenv("S3_ROLE_EXTERNAL_ID")
+	}
+
+	if c.partSize == 0 {
+		c.partSize = 10 * 1024 * 1024 // 10MB per part
+		if v := os.Getenv("S3_PART_SIZE"); v != "" {
+			size, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return c, fmt.Errorf("invalid S3_PART_SIZE: %w", err)
+			}
+			c.partSize = size
+		}
+	}
+	if c.partSize < manager.MinUploadPartSize {
+		return c, fmt.Errorf("part size must be at least %d bytes, got %d", manager.MinUploadPartSize, c.partSize)
+	}
+
+	if c.concurrency == 0 {
+		c.concurrency = 5 // 5 concurrent parts
+		if v := os.Getenv("S3_CONCURRENCY"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return c, fmt.Errorf("invalid S3_CONCURRENCY: %w", err)
+			}
+			c.concurrency = n
+		}
+	}
+	if c.concurrency < 1 {
+		return c, fmt.Errorf("concurrency must be at least 1, got %d", c.concurrency)
+	}
+
+	if c.maxUploadParts == 0 {
+		c.maxUploadParts = manager.MaxUploadParts
+		if v := os.Getenv("S3_MAX_UPLOAD_PARTS"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				return c, fmt.Errorf("invalid S3_MAX_UPLOAD_PARTS: %w", err)
+			}
+			c.maxUploadParts = int32(n)
+		}
+	}
+	if c.maxUploadParts < 1 || c.maxUploadParts > manager.MaxUploadParts {
+		return c, fmt.Errorf("max upload parts must be between 1 and %d, got %d", manager.MaxUploadParts, c.maxUploadParts)
+	}
+
+	if c.cacheDir != "" && c.cacheMaxBytes <= 0 {
+		return c, fmt.Errorf("cache size must be positive, got %d", c.cacheMaxBytes)
+	}
+
+	if c.directoryZone != "" && (c.objectLock || len(c.publicPrefixes) > 0 || c.secureDefaults ||
+		len(c.notifications) > 0 || len(c.lifecycleRules) > 0) {
+		return c, fmt.Errorf("directory buckets support neither object lock, public prefixes, secure defaults, notifications nor lifecycle rules")
+	}
+
+	if c.defaultRetentionMode != "" || c.defaultRetentionDays != 0 {
+		if c.defaultRetentionMode != RetentionGovernance && c.defaultRetentionMode != RetentionCompliance {
+			return c, fmt.Errorf("invalid default retention mode %q", c.defaultRetentionMode)
+		}
+		if c.defaultRetentionDays < 1 {
+			return c, fmt.Errorf("default retention must be at least 1 day, got %d", c.defaultRetentionDays)
+		}
+	}
+
+	if c.cloudFrontDomain == "" {
+		c.cloudFrontDomain = os.Getenv("CLOUDFRONT_DOMAIN")
+		c.cloudFrontKeyPairID = os.Getenv("CLOUDFRONT_KEY_PAIR_ID")
+		c.cloudFrontPrivateKeyPEM = []byte(os.Getenv("CLOUDFRONT_PRIVATE_KEY"))
+	}
+	if c.cloudFrontDomain != "" {
+		c.cloudFrontDomain = strings.TrimSuffix(strings.TrimPrefix(c.cloudFrontDomain, "https://"), "/")
+		if c.cloudFrontKeyPairID == "" {
+			return c, fmt.Errorf("CloudFront key pair ID is required")
+		}
+		key, err := parseCloudFrontKey(c.cloudFrontPrivateKeyPEM)
+		if err != nil {
+			return c, fmt.Errorf("invalid CloudFront private key: %w", err)
+		}
+		c.cloudFrontKey = key
+	}
+
+	if c.failoverBucket != "" {
+		secondary, err := loadConfig(append(append(slices.Clone(opts), withoutFailover()), c.failoverOptions...))
+		if err != nil {
+			return c, fmt.Errorf("invalid failover configuration: %w", err)
+		}
+		c.failover = &secondary
+	} else if c.dualWrite {
+		return c, fmt.Errorf("dual write requires a failover bucket, see WithFailover")
+	}
+
+	return c, nil
+}
+
+// encryption returns the server-side encryption fields of write requests.
+func (b *Bucket) encryption() (types.ServerSideEncryption, *string) {
+	if b.settings.kmsKeyID == "" {
+		return b.settings.sse, nil
+	}
+	return b.settings.sse, aws.String(b.settings.kmsKeyID)
+}
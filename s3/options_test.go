@@ -0,0 +1,66 @@
+package s3
+
+import (
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	c, err := loadConfig([]Option{WithEndpoint("localhost:9000"), WithDisableSSL()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.endpoint != "http://localhost:9000" {
+		t.Errorf("got endpoint %q", c.endpoint)
+	}
+	if c.partSize != 10*1024*1024 || c.concurrency != 5 {
+		t.Errorf("got part size %d and concurrency %d", c.partSize, c.concurrency)
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"part size", []Option{WithPartSize(1024)}},
+		{"concurrency", []Option{WithConcurrency(-1)}},
+		{"upload parts", []Option{WithMaxUploadParts(20000)}},
+		{"cache size", []Option{WithCache(t.TempDir(), 0)}},
+		{"retention mode", []Option{WithDefaultRetention("FOREVER", 1)}},
+		{"retention days", []Option{WithDefaultRetention(RetentionGovernance, 0)}},
+		{"directory bucket lock", []Option{WithDirectoryBucket("use1-az4"), WithObjectLock()}},
+		{"directory bucket defaults", []Option{WithDirectoryBucket("use1-az4"), WithSecureDefaults()}},
+		{"dual write", []Option{WithDualWrite()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadConfig(tt.opts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadConfigEnv(t *testing.T) {
+	t.Setenv("S3_PART_SIZE", "not a number")
+	if _, err := loadConfig(nil); err == nil {
+		t.Error("expected an error for an invalid S3_PART_SIZE")
+	}
+
+	t.Setenv("S3_PART_SIZE", "6291456")
+	t.Setenv("S3_CONCURRENCY", "2")
+	c, err := loadConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.partSize != 6291456 || c.concurrency != 2 {
+		t.Errorf("got part size %d and concurrency %d", c.partSize, c.concurrency)
+	}
+
+	// Options take precedence over the environment.
+	c, err = loadConfig([]Option{WithConcurrency(8)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.concurrency != 8 {
+		t.Errorf("got concurrency %d, want 8", c.concurrency)
+	}
+}
@@ -0,0 +1,184 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxPresignExpiry is the longest validity of a presigned URL signed with SigV4.
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// PresignGetOptions configures PresignGet.
+type PresignGetOptions struct {
+	// ContentDisposition overrides the Content-Disposition of the response, e.g.
+	// `attachment; filename="report.pdf"` to make browsers save the object under that name.
+	ContentDisposition string
+	// ContentType overrides the Content-Type of the response.
+	ContentType string
+}
+
+// PresignGet returns a URL that downloads the object stored under key without credentials until
+// expiry has passed, at most 7 days, so browsers can fetch objects directly from S3 instead of
+// through the application. The object does not have to exist yet.
+func PresignGet(ctx context.Context, key string, expiry time.Duration, opts PresignGetOptions) (string, error) {
+	return defaultBucket().PresignGet(ctx, key, expiry, opts)
+}
+
+// PresignGet returns a URL that downloads the object stored under key, see the package-level PresignGet.
+func (b *Bucket) PresignGet(ctx context.Context, key string, expiry time.Duration, opts PresignGetOptions) (url string, err error) {
+	ctx, op := b.startOperation(ctx, "PresignGet", attribute.String("aws.s3.key", key))
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return "", err
+	}
+	if expiry <= 0 || expiry > maxPresignExpiry {
+		return "", fmt.Errorf("expiry must be between 0 and %v, got %v", maxPresignExpiry, expiry)
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(key),
+	}
+	if opts.ContentDisposition != "" {
+		input.ResponseContentDisposition = aws.String(opts.ContentDisposition)
+	}
+	if opts.ContentType != "" {
+		input.ResponseContentType = aws.String(opts.ContentType)
+	}
+
+	req, err := b.presigner.PresignGetObject(ctx, input, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign download: %w", err)
+	}
+	return req.URL, nil
+}
+
+// PresignPutOptions configures PresignPut. Constraints are signed into the URL, so an upload
+// that does not send exactly these values as headers is rejected.
+type PresignPutOptions struct {
+	// ContentType is the required Content-Type of the upload, stored as the object's content type.
+	ContentType string
+	// ContentLength is the required size of the upload in bytes.
+	ContentLength int64
+}
+
+// PresignPut returns a URL that uploads to key with a PUT request without credentials until expiry
+// has passed, at most 7 days, so clients can send large files directly to S3 instead of through
+// the application. Uploads through the URL are limited to 5GB.
+func PresignPut(ctx context.Context, key string, expiry time.Duration, opts PresignPutOptions) (string, error) {
+	return defaultBucket().PresignPut(ctx, key, expiry, opts)
+}
+
+// PresignPut returns a URL that uploads to key, see the package-level PresignPut.
+func (b *Bucket) PresignPut(ctx context.Context, key string, expiry time.Duration, opts PresignPutOptions) (url string, err error) {
+	ctx, op := b.startOperation(ctx, "PresignPut", attribute.String("aws.s3.key", key))
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return "", err
+	}
+	if expiry <= 0 || expiry > maxPresignExpiry {
+		return "", fmt.Errorf("expiry must be between 0 and %v, got %v", maxPresignExpiry, expiry)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(key),
+	}
+	if opts.ContentType != "" {
+		input.ContentType = aws.String(opts.ContentType)
+	}
+	if opts.ContentLength > 0 {
+		input.ContentLength = aws.Int64(opts.ContentLength)
+	}
+
+	req, err := b.presigner.PresignPutObject(ctx, input, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign upload: %w", err)
+	}
+	return req.URL, nil
+}
+
+// PresignPostOptions configures PresignPost. Every constraint is part of the signed policy,
+// so S3 rejects uploads that do not satisfy it.
+type PresignPostOptions struct {
+	// KeyPrefix allows the form to upload to any key starting with it instead of only the
+	// given key, e.g. when the key field is filled in by the page.
+	KeyPrefix string
+	// MinSize and MaxSize limit the size of the upload in bytes, if MaxSize is positive.
+	MinSize int64
+	MaxSize int64
+	// ContentType is the required Content-Type of the upload. It is included in the form fields.
+	ContentType string
+	// ContentTypePrefix allows any Content-Type starting with it, e.g. "image/". The form has to
+	// include a Content-Type field then.
+	ContentTypePrefix string
+}
+
+// PresignedPost is the target and fields of an HTML form that uploads to S3, see PresignPost.
+type PresignedPost struct {
+	// URL is the form action, the form method is POST.
+	URL string
+	// Fields are the hidden form fields to send before the file field.
+	Fields map[string]string
+}
+
+// PresignPost returns an HTML form upload to key that is accepted until expiry has passed, at most
+// 7 days. Unlike PresignPut, it can limit the key to a prefix, the size to a range and the content
+// type to a prefix. key may contain ${filename}, which S3 replaces with the name of the uploaded file.
+func PresignPost(ctx context.Context, key string, expiry time.Duration, opts PresignPostOptions) (*PresignedPost, error) {
+	return defaultBucket().PresignPost(ctx, key, expiry, opts)
+}
+
+// PresignPost returns an HTML form upload to key, see the package-level PresignPost.
+func (b *Bucket) PresignPost(ctx context.Context, key string, expiry time.Duration, opts PresignPostOptions) (post *PresignedPost, err error) {
+	ctx, op := b.startOperation(ctx, "PresignPost", attribute.String("aws.s3.key", key))
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+	if expiry <= 0 || expiry > maxPresignExpiry {
+		return nil, fmt.Errorf("expiry must be between 0 and %v, got %v", maxPresignExpiry, expiry)
+	}
+	if !strings.HasPrefix(key, opts.KeyPrefix) {
+		return nil, fmt.Errorf("key %s does not start with %s", key, opts.KeyPrefix)
+	}
+
+	var conditions []any
+	if opts.KeyPrefix != "" {
+		conditions = append(conditions, []any{"starts-with", "$key", opts.KeyPrefix})
+	}
+	if opts.MaxSize > 0 {
+		conditions = append(conditions, []any{"content-length-range", opts.MinSize, opts.MaxSize})
+	}
+	if opts.ContentType != "" {
+		conditions = append(conditions, map[string]string{"Content-Type": opts.ContentType})
+	}
+	if opts.ContentTypePrefix != "" {
+		conditions = append(conditions, []any{"starts-with", "$Content-Type", opts.ContentTypePrefix})
+	}
+
+	req, err := b.presigner.PresignPostObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(key),
+	}, func(o *s3.PresignPostOptions) {
+		o.Expires = expiry
+		o.Conditions = conditions
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign form upload: %w", err)
+	}
+
+	if opts.ContentType != "" {
+		req.Values["Content-Type"] = opts.ContentType
+	}
+	return &PresignedPost{URL: req.URL, Fields: req.Values}, nil
+}
@@ -0,0 +1,71 @@
+package s3
+
+import (
+	"io"
+	"sync"
+)
+
+// progressChunk is the most bytes read at once when reporting progress, as the uploader reads
+// whole parts at once and would otherwise report every 10MB.
+const progressChunk = 256 * 1024
+
+// progressReader reports the bytes read through it to fn.
+type progressReader struct {
+	io.Reader
+	fn    func(transferred, total int64)
+	read  int64
+	total int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p[:min(len(p), progressChunk)])
+	if n > 0 {
+		r.read += int64(n)
+		r.fn(r.read, r.total)
+	}
+	return n, err
+}
+
+// progressWriterAt reports the bytes written through it to fn. Writes may come from several
+// goroutines, fn is called by one at a time.
+type progressWriterAt struct {
+	io.WriterAt
+	fn      func(transferred, total int64)
+	mu      sync.Mutex
+	written int64
+	total   int64
+}
+
+func (w *progressWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	n, err := w.WriterAt.WriteAt(p, off)
+	if n > 0 {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.written += int64(n)
+		w.fn(w.written, w.total)
+	}
+	return n, err
+}
+
+// readerSize returns the number of bytes left in r, or -1 if it cannot be determined without
+// consuming it.
+func readerSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }: // bytes.Reader, strings.Reader, bytes.Buffer
+		return int64(r.Len())
+	case io.Seeker:
+		current, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := r.Seek(current, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - current
+	}
+	return -1
+}
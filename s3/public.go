@@ -0,0 +1,265 @@
+package s3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// publicReadSid identifies the bucket policy statement managed by SetPublicPrefixes.
+const publicReadSid = "PublicRead"
+
+// WithPublicRead makes the uploaded object readable by anyone at its PublicURL through the
+// public-read ACL. Buckets created since April 2023 have ACLs disabled and reject it; make
+// prefixes public with WithPublicPrefixes instead.
+func WithPublicRead() UploadOption {
+	return func(o *uploadOptions) {
+		o.publicRead = true
+	}
+}
+
+// WithPublicPrefixes makes all objects under prefixes readable by anyone at their PublicURL, e.g. for
+// published exports and images, see SetPublicPrefixes. Init applies them every time; without this
+// option the bucket policy is left as it is.
+func WithPublicPrefixes(prefixes ...string) Option {
+	return func(c *config) {
+		c.publicPrefixes = prefixes
+	}
+}
+
+// SetPublicPrefixes makes the objects under prefixes, and only those, readable by anyone at their
+// PublicURL. It manages one statement of the bucket policy, keeping any others, and allows public
+// bucket policies in the block public access settings of the bucket. Public access blocked for the
+// whole account still applies. Setting no prefixes removes the statement.
+func SetPublicPrefixes(ctx context.Context, prefixes ...string) error {
+	return defaultBucket().SetPublicPrefixes(ctx, prefixes...)
+}
+
+// SetPublicPrefixes makes the objects under prefixes public, see the package-level SetPublicPrefixes.
+func (b *Bucket) SetPublicPrefixes(ctx context.Context, prefixes ...string) error {
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+
+	var statement map[string]any
+	if len(prefixes) > 0 {
+		if err := b.allowPublicPolicy(ctx); err != nil {
+			return err
+		}
+
+		resources := make([]string, len(prefixes))
+		for i, prefix := range prefixes {
+			resources[i] = "arn:aws:s3:::" + b.name + "/" + prefix + "*"
+		}
+		statement = map[string]any{
+			"Sid":       publicReadSid,
+			"Effect":    "Allow",
+			"Principal": "*",
+			"Action":    "s3:GetObject",
+			"Resource":  resources,
+		}
+	}
+	return b.setPolicyStatement(ctx, publicReadSid, statement)
+}
+
+// PublicURL returns the unsigned URL of the object stored under key, which anyone can download if
+// the object is public, see WithPublicRead and WithPublicPrefixes.
+func PublicURL(key string) string {
+	return defaultBucket().PublicURL(key)
+}
+
+// PublicURL returns the unsigned URL of the object stored under key, see the package-level PublicURL.
+func (b *Bucket) PublicURL(key string) string {
+	if b.client == nil {
+		return ""
+	}
+
+	o := b.client.Options()
+	endpoint := &url.URL{Scheme: "https", Host: "s3." + o.Region + ".amazonaws.com"}
+	// Directory buckets are served by the endpoint of their zone.
+	if zone := b.settings.directoryZone; zone != "" {
+		endpoint.Host = "s3express-" + zone + "." + o.Region + ".amazonaws.com"
+	}
+	if o.BaseEndpoint != nil {
+		if u, err := url.Parse(*o.BaseEndpoint); err == nil {
+			endpoint = u
+		}
+	}
+	if o.EndpointOptions.DisableHTTPS {
+		endpoint.Scheme = "http"
+	}
+	base := strings.TrimSuffix(endpoint.Path, "/") + "/"
+
+	// Names with dots do not match the certificate of virtual-hosted URLs.
+	if o.UsePathStyle || strings.Contains(b.name, ".") && endpoint.Scheme == "https" {
+		return endpoint.Scheme + "://" + endpoint.Host + base + b.name + "/" + escapeKey(key)
+	}
+	return endpoint.Scheme + "://" + b.name + "." + endpoint.Host + base + escapeKey(key)
+}
+
+// escapeKey URL-encodes key for use in a path, keeping its slashes.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		// + is escaped too, as some services decode it as a space.
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "+", "%2B")
+	}
+	return strings.Join(segments, "/")
+}
+
+// bucketPolicy returns the top-level fields of the bucket policy, or nil if the bucket has none.
+func (b *Bucket) bucketPolicy(ctx context.Context) (map[string]json.RawMessage, error) {
+	out, err := b.client.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{
+		Bucket: aws.String(b.name),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchBucketPolicy" {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var policy map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(aws.ToString(out.Policy)), &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse bucket policy: %w", err)
+	}
+	return policy, nil
+}
+
+// setPolicyStatement replaces the statement of the bucket policy with the given Sid by statement,
+// or removes it if statement is nil, keeping the others. A policy left without statements is
+// deleted, and an unchanged one is not written.
+func (b *Bucket) setPolicyStatement(ctx context.Context, sid string, statement map[string]any) error {
+	policy, err := b.bucketPolicy(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get bucket policy: %w", err)
+	}
+
+	var statements []json.RawMessage
+	if raw, ok := policy["Statement"]; ok {
+		if err := json.Unmarshal(raw, &statements); err != nil {
+			return fmt.Errorf("failed to parse bucket policy: %w", err)
+		}
+	}
+	current := slices.Clone(statements)
+	// The statement keeps its position, so statements managed this way do not swap places.
+	i := slices.IndexFunc(statements, func(s json.RawMessage) bool { return statementSid(s) == sid })
+	statements = withoutStatement(statements, sid)
+
+	if statement != nil {
+		encoded, err := json.Marshal(statement)
+		if err != nil {
+			return fmt.Errorf("failed to encode bucket policy: %w", err)
+		}
+		if i < 0 {
+			i = len(statements)
+		}
+		statements = slices.Insert(statements, i, encoded)
+	}
+	if sameStatements(current, statements) {
+		return nil
+	}
+
+	if len(statements) == 0 {
+		_, err := b.client.DeleteBucketPolicy(ctx, &s3.DeleteBucketPolicyInput{
+			Bucket: aws.String(b.name),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove bucket policy: %w", err)
+		}
+		return nil
+	}
+
+	if policy == nil {
+		policy = map[string]json.RawMessage{"Version": json.RawMessage(`"2012-10-17"`)}
+	}
+	if policy["Statement"], err = json.Marshal(statements); err != nil {
+		return fmt.Errorf("failed to encode bucket policy: %w", err)
+	}
+	document, err := json.Marshal(policy)
+	if err != nil {
+		return fmt.Errorf("failed to encode bucket policy: %w", err)
+	}
+	_, err = b.client.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{
+		Bucket: aws.String(b.name),
+		Policy: aws.String(string(document)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set bucket policy: %w", err)
+	}
+	return nil
+}
+
+// sameStatements reports whether a and b hold the same statements in the same order, regardless
+// of their formatting.
+func sameStatements(a, b []json.RawMessage) bool {
+	return slices.EqualFunc(a, b, func(x, y json.RawMessage) bool {
+		var vx, vy any
+		return json.Unmarshal(x, &vx) == nil && json.Unmarshal(y, &vy) == nil && reflect.DeepEqual(vx, vy)
+	})
+}
+
+// withoutStatement returns statements without those with the given Sid.
+func withoutStatement(statements []json.RawMessage, sid string) []json.RawMessage {
+	kept := statements[:0]
+	for _, statement := range statements {
+		if statementSid(statement) == sid {
+			continue
+		}
+		kept = append(kept, statement)
+	}
+	return kept
+}
+
+// statementSid returns the Sid of statement, or "" if it has none.
+func statementSid(statement json.RawMessage) string {
+	var s struct{ Sid string }
+	if json.Unmarshal(statement, &s) != nil {
+		return ""
+	}
+	return s.Sid
+}
+
+// allowPublicPolicy lifts the block public access settings of the bucket that reject public
+// bucket policies, leaving those about ACLs as they are.
+func (b *Bucket) allowPublicPolicy(ctx context.Context) error {
+	out, err := b.client.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{
+		Bucket: aws.String(b.name),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchPublicAccessBlockConfiguration" {
+			return nil
+		}
+		return fmt.Errorf("failed to get public access block: %w", err)
+	}
+
+	block := out.PublicAccessBlockConfiguration
+	if block == nil || (!aws.ToBool(block.BlockPublicPolicy) && !aws.ToBool(block.RestrictPublicBuckets)) {
+		return nil
+	}
+	_, err = b.client.PutPublicAccessBlock(ctx, &s3.PutPublicAccessBlockInput{
+		Bucket: aws.String(b.name),
+		PublicAccessBlockConfiguration: &types.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       block.BlockPublicAcls,
+			IgnorePublicAcls:      block.IgnorePublicAcls,
+			BlockPublicPolicy:     aws.Bool(false),
+			RestrictPublicBuckets: aws.Bool(false),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to allow public bucket policy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package s3
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// readerBlockSize is the unit in which ObjectReader fetches and caches content.
+	readerBlockSize = 1024 * 1024
+	// readerCacheBlocks is the number of most recently used blocks an ObjectReader keeps.
+	readerCacheBlocks = 16
+)
+
+// ObjectReader reads an object in place with ranged requests, see OpenReaderAt.
+type ObjectReader struct {
+	bucket *Bucket
+	ctx    context.Context
+	info   ObjectInfo
+
+	offset int64 // of Read and Seek
+
+	mu     sync.Mutex
+	blocks map[int64]*list.Element // block index → element of lru
+	lru    *list.List              // *cachedBlock, most recently used first
+}
+
+type cachedBlock struct {
+	index int64
+	data  []byte
+}
+
+// OpenReaderAt returns a reader of the object stored under key that fetches only the byte ranges
+// that are read, so formats with an index such as zip or Parquet can be read without downloading
+// the whole object, e.g. with zip.NewReader(r, r.Size()). The 16 most recently read 1MB blocks
+// are cached. Reads fail if the object is replaced meanwhile. Content is read as stored, without
+// checksum verification or gzip decompression. ctx applies to all reads. A missing object is
+// reported as a *NotFoundError.
+func OpenReaderAt(ctx context.Context, key string) (*ObjectReader, error) {
+	return defaultBucket().OpenReaderAt(ctx, key)
+}
+
+// OpenReaderAt returns a reader of the object stored under key, see the package-level OpenReaderAt.
+func (b *Bucket) OpenReaderAt(ctx context.Context, key string) (*ObjectReader, error) {
+	info, err := b.Stat(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectReader{
+		bucket: b,
+		ctx:    ctx,
+		info:   info,
+		blocks: map[int64]*list.Element{},
+		lru:    list.New(),
+	}, nil
+}
+
+// Size returns the size of the object.
+func (r *ObjectReader) Size() int64 {
+	return r.info.Size
+}
+
+// Info returns the metadata of the object.
+func (r *ObjectReader) Info() ObjectInfo {
+	return r.info
+}
+
+// ReadAt implements io.ReaderAt. It is safe for concurrent use.
+func (r *ObjectReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("negative offset %d", off)
+	}
+	if off >= r.info.Size {
+		return 0, io.EOF
+	}
+	end := min(off+int64(len(p)), r.info.Size)
+
+	n := 0
+	for off+int64(n) < end {
+		block := (off + int64(n)) / readerBlockSize
+		data, ok := r.cached(block)
+		if !ok {
+			// Fetch the run of uncached blocks in one request.
+			last := block
+			for (last+1)*readerBlockSize < end && !r.isCached(last+1) {
+				last++
+			}
+			var err error
+			if data, err = r.fetch(block, last); err != nil {
+				return n, err
+			}
+		}
+
+		// data holds the blocks from block on; copy the part overlapping [off+n, end).
+		start := off + int64(n) - block*readerBlockSize
+		n += copy(p[n:end-off], data[start:])
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Read implements io.Reader, reading from the offset set by Seek.
+func (r *ObjectReader) Read(p []byte) (int, error) {
+	n, err := r.ReadAt(p, r.offset)
+	r.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek implements io.Seeker for Read.
+func (r *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.info.Size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, errors.New("seek before start of object")
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// cached returns block from the cache.
+func (r *ObjectReader) cached(block int64) ([]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.blocks[block]
+	if !ok {
+		return nil, false
+	}
+	r.lru.MoveToFront(e)
+	return e.Value.(*cachedBlock).data, true
+}
+
+func (r *ObjectReader) isCached(block int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.blocks[block]
+	return ok
+}
+
+// fetch downloads blocks first to last, caches them and returns their content.
+func (r *ObjectReader) fetch(first, last int64) ([]byte, error) {
+	b := r.bucket
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	start := first * readerBlockSize
+	end := min((last+1)*readerBlockSize, r.info.Size)
+	out, err := b.client.GetObject(r.ctx, &s3.GetObjectInput{
+		Bucket:  aws.String(b.name),
+		Key:     aws.String(r.info.Key),
+		Range:   aws.String(fmt.Sprintf("bytes=%d-%d", start, end-1)),
+		IfMatch: aws.String(r.info.ETag),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", notFound(err, r.info.Key))
+	}
+	defer out.Body.Close()
+
+	data := make([]byte, end-start)
+	if _, err := io.ReadFull(out.Body, data); err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Earlier blocks of a long run would be evicted right away. The cached ones are copied,
+	// so they do not keep the whole run in memory.
+	for block := max(first, last-readerCacheBlocks+1); block <= last; block++ {
+		if _, ok := r.blocks[block]; ok {
+			continue
+		}
+		blockData := data[(block-first)*readerBlockSize : min((block-first+1)*readerBlockSize, int64(len(data)))]
+		r.blocks[block] = r.lru.PushFront(&cachedBlock{index: block, data: bytes.Clone(blockData)})
+		if r.lru.Len() > readerCacheBlocks {
+			oldest := r.lru.Remove(r.lru.Back()).(*cachedBlock)
+			delete(r.blocks, oldest.index)
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,25 @@
+package s3
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// WithRequesterPays sends every request of the bucket as agreeing to pay for it, which buckets with
+// Requester Pays enabled require, e.g. public datasets whose owner does not pay for downloads:
+//
+//	dataset, err := s3.OpenBucket("example-public-dataset", s3.WithRequesterPays(), s3.WithRegion("us-west-2"))
+//
+// Presigned URLs carry the agreement too, so whoever uses them is charged to the signing account.
+// Buckets without Requester Pays ignore it.
+func WithRequesterPays() Option {
+	return func(c *config) {
+		c.requesterPays = true
+	}
+}
+
+// withRequesterPays sets the header of WithRequesterPays on every request. It is signed with the
+// request, and moved to the query of presigned URLs.
+func withRequesterPays(o *s3.Options) {
+	o.APIOptions = append(o.APIOptions, smithyhttp.SetHeaderValue("x-amz-request-payer", "requester"))
+}
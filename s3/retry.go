@@ -0,0 +1,93 @@
+package s3
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+// WithRetry sets how often a failed request is attempted in total and the longest delay between
+// attempts, which grow exponentially with jitter up to it. Zero keeps the SDK setting, e.g. from
+// $AWS_MAX_ATTEMPTS and $AWS_RETRY_MODE, defaulting to 3 attempts and 20s.
+func WithRetry(maxAttempts int, maxBackoff time.Duration) Option {
+	return func(c *config) {
+		c.maxAttempts = maxAttempts
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithRetryable retries failed requests for which fn returns true, in addition to those the SDK
+// considers retryable such as throttling, 5xx responses and connection errors.
+func WithRetryable(fn func(err error) bool) Option {
+	return func(c *config) {
+		c.retryable = fn
+	}
+}
+
+// WithRetryHook calls fn before each retry with the number of the attempt that failed, starting
+// at 1, the delay before the next one and the error, e.g. to log or count retries.
+func WithRetryHook(fn func(attempt int, delay time.Duration, err error)) Option {
+	return func(c *config) {
+		c.retryHook = fn
+	}
+}
+
+// retryer returns the retryer of clients created from awsCfg with the retry options of c applied.
+func (c config) retryer(awsCfg aws.Config) func() aws.Retryer {
+	if c.maxAttempts == 0 && c.maxBackoff == 0 && c.retryable == nil && c.retryHook == nil {
+		return awsCfg.Retryer
+	}
+
+	return func() aws.Retryer {
+		var r aws.Retryer
+		switch {
+		case awsCfg.Retryer != nil:
+			r = awsCfg.Retryer()
+		case awsCfg.RetryMode == aws.RetryModeAdaptive:
+			r = retry.NewAdaptiveMode()
+		default:
+			r = retry.NewStandard()
+		}
+
+		if c.maxAttempts > 0 {
+			r = retry.AddWithMaxAttempts(r, c.maxAttempts)
+		} else if awsCfg.RetryMaxAttempts > 0 {
+			r = retry.AddWithMaxAttempts(r, awsCfg.RetryMaxAttempts)
+		}
+		if c.maxBackoff > 0 {
+			r = retry.AddWithMaxBackoffDelay(r, c.maxBackoff)
+		}
+		if c.retryable != nil || c.retryHook != nil {
+			// AddWithMaxAttempts turns retryers that predate RetryerV2 into one.
+			v2, ok := r.(aws.RetryerV2)
+			if !ok {
+				v2 = retry.AddWithMaxAttempts(r, r.MaxAttempts()).(aws.RetryerV2)
+			}
+			r = &hookRetryer{RetryerV2: v2, retryable: c.retryable, hook: c.retryHook}
+		}
+		return r
+	}
+}
+
+// hookRetryer adds WithRetryable and WithRetryHook to an SDK retryer.
+type hookRetryer struct {
+	aws.RetryerV2
+	retryable func(err error) bool
+	hook      func(attempt int, delay time.Duration, err error)
+}
+
+func (r *hookRetryer) IsErrorRetryable(err error) bool {
+	if r.retryable != nil && r.retryable(err) {
+		return true
+	}
+	return r.RetryerV2.IsErrorRetryable(err)
+}
+
+func (r *hookRetryer) RetryDelay(attempt int, err error) (time.Duration, error) {
+	delay, delayErr := r.RetryerV2.RetryDelay(attempt, err)
+	if delayErr == nil && r.hook != nil {
+		r.hook(attempt, delay, err)
+	}
+	return delay, delayErr
+}
@@ -6,21 +6,93 @@
 //
 // Key features:
 //   - Automatic bucket creation and management based on APP_NAME
+//   - Several buckets in one process via OpenBucket, with the same operations as methods of Bucket
+//   - Storage interface with a local directory implementation, selected by OpenStorage from $STORAGE_BACKEND
+//   - Azure Blob Storage implementation of Storage, for deployments outside AWS
+//   - In-memory Storage with assertion helpers for unit tests in package s3test
 //   - High-performance uploads using s3manager with automatic multipart upload
 //   - Parallel upload of large files for improved throughput
 //   - Memory-efficient streaming uploads without buffering entire files
-//   - Configurable part size (10MB) and concurrency (5 goroutines) for optimal performance
-//   - Automatic retry and error recovery for robust uploads
+//   - Uploading files by path via UploadFile and whole directory trees in parallel via UploadDir
+//   - Rsync-like Sync of a directory to a prefix, optionally deleting orphaned objects
+//   - Content type set with WithContentType or detected from the key extension or content
+//   - User metadata stored with WithMetadata and returned by Stat and Download
+//   - Object tags set on upload with WithTags or later with SetTags, read with GetTags
+//   - Upload and download progress reporting via WithProgress and WithDownloadProgress
+//   - Checksums computed on upload with WithChecksum and verified on download, as are MD5 ETags
+//   - Re-downloading files whose content arrived corrupted via WithRetryOnMismatch
+//   - SSE-S3 or SSE-KMS encryption of all writes and the bucket default via WithSSES3 and WithSSEKMS
+//   - KMS encryption contexts per upload, e.g. per tenant, verified on download via WithEncryptionContext
+//   - Transparent gzip compression with WithGzip, decompressed again by Download
+//   - JSON documents stored and decoded with UploadJSON and DownloadJSON[T]
+//   - Streaming downloads with size, content type and ETag via Download
+//   - Parallel ranged downloads of large objects to files via DownloadToFile
+//   - Downloading everything under a prefix into a directory via DownloadPrefix
+//   - Streaming everything under a prefix as a zip or tar archive via DownloadArchive
+//   - Expanding uploaded zip or tar archives into objects under a prefix via UploadArchive
+//   - Reading objects in place with ranged requests via OpenReaderAt, e.g. for zip or Parquet
+//   - Lifecycle rules that expire or transition objects by prefix via WithLifecycleRules
+//   - Temporary objects that expire after a given time via UploadTemp
+//   - Publishing through a temporary object for services without atomic uploads via UploadAtomic
+//   - Deduplicated, immutable content-addressed blobs via PutCAS
+//   - Public objects via WithPublicRead or WithPublicPrefixes, linked with PublicURL
+//   - TLS-only bucket policy and blocked public access for every new bucket via WithSecureDefaults
+//   - WORM protection with Object Lock retention and legal holds via WithObjectLock and WithRetention
+//   - Object event notifications to SQS queues and SNS topics via WithNotifications
+//   - Skipping uploads of content already stored under the key via WithSkipUnchanged
+//   - Deduplicating retried uploads under generated keys via WithIdempotencyKey
+//   - Create-once and optimistic concurrency uploads via WithIfNotExists and WithIfMatch
+//   - Conditional downloads that skip unchanged objects via DownloadIfModified
+//   - Read-through local disk cache of downloads, revalidated by ETag, via WithCache
+//   - Polling for new objects under a prefix with Watch, without notification infrastructure
+//   - Idempotent Delete, with missing objects reported as a typed *NotFoundError
+//   - Scratch prefixes for intermediate pipeline artifacts, deleted on Close, via NewScratch
+//   - Concurrent UploadBatch of many small objects with per-item errors
+//   - Batched DeleteMany with per-key errors for large cleanups
+//   - Recursive DeletePrefix with dry-run and versioned bucket support
+//   - Object count and bytes per top-level prefix, e.g. per tenant, via Usage
+//   - CSV or JSON inventories of objects for reconciliation via WriteInventory and UploadInventory
+//   - Listing by prefix as an iterator that fetches pages on demand via List
+//   - Paged listing with continuation tokens for APIs and UIs via ListPage
+//   - Object metadata without downloading via Stat and Exists
+//   - Server-side Copy, in parallel parts for objects over 5GB
+//   - Server-side Compose concatenating objects, e.g. log segments, without downloading them
+//   - Move that deletes the source only after verifying the copy
+//   - Presigned GET URLs for handing download links to browsers via PresignGet
+//   - Presigned PUT URLs with content type and size constraints via PresignPut
+//   - Presigned POST policies for HTML form uploads via PresignPost
+//   - Multi-region failover of Upload and Download to a secondary bucket, or dual writes, via WithFailover
+//   - CloudFront signed URLs and cookies for downloads through the CDN via CloudFrontURL and CloudFrontCookies
+//   - Parallel part reads from an io.ReaderAt of known size, e.g. a memory-mapped file, via UploadReaderAt
+//   - Configurable part size (10MB) and concurrency (5 goroutines) via WithPartSize and WithConcurrency
+//   - Default timeouts for uploads, downloads and listings without a deadline via WithTimeouts
+//   - Automatic retry and error recovery for robust uploads, tunable with WithRetry, WithRetryable and WithRetryHook
+//   - S3 Express One Zone directory buckets for single-digit millisecond latency via WithDirectoryBucket
 //   - Support for both LocalStack (development) and AWS S3 (production)
+//   - Reading requester-pays buckets such as public datasets via WithRequesterPays
+//   - S3-compatible services such as MinIO, Ceph and R2 via WithEndpoint, WithPathStyle and WithDisableSSL
+//   - Cross-account access by assuming an IAM role with WithAssumeRole, refreshing its credentials
+//   - Custom credential sources such as Vault via WithCredentials
+//   - Server-side filtering of CSV and JSON objects with SQL via SelectCSV and SelectJSON
+//   - OpenTelemetry spans for every operation, see WithTracerProvider
+//   - Operation hook with key, duration, bytes and error for structured logging, see WithOperationHook
+//   - Programmatic configuration with WithBucket, WithEndpoint, WithRegion and WithConfig instead of environment variables
 //   - Context-aware operations with proper error handling
 //   - Cleanup function pattern consistent with other packages
+//   - Lazy initialization from the environment on first use, and re-Init that is safe while operations run
 //
 // Environment variables:
-//   - APP_NAME: Required, used as bucket name
-//   - AWS_ENDPOINT_URL: Optional, for LocalStack or custom S3-compatible services
-//   - AWS_REGION: Optional, defaults to us-east-1
+//   - APP_NAME: Required unless WithBucket is given, used as bucket name
+//   - AWS_ENDPOINT_URL: Optional, for LocalStack or custom S3-compatible services, see WithEndpoint
+//   - AWS_REGION: Optional, defaults to us-east-1, see WithRegion
 //   - AWS_ACCESS_KEY_ID: AWS credentials
 //   - AWS_SECRET_ACCESS_KEY: AWS credentials
+//   - STORAGE_BACKEND, STORAGE_DIR: Optional, storage opened by OpenStorage, "s3" (default), "fs" in a directory or "azure"
+//   - AZURE_STORAGE_CONNECTION_STRING, AZURE_STORAGE_CONTAINER: Required for STORAGE_BACKEND "azure", see OpenAzure
+//   - S3_PATH_STYLE, S3_DISABLE_SSL: Optional, for S3-compatible services, see WithPathStyle and WithDisableSSL
+//   - S3_ROLE_ARN, S3_ROLE_EXTERNAL_ID: Optional, role to assume, see WithAssumeRole
+//   - CLOUDFRONT_DOMAIN, CLOUDFRONT_KEY_PAIR_ID, CLOUDFRONT_PRIVATE_KEY: Optional, see WithCloudFront
+//   - S3_PART_SIZE, S3_CONCURRENCY, S3_MAX_UPLOAD_PARTS: Optional, see WithPartSize, WithConcurrency and WithMaxUploadParts
 //
 // Example usage:
 //
@@ -48,81 +120,340 @@ package s3
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"sync"
+	"sync/atomic"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-var (
+// Bucket is a handle of a bucket returned by OpenBucket. The package-level functions operate on the
+// bucket opened by Init, the methods of the same name operate on a specific bucket.
+type Bucket struct {
+	name       string
 	client     *s3.Client
 	uploader   *manager.Uploader
-	bucketName string
+	downloader *manager.Downloader
+	presigner  *s3.PresignClient
+	settings   config
+
+	// failover is the secondary bucket set with WithFailover, nil without one.
+	failover *Bucket
+	// cache is the cache of downloads set with WithCache, nil without one.
+	cache *diskCache
+
+	// openErr is why the default bucket could not be opened from the environment, see defaultBucket.
+	openErr error
+
+	// ttlRules holds the lifetimes in days known to have a lifecycle rule, see UploadTemp.
+	ttlRules   map[int32]bool
+	ttlRulesMu sync.Mutex
+}
+
+var (
+	// current is the bucket the package-level functions operate on, nil until Init or first use.
+	current atomic.Pointer[Bucket]
+	// currentMu serializes opening current from the environment.
+	currentMu sync.Mutex
 )
 
-func Init() (func(), error) {
-	appName := os.Getenv("APP_NAME")
-	if appName == "" {
-		return nil, fmt.Errorf("APP_NAME environment variable is required")
+// Init opens the bucket $APP_NAME, or the one given with WithBucket, for the package-level functions,
+// creating it if missing. Without Init, it is opened from the environment on their first use.
+// Calling Init again replaces the bucket; operations already running complete on the previous one.
+// Returns a function that releases the bucket, after which the next use opens it again.
+func Init(opts ...Option) (func(), error) {
+	b, err := openDefault(opts)
+	if err != nil {
+		return nil, err
+	}
+	current.Store(b)
+
+	closeFunc := func() {
+		// A later Init has replaced b and is not affected.
+		current.CompareAndSwap(b, nil)
+	}
+
+	return closeFunc, nil
+}
+
+// openDefault opens the bucket given with WithBucket or $APP_NAME.
+func openDefault(opts []Option) (*Bucket, error) {
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	name := cfg.bucket
+	if name == "" {
+		name = os.Getenv("APP_NAME")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("APP_NAME environment variable or WithBucket is required")
+	}
+
+	return openBucket(name, cfg)
+}
+
+// defaultBucket returns the bucket opened by Init, opening it from the environment if Init was not
+// called. If that fails, the returned bucket reports the error from every operation, and the next
+// call tries again.
+func defaultBucket() *Bucket {
+	if b := current.Load(); b != nil {
+		return b
+	}
+
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	if b := current.Load(); b != nil {
+		return b
+	}
+	b, err := openDefault(nil)
+	if err != nil {
+		return &Bucket{openErr: err}
+	}
+	current.Store(b)
+	return b
+}
+
+// checkOpen returns an error if the bucket cannot be used.
+func (b *Bucket) checkOpen() error {
+	if b.client != nil {
+		return nil
+	}
+	if b.openErr != nil {
+		return fmt.Errorf("S3 client not initialized, call Init() first: %w", b.openErr)
 	}
+	return fmt.Errorf("S3 client not initialized, call Init() first")
+}
 
-	bucketName = appName
+// OpenBucket opens the bucket name, configured like Init and created if missing, so one process
+// can use several buckets, e.g. for uploads and exports:
+//
+//	exports, err := s3.OpenBucket("myapp-exports")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	err = exports.Upload(ctx, "reports/2024.csv", file)
+func OpenBucket(name string, opts ...Option) (*Bucket, error) {
+	cfg, err := loadConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	return openBucket(name, cfg)
+}
+
+// openBucket opens the bucket name and its failover bucket, if any, creating and setting them up.
+// With a failover bucket, either of them being set up is enough, so an outage of one region does
+// not keep the process from starting.
+func openBucket(name string, cfg config) (*Bucket, error) {
+	b, err := newBucket(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	ensureErr := b.ensureBucket(context.TODO())
+	if ensureErr != nil {
+		ensureErr = fmt.Errorf("failed to ensure bucket exists: %w", ensureErr)
+	}
+	if cfg.failover == nil {
+		if ensureErr != nil {
+			return nil, ensureErr
+		}
+		return b, nil
+	}
 
-	cfg, err := config.LoadDefaultConfig(context.TODO())
+	failover, err := newBucket(cfg.failoverBucket, *cfg.failover)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		return nil, fmt.Errorf("failed to open failover bucket %s: %w", cfg.failoverBucket, err)
+	}
+	if err := failover.ensureBucket(context.TODO()); err != nil && ensureErr != nil {
+		return nil, fmt.Errorf("%w; failover bucket %s: %w", ensureErr, cfg.failoverBucket, err)
+	}
+	b.failover = failover
+	return b, nil
+}
+
+// newBucket returns the bucket name with its clients, without checking that it exists.
+func newBucket(name string, cfg config) (*Bucket, error) {
+	if cfg.directoryZone != "" {
+		name = directoryBucketName(name, cfg.directoryZone)
 	}
 
-	client = s3.NewFromConfig(cfg, func(o *s3.Options) {
-		if os.Getenv("AWS_ENDPOINT_URL") != "" {
+	var awsCfg aws.Config
+	if cfg.awsConfig != nil {
+		awsCfg = cfg.awsConfig.Copy()
+	} else {
+		var err error
+		awsCfg, err = awsconfig.LoadDefaultConfig(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		}
+	}
+	if cfg.region != "" {
+		awsCfg.Region = cfg.region
+	}
+
+	awsCfg.Retryer = cfg.retryer(awsCfg)
+	if cfg.maxAttempts > 0 {
+		// The client would wrap the retryer with the attempts of $AWS_MAX_ATTEMPTS again.
+		awsCfg.RetryMaxAttempts = 0
+	}
+	awsCfg.Credentials = cfg.credentials(awsCfg)
+
+	b := &Bucket{name: name, settings: cfg}
+	b.client = s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.endpoint)
+		}
+		if cfg.pathStyle != nil {
+			o.UsePathStyle = *cfg.pathStyle
+		} else if cfg.endpoint != "" || os.Getenv("AWS_ENDPOINT_URL") != "" {
 			o.UsePathStyle = true
 		}
+		o.EndpointOptions.DisableHTTPS = cfg.disableSSL
+		if cfg.requesterPays {
+			withRequesterPays(o)
+		}
 	})
 
-	uploader = manager.NewUploader(client, func(u *manager.Uploader) {
-		u.PartSize = 10 * 1024 * 1024 // 10MB per part
-		u.Concurrency = 5             // 5 concurrent uploads
+	b.uploader = manager.NewUploader(b.client, func(u *manager.Uploader) {
+		u.PartSize = cfg.partSize
+		u.Concurrency = cfg.concurrency
+		u.MaxUploadParts = cfg.maxUploadParts
 	})
 
-	if err := ensureBucket(context.TODO()); err != nil {
-		return nil, fmt.Errorf("failed to ensure bucket exists: %w", err)
-	}
+	b.downloader = manager.NewDownloader(b.client, func(d *manager.Downloader) {
+		d.PartSize = cfg.partSize
+		d.Concurrency = cfg.concurrency
+	})
 
-	closeFunc := func() {
-		client = nil
-		uploader = nil
-		bucketName = ""
+	b.presigner = s3.NewPresignClient(b.client)
+
+	if cfg.cacheDir != "" {
+		cache, err := newDiskCache(cfg.cacheDir, cfg.cacheMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		b.cache = cache
 	}
 
-	return closeFunc, nil
+	return b, nil
+}
+
+// Name returns the name of the bucket.
+func (b *Bucket) Name() string {
+	return b.name
 }
 
-func Upload(ctx context.Context, key string, reader io.Reader) error {
-	if uploader == nil {
-		return fmt.Errorf("S3 uploader not initialized, call Init() first")
+func Upload(ctx context.Context, key string, reader io.Reader, opts ...UploadOption) error {
+	return defaultBucket().Upload(ctx, key, reader, opts...)
+}
+
+// Upload uploads the content of reader under key, see the package-level Upload.
+func (b *Bucket) Upload(ctx context.Context, key string, reader io.Reader, opts ...UploadOption) error {
+	if b.failover != nil {
+		return b.uploadWithFailover(ctx, key, reader, opts)
+	}
+	return b.upload(ctx, key, reader, opts...)
+}
+
+// upload uploads the content of reader under key to this bucket only.
+func (b *Bucket) upload(ctx context.Context, key string, reader io.Reader, opts ...UploadOption) (err error) {
+	ctx, op := b.startOperation(ctx, "Upload", attribute.String("aws.s3.key", key))
+	defer func() { op.end(err) }()
+	ctx, cancel := withTimeout(ctx, b.settings.timeouts.Upload)
+	defer cancel()
+
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+
+	var o uploadOptions
+	for _, opt := range opts {
+		opt(&o)
 	}
+	if o.skipSame {
+		sum, body, done, err := hashContent(reader)
+		if err != nil {
+			return fmt.Errorf("failed to upload object: %w", err)
+		}
+		defer done()
+		digest := hex.EncodeToString(sum)
 
-	_, err := uploader.Upload(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(bucketName),
+		stored, err := b.Stat(ctx, key)
+		var notFound *NotFoundError
+		if err != nil && !errors.As(err, &notFound) {
+			return fmt.Errorf("failed to upload object: %w", err)
+		}
+		if err == nil && stored.Metadata[contentHashMetadata] == digest {
+			op.span.SetAttributes(attribute.Bool("aws.s3.unchanged", true))
+			return nil
+		}
+		reader = body
+		opts = append(opts, WithMetadata(map[string]string{contentHashMetadata: digest}))
+	}
+	if o.idempotencyKey != "" {
+		if err := b.claimIdempotencyKey(ctx, o.idempotencyKey, key); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				b.releaseIdempotencyKey(o.idempotencyKey)
+			}
+		}()
+		opts = append(opts, WithMetadata(map[string]string{idempotencyKeyMetadata: o.idempotencyKey}))
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(b.name),
 		Key:    aws.String(key),
 		Body:   reader,
-	})
-	if err != nil {
+	}
+	input.ServerSideEncryption, input.SSEKMSKeyId = b.encryption()
+	if err := applyUploadOptions(input, opts); err != nil {
 		return fmt.Errorf("failed to upload object: %w", err)
 	}
+	if r, ok := input.Body.(compressingReader); ok {
+		defer r.Close()
+	}
+
+	// Readers of unknown size are not read in parallel, so counting them costs nothing.
+	size := readerSize(input.Body)
+	var counter *countingReader
+	if size < 0 {
+		counter = &countingReader{Reader: input.Body}
+		input.Body = counter
+	}
 
+	out, err := b.uploader.Upload(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %w", preconditionFailed(err, key))
+	}
+
+	if counter != nil {
+		size = counter.n
+	}
+	op.setBytes(size)
+	op.span.SetAttributes(attribute.Int("aws.s3.parts", max(len(out.CompletedParts), 1)))
 	return nil
 }
 
-func ensureBucket(ctx context.Context) error {
-	_, err := client.HeadBucket(ctx, &s3.HeadBucketInput{
-		Bucket: aws.String(bucketName),
+// ensureBucket creates the bucket if missing and applies the configured bucket settings, reported
+// as the operation EnsureBucket.
+func (b *Bucket) ensureBucket(ctx context.Context) (err error) {
+	ctx, op := b.startOperation(ctx, "EnsureBucket")
+	defer func() { op.end(err) }()
+
+	_, err = b.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(b.name),
 	})
 	if err != nil {
 		var notFound *types.NotFound
@@ -131,14 +462,73 @@ func ensureBucket(ctx context.Context) error {
 			return fmt.Errorf("failed to check if bucket exists: %w", err)
 		}
 
-		_, err = client.CreateBucket(ctx, &s3.CreateBucketInput{
-			Bucket: aws.String(bucketName),
-		})
+		input := &s3.CreateBucketInput{
+			Bucket: aws.String(b.name),
+		}
+		if b.settings.objectLock {
+			input.ObjectLockEnabledForBucket = aws.Bool(true)
+		}
+		if b.settings.directoryZone != "" {
+			input.CreateBucketConfiguration = directoryBucketConfiguration(b.settings.directoryZone)
+		} else if region := b.client.Options().Region; region != "" && region != "us-east-1" {
+			// Buckets outside us-east-1, e.g. failover buckets, have to name their region.
+			input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+				LocationConstraint: types.BucketLocationConstraint(region),
+			}
+		}
+		_, err = b.client.CreateBucket(ctx, input)
 		if err != nil {
 			return fmt.Errorf("failed to create bucket: %w", err)
 		}
 	}
 
+	if b.settings.objectLock {
+		if err := b.ensureObjectLock(ctx); err != nil {
+			return err
+		}
+	}
+
+	if b.settings.sse != "" {
+		sse, kmsKeyID := b.encryption()
+		_, err = b.client.PutBucketEncryption(ctx, &s3.PutBucketEncryptionInput{
+			Bucket: aws.String(b.name),
+			ServerSideEncryptionConfiguration: &types.ServerSideEncryptionConfiguration{
+				Rules: []types.ServerSideEncryptionRule{{
+					ApplyServerSideEncryptionByDefault: &types.ServerSideEncryptionByDefault{
+						SSEAlgorithm:   sse,
+						KMSMasterKeyID: kmsKeyID,
+					},
+				}},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set default bucket encryption: %w", err)
+		}
+	}
+
+	if len(b.settings.lifecycleRules) > 0 {
+		if err := b.SetLifecycleRules(ctx, b.settings.lifecycleRules...); err != nil {
+			return err
+		}
+	}
+
+	if b.settings.secureDefaults {
+		if err := b.ensureSecureDefaults(ctx); err != nil {
+			return err
+		}
+	}
+
+	if len(b.settings.publicPrefixes) > 0 {
+		if err := b.SetPublicPrefixes(ctx, b.settings.publicPrefixes...); err != nil {
+			return err
+		}
+	}
+
+	if len(b.settings.notifications) > 0 {
+		if err := b.SetNotifications(ctx, b.settings.notifications...); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
-
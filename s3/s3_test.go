@@ -0,0 +1,46 @@
+package s3_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michaldziurowski/one/s3"
+)
+
+// openTestBucket opens the bucket $S3_TEST_BUCKET, by default one-s3-test, of the S3-compatible
+// service at $S3_TEST_ENDPOINT, e.g. MinIO or LocalStack, with credentials from the AWS_ environment
+// variables. It returns a prefix unique to the test, deleted when the test ends. The test is skipped
+// without S3_TEST_ENDPOINT:
+//
+//	docker run -d -p 9000:9000 minio/minio server /data
+//	S3_TEST_ENDPOINT=http://localhost:9000 AWS_ACCESS_KEY_ID=minioadmin AWS_SECRET_ACCESS_KEY=minioadmin go test ./...
+func openTestBucket(t *testing.T, opts ...s3.Option) (*s3.Bucket, string) {
+	t.Helper()
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+	name := os.Getenv("S3_TEST_BUCKET")
+	if name == "" {
+		name = "one-s3-test"
+	}
+	if os.Getenv("AWS_REGION") == "" {
+		opts = append([]s3.Option{s3.WithRegion("us-east-1")}, opts...)
+	}
+
+	b, err := s3.OpenBucket(name, append([]s3.Option{s3.WithEndpoint(endpoint), s3.WithPathStyle(true)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := fmt.Sprintf("%s-%d/", strings.ReplaceAll(t.Name(), "/", "-"), time.Now().UnixNano())
+	t.Cleanup(func() {
+		if _, err := b.DeletePrefix(context.Background(), prefix, s3.DeletePrefixOptions{}); err != nil {
+			t.Errorf("failed to clean up %s: %v", prefix, err)
+		}
+	})
+	return b, prefix
+}
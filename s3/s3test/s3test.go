@@ -0,0 +1,180 @@
+// Package s3test provides an in-memory s3.Storage for unit tests, so code written against the
+// Storage interface can be tested without S3 or LocalStack, and helpers to assert on what it stored:
+//
+//	store := s3test.New()
+//	err := export(ctx, store) // func export(ctx context.Context, st s3.Storage) error
+//	store.AssertObject(t, "exports/report.csv", []byte("id,name\n"))
+package s3test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"mime"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/michaldziurowski/one/s3"
+)
+
+// Memory is an s3.Storage that keeps objects in memory. Like S3, it lists keys in byte order and
+// reports missing objects as a *s3.NotFoundError. Only the content is stored: ContentType is
+// derived from the extension of the key and upload options have no effect. It is safe for
+// concurrent use.
+type Memory struct {
+	mu      sync.Mutex
+	objects map[string]object
+}
+
+type object struct {
+	data []byte
+	info s3.ObjectInfo
+}
+
+var _ s3.Storage = (*Memory)(nil)
+
+// New returns an empty Memory.
+func New() *Memory {
+	return &Memory{objects: map[string]object{}}
+}
+
+// Upload stores the content of reader under key.
+func (m *Memory) Upload(ctx context.Context, key string, reader io.Reader, opts ...s3.UploadOption) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	m.Put(key, data)
+	return nil
+}
+
+// Download returns the content of the object stored under key.
+func (m *Memory) Download(ctx context.Context, key string, opts ...s3.DownloadOption) (*s3.Object, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to download object: %w", err)
+	}
+
+	m.mu.Lock()
+	obj, ok := m.objects[key]
+	m.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("failed to download object: %w", &s3.NotFoundError{Key: key})
+	}
+	return &s3.Object{ReadCloser: io.NopCloser(bytes.NewReader(obj.data)), ObjectInfo: obj.info}, nil
+}
+
+// List returns the objects whose key starts with prefix, in key order, as stored when the
+// iteration started.
+func (m *Memory) List(ctx context.Context, prefix string) iter.Seq2[s3.ObjectInfo, error] {
+	return func(yield func(s3.ObjectInfo, error) bool) {
+		if err := ctx.Err(); err != nil {
+			yield(s3.ObjectInfo{}, fmt.Errorf("failed to list objects: %w", err))
+			return
+		}
+
+		m.mu.Lock()
+		var objects []s3.ObjectInfo
+		for key, obj := range m.objects {
+			if strings.HasPrefix(key, prefix) {
+				objects = append(objects, obj.info)
+			}
+		}
+		m.mu.Unlock()
+
+		slices.SortFunc(objects, func(a, b s3.ObjectInfo) int { return strings.Compare(a.Key, b.Key) })
+		for _, obj := range objects {
+			if !yield(obj, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Delete removes the object stored under key. Deleting an object that does not exist succeeds.
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+// Put stores data under key, e.g. to set up the objects a test reads. data must not be modified afterwards.
+func (m *Memory) Put(key string, data []byte) {
+	sum := md5.Sum(data)
+	obj := object{
+		data: data,
+		info: s3.ObjectInfo{
+			Key:          key,
+			Size:         int64(len(data)),
+			ContentType:  mime.TypeByExtension(path.Ext(key)),
+			ETag:         `"` + hex.EncodeToString(sum[:]) + `"`,
+			LastModified: time.Now(),
+		},
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = obj
+}
+
+// Get returns the content of the object stored under key and whether there is one.
+// The content must not be modified.
+func (m *Memory) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj, ok := m.objects[key]
+	return obj.data, ok
+}
+
+// Keys returns the keys of all stored objects in key order.
+func (m *Memory) Keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Sorted(maps.Keys(m.objects))
+}
+
+// AssertObject fails t unless want is stored under key.
+func (m *Memory) AssertObject(t testing.TB, key string, want []byte) {
+	t.Helper()
+	got, ok := m.Get(key)
+	if !ok {
+		t.Errorf("object %s not found, stored keys are %q", key, m.Keys())
+		return
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("object %s is %q, want %q", key, got, want)
+	}
+}
+
+// AssertNoObject fails t if an object is stored under key.
+func (m *Memory) AssertNoObject(t testing.TB, key string) {
+	t.Helper()
+	if _, ok := m.Get(key); ok {
+		t.Errorf("object %s exists, want none", key)
+	}
+}
+
+// AssertKeys fails t unless exactly the objects with keys are stored, in any order.
+func (m *Memory) AssertKeys(t testing.TB, keys ...string) {
+	t.Helper()
+	want := slices.Sorted(slices.Values(keys))
+	if got := m.Keys(); !slices.Equal(got, want) {
+		t.Errorf("stored keys are %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package s3
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// scratchDays is the lifetime in days after which the lifecycle rule removes a scratch prefix that
+// was not closed.
+const scratchDays = 7
+
+// Scratch is a unique prefix for the intermediate objects of one run of a pipeline, see NewScratch.
+type Scratch struct {
+	bucket *Bucket
+	prefix string
+}
+
+// NewScratch returns a Scratch whose objects are stored under a unique prefix and deleted by Close,
+// for intermediate artifacts passed between the steps of a pipeline:
+//
+//	scratch, err := s3.NewScratch(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer scratch.Close(ctx)
+//	err = scratch.Upload(ctx, "extracted.csv", extracted)
+//	// ...
+//	obj, err := scratch.Download(ctx, "extracted.csv")
+//
+// The prefix is under ttl/7d/scratch/, see UploadTemp, so the objects of a process that crashed
+// before Close expire a week later.
+func NewScratch(ctx context.Context) (*Scratch, error) {
+	return defaultBucket().NewScratch(ctx)
+}
+
+// NewScratch returns a Scratch in the bucket, see the package-level NewScratch.
+func (b *Bucket) NewScratch(ctx context.Context) (*Scratch, error) {
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+	if err := b.ensureTTLRule(ctx, scratchDays); err != nil {
+		return nil, err
+	}
+	return &Scratch{
+		bucket: b,
+		prefix: fmt.Sprintf("%s%dd/scratch/%s/", ttlPrefix, scratchDays, rand.Text()),
+	}, nil
+}
+
+// Prefix returns the prefix under which the objects of s are stored.
+func (s *Scratch) Prefix() string {
+	return s.prefix
+}
+
+// Key returns the key under which the object name of s is stored, for use with the other operations
+// of the bucket.
+func (s *Scratch) Key(name string) string {
+	return s.prefix + name
+}
+
+// Upload uploads the content of reader as the object name of s, like Upload.
+func (s *Scratch) Upload(ctx context.Context, name string, reader io.Reader, opts ...UploadOption) error {
+	return s.bucket.Upload(ctx, s.Key(name), reader, opts...)
+}
+
+// Download returns the content of the object name of s, like Download.
+func (s *Scratch) Download(ctx context.Context, name string, opts ...DownloadOption) (*Object, error) {
+	return s.bucket.Download(ctx, s.Key(name), opts...)
+}
+
+// Close deletes every object of s. It also runs once ctx is cancelled, e.g. after a pipeline was
+// aborted, as cleaning up is most needed then.
+func (s *Scratch) Close(ctx context.Context) error {
+	_, err := s.bucket.DeletePrefix(context.WithoutCancel(ctx), s.prefix, DeletePrefixOptions{})
+	return err
+}
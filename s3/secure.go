@@ -0,0 +1,73 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// denyInsecureTransportSid identifies the bucket policy statement managed by WithSecureDefaults.
+const denyInsecureTransportSid = "DenyInsecureTransport"
+
+// WithSecureDefaults makes Init apply a secure baseline to the bucket every time: its policy denies
+// all requests not made over TLS, and its block public access settings reject public ACLs, so
+// WithPublicRead fails, and public bucket policies unless WithPublicPrefixes is given. Other
+// statements of the bucket policy are kept. AWS rejects every request of a client configured
+// WithDisableSSL once it is applied.
+func WithSecureDefaults() Option {
+	return func(c *config) {
+		c.secureDefaults = true
+	}
+}
+
+// ensureSecureDefaults blocks public access to the bucket and denies requests without TLS in its
+// policy, unless it already does.
+func (b *Bucket) ensureSecureDefaults(ctx context.Context) error {
+	// Public prefixes need public bucket policies, which SetPublicPrefixes allows anyway.
+	blockPolicy := len(b.settings.publicPrefixes) == 0
+	block := &types.PublicAccessBlockConfiguration{
+		BlockPublicAcls:       aws.Bool(true),
+		IgnorePublicAcls:      aws.Bool(true),
+		BlockPublicPolicy:     aws.Bool(blockPolicy),
+		RestrictPublicBuckets: aws.Bool(blockPolicy),
+	}
+
+	out, err := b.client.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{
+		Bucket: aws.String(b.name),
+	})
+	var apiErr smithy.APIError
+	if err != nil && !(errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchPublicAccessBlockConfiguration") {
+		return fmt.Errorf("failed to get public access block: %w", err)
+	}
+	if err != nil || !samePublicAccessBlock(out.PublicAccessBlockConfiguration, block) {
+		_, err = b.client.PutPublicAccessBlock(ctx, &s3.PutPublicAccessBlockInput{
+			Bucket:                         aws.String(b.name),
+			PublicAccessBlockConfiguration: block,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to block public access: %w", err)
+		}
+	}
+
+	return b.setPolicyStatement(ctx, denyInsecureTransportSid, map[string]any{
+		"Sid":       denyInsecureTransportSid,
+		"Effect":    "Deny",
+		"Principal": "*",
+		"Action":    "s3:*",
+		"Resource":  []string{"arn:aws:s3:::" + b.name, "arn:aws:s3:::" + b.name + "/*"},
+		"Condition": map[string]any{"Bool": map[string]string{"aws:SecureTransport": "false"}},
+	})
+}
+
+func samePublicAccessBlock(a, b *types.PublicAccessBlockConfiguration) bool {
+	return a != nil &&
+		aws.ToBool(a.BlockPublicAcls) == aws.ToBool(b.BlockPublicAcls) &&
+		aws.ToBool(a.IgnorePublicAcls) == aws.ToBool(b.IgnorePublicAcls) &&
+		aws.ToBool(a.BlockPublicPolicy) == aws.ToBool(b.BlockPublicPolicy) &&
+		aws.ToBool(a.RestrictPublicBuckets) == aws.ToBool(b.RestrictPublicBuckets)
+}
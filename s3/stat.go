@@ -0,0 +1,59 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Stat returns the metadata of the object stored under key without downloading it.
+// A missing object is reported as a *NotFoundError.
+func Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	return defaultBucket().Stat(ctx, key)
+}
+
+// Stat returns the metadata of the object stored under key, see the package-level Stat.
+func (b *Bucket) Stat(ctx context.Context, key string) (_ ObjectInfo, err error) {
+	ctx, op := b.startOperation(ctx, "Stat", attribute.String("aws.s3.key", key))
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	out, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return ObjectInfo{}, fmt.Errorf("failed to stat object: %w", notFound(err, key))
+	}
+
+	return ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		ContentType:  aws.ToString(out.ContentType),
+		ETag:         aws.ToString(out.ETag),
+		LastModified: aws.ToTime(out.LastModified),
+		Metadata:     out.Metadata,
+	}, nil
+}
+
+// Exists reports whether an object is stored under key.
+func Exists(ctx context.Context, key string) (bool, error) {
+	return defaultBucket().Exists(ctx, key)
+}
+
+// Exists reports whether an object is stored under key.
+func (b *Bucket) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := b.Stat(ctx, key)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
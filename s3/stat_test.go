@@ -0,0 +1,58 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/michaldziurowski/one/s3"
+)
+
+func TestStat(t *testing.T) {
+	ctx := context.Background()
+	b, prefix := openTestBucket(t)
+	start := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		key         string
+		content     string
+		opts        []s3.UploadOption
+		contentType string
+		metadata    map[string]string
+	}{
+		{key: "report.csv", content: "a,b\n1,2\n", opts: []s3.UploadOption{s3.WithMetadata(map[string]string{"owner": "test"})}, contentType: "text/csv", metadata: map[string]string{"owner": "test"}},
+		{key: "data", content: "{}", opts: []s3.UploadOption{s3.WithContentType("application/json")}, contentType: "application/json"},
+		{key: "empty.txt", content: "", contentType: "text/plain"},
+	}
+	for _, tt := range tests {
+		if err := b.Upload(ctx, prefix+tt.key, strings.NewReader(tt.content), tt.opts...); err != nil {
+			t.Fatal(err)
+		}
+		info, err := b.Stat(ctx, prefix+tt.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Key != prefix+tt.key || info.Size != int64(len(tt.content)) || !strings.HasPrefix(info.ContentType, tt.contentType) ||
+			info.ETag == "" || info.LastModified.Before(start) {
+			t.Errorf("Stat(%s) = %+v", tt.key, info)
+		}
+		for k, v := range tt.metadata {
+			if info.Metadata[k] != v {
+				t.Errorf("Stat(%s) has metadata %v, want %v", tt.key, info.Metadata, tt.metadata)
+			}
+		}
+		if exists, err := b.Exists(ctx, prefix+tt.key); err != nil || !exists {
+			t.Errorf("Exists(%s) = %v, %v, want true", tt.key, exists, err)
+		}
+	}
+
+	var notFound *s3.NotFoundError
+	if _, err := b.Stat(ctx, prefix+"missing"); !errors.As(err, &notFound) || notFound.Key != prefix+"missing" {
+		t.Errorf("Stat of a missing object returned %v, want a *NotFoundError", err)
+	}
+	if exists, err := b.Exists(ctx, prefix+"missing"); err != nil || exists {
+		t.Errorf("Exists of a missing object = %v, %v, want false", exists, err)
+	}
+}
@@ -0,0 +1,60 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+)
+
+// Storage is the part of the API that works on any object store, so code written against it
+// runs on S3 in production and, e.g., on a local directory in development, see OpenStorage.
+type Storage interface {
+	Upload(ctx context.Context, key string, reader io.Reader, opts ...UploadOption) error
+	Download(ctx context.Context, key string, opts ...DownloadOption) (*Object, error)
+	List(ctx context.Context, prefix string) iter.Seq2[ObjectInfo, error]
+	Delete(ctx context.Context, key string) error
+}
+
+var (
+	_ Storage = (*Bucket)(nil)
+	_ Storage = (*LocalStorage)(nil)
+	_ Storage = (*AzureStorage)(nil)
+)
+
+// OpenStorage opens the storage selected by $STORAGE_BACKEND: for "fs" the directory $STORAGE_DIR,
+// by default ./data/$APP_NAME, see OpenLocal, for "azure" the container $AZURE_STORAGE_CONTAINER, by
+// default $APP_NAME, of the account $AZURE_STORAGE_CONNECTION_STRING, see OpenAzure, and for "s3" or
+// by default the bucket Init would open, configured by opts.
+func OpenStorage(opts ...Option) (Storage, error) {
+	switch backend := os.Getenv("STORAGE_BACKEND"); backend {
+	case "fs":
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			appName := os.Getenv("APP_NAME")
+			if appName == "" {
+				return nil, fmt.Errorf("APP_NAME environment variable is required")
+			}
+			dir = "./data/" + appName
+		}
+		return OpenLocal(dir)
+	case "azure":
+		connectionString := os.Getenv("AZURE_STORAGE_CONNECTION_STRING")
+		if connectionString == "" {
+			return nil, fmt.Errorf("AZURE_STORAGE_CONNECTION_STRING environment variable is required")
+		}
+		containerName := os.Getenv("AZURE_STORAGE_CONTAINER")
+		if containerName == "" {
+			containerName = os.Getenv("APP_NAME")
+		}
+		if containerName == "" {
+			return nil, fmt.Errorf("APP_NAME environment variable is required")
+		}
+		return OpenAzure(connectionString, containerName)
+	case "", "s3":
+		return openDefault(opts)
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q, expected s3, fs or azure", backend)
+	}
+}
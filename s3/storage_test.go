@@ -0,0 +1,95 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/michaldziurowski/one/s3"
+	"github.com/michaldziurowski/one/s3/s3test"
+)
+
+// testStorage runs fn against each implementation of Storage available in the test environment,
+// with keys under prefix. The bucket is only tested when S3_TEST_ENDPOINT is set, see openTestBucket.
+func testStorage(t *testing.T, fn func(t *testing.T, storage s3.Storage, prefix string)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, s3test.New(), "")
+	})
+	t.Run("local", func(t *testing.T) {
+		local, err := s3.OpenLocal(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn(t, local, "")
+	})
+	t.Run("bucket", func(t *testing.T) {
+		b, prefix := openTestBucket(t)
+		fn(t, b, prefix)
+	})
+}
+
+func TestStorage(t *testing.T) {
+	testStorage(t, func(t *testing.T, storage s3.Storage, prefix string) {
+		ctx := context.Background()
+		keys := []string{"docs/a-b.txt", "docs/a/b.txt", "docs/c.txt", "other.txt"}
+		for _, key := range keys {
+			if err := storage.Upload(ctx, prefix+key, strings.NewReader("content of "+key)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		obj, err := storage.Download(ctx, prefix+"docs/c.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(obj)
+		obj.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "content of docs/c.txt" || obj.Size != int64(len(data)) {
+			t.Errorf("downloaded %q of size %d", data, obj.Size)
+		}
+
+		var listed []string
+		for info, err := range storage.List(ctx, prefix+"docs/") {
+			if err != nil {
+				t.Fatal(err)
+			}
+			listed = append(listed, strings.TrimPrefix(info.Key, prefix))
+		}
+		if want := keys[:3]; !slices.Equal(listed, want) {
+			t.Errorf("listed %v, want %v in key order", listed, want)
+		}
+
+		if err := storage.Delete(ctx, prefix+"docs/c.txt"); err != nil {
+			t.Fatal(err)
+		}
+		var notFound *s3.NotFoundError
+		if _, err := storage.Download(ctx, prefix+"docs/c.txt"); !errors.As(err, &notFound) {
+			t.Errorf("download of a deleted object returned %v, want a *NotFoundError", err)
+		}
+		if err := storage.Delete(ctx, prefix+"docs/c.txt"); err != nil {
+			t.Errorf("deleting a missing object returned %v", err)
+		}
+	})
+}
+
+func TestStorageCancelled(t *testing.T) {
+	testStorage(t, func(t *testing.T, storage s3.Storage, prefix string) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		for _, err := range storage.List(ctx, prefix) {
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("listing with a cancelled context returned %v", err)
+			}
+			break
+		}
+		if err := storage.Upload(ctx, prefix+"key", strings.NewReader("x")); !errors.Is(err, context.Canceled) {
+			t.Errorf("upload with a cancelled context returned %v", err)
+		}
+	})
+}
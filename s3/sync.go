@@ -0,0 +1,164 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// SyncOptions configures Sync.
+type SyncOptions struct {
+	// Include and Exclude select the files to sync like for UploadDir. Objects of excluded files
+	// are neither uploaded nor deleted.
+	Include []string
+	Exclude []string
+	// Delete removes objects under the prefix that have no file in the directory.
+	Delete bool
+	// DryRun only reports what would be uploaded and deleted.
+	DryRun bool
+	// Concurrency is the number of files compared and uploaded at once, 4 by default.
+	Concurrency int
+	// Options are applied to every upload. With WithGzip, the stored size never matches the file,
+	// so every file is uploaded. With WithSkipUnchanged, files that only seem changed, e.g. by their
+	// modification time, are not transferred again, though counted as uploaded.
+	Options []UploadOption
+}
+
+// SyncSummary reports what Sync did.
+type SyncSummary struct {
+	Uploaded      int
+	UploadedBytes int64
+	Unchanged     int
+	Deleted       int
+}
+
+// Sync makes the objects under prefix mirror the files in dir and its subdirectories, keyed like
+// by UploadDir, e.g. to deploy static assets. A file is uploaded when its object is missing or
+// differs in size or content. Content is compared by MD5 against the ETag where S3 computes it as
+// such, i.e. for objects uploaded in one part without SSE-KMS, and otherwise by modification time.
+// Objects of the same size as their files are looked up to tell how they are encrypted.
+// On error, the summary reports what was done until then.
+func Sync(ctx context.Context, dir, prefix string, opts SyncOptions) (SyncSummary, error) {
+	return defaultBucket().Sync(ctx, dir, prefix, opts)
+}
+
+// Sync makes the objects under prefix mirror the files in dir, see the package-level Sync.
+func (b *Bucket) Sync(ctx context.Context, dir, prefix string, opts SyncOptions) (SyncSummary, error) {
+	var summary SyncSummary
+
+	files, err := walkFiles(dir, opts.Include, opts.Exclude)
+	if err != nil {
+		return summary, err
+	}
+
+	remote := map[string]ObjectInfo{}
+	for obj, err := range b.List(ctx, prefix) {
+		if err != nil {
+			return summary, err
+		}
+		if rel := strings.TrimPrefix(obj.Key, prefix); includeFile(rel, opts.Include, opts.Exclude) {
+			remote[rel] = obj
+		}
+	}
+
+	var mu sync.Mutex
+	err = forEachParallel(ctx, files, opts.Concurrency, func(ctx context.Context, rel string) error {
+		name := filepath.Join(dir, filepath.FromSlash(rel))
+		obj, exists := remote[rel]
+		changed, size, err := b.fileChanged(ctx, name, obj, exists)
+		if err != nil {
+			return err
+		}
+		if changed && !opts.DryRun {
+			if err := b.UploadFile(ctx, prefix+rel, name, opts.Options...); err != nil {
+				return err
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if changed {
+			summary.Uploaded++
+			summary.UploadedBytes += size
+		} else {
+			summary.Unchanged++
+		}
+		return nil
+	})
+	if err != nil || !opts.Delete {
+		return summary, err
+	}
+
+	for _, rel := range files {
+		delete(remote, rel)
+	}
+	keys := make([]string, 0, len(remote))
+	for _, obj := range remote {
+		keys = append(keys, obj.Key)
+	}
+	if opts.DryRun {
+		summary.Deleted = len(keys)
+		return summary, nil
+	}
+	failed, err := b.DeleteMany(ctx, keys)
+	summary.Deleted = len(keys) - len(failed)
+	if err != nil {
+		return summary, err
+	}
+	if len(failed) > 0 {
+		return summary, fmt.Errorf("failed to delete %d objects under %s: %w", len(failed), prefix, errors.Join(slices.Collect(maps.Values(failed))...))
+	}
+	return summary, nil
+}
+
+// fileChanged reports whether the file name differs from obj, and its size.
+func (b *Bucket) fileChanged(ctx context.Context, name string, obj ObjectInfo, exists bool) (bool, int64, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if !exists || obj.Size != info.Size() {
+		return true, info.Size(), nil
+	}
+
+	// ETags of multipart uploads and directory buckets are not the MD5 of the content.
+	if strings.Contains(obj.ETag, "-") || b.settings.directoryZone != "" {
+		return info.ModTime().After(obj.LastModified), info.Size(), nil
+	}
+	// Nor are those of objects encrypted with KMS or customer keys, which listings do not tell, and
+	// which objects may be whatever the bucket is configured with now.
+	head, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(obj.Key),
+	})
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to stat object: %w", notFound(err, obj.Key))
+	}
+	if sse := head.ServerSideEncryption; sse == types.ServerSideEncryptionAwsKms || sse == types.ServerSideEncryptionAwsKmsDsse || head.SSECustomerAlgorithm != nil {
+		return info.ModTime().After(aws.ToTime(head.LastModified)), info.Size(), nil
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return false, 0, fmt.Errorf("failed to read file: %w", err)
+	}
+	return strings.Trim(aws.ToString(head.ETag), `"`) != hex.EncodeToString(hash.Sum(nil)), info.Size(), nil
+}
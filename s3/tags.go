@@ -0,0 +1,95 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// WithTags tags the uploaded object, e.g. for lifecycle rules or cost allocation.
+// Repeated options add to the tags. An object can have up to 10 tags.
+func WithTags(tags map[string]string) UploadOption {
+	return func(o *uploadOptions) {
+		if o.tags == nil {
+			o.tags = map[string]string{}
+		}
+		maps.Copy(o.tags, tags)
+	}
+}
+
+// SetTags replaces the tags of the object stored under key. A missing object is reported
+// as a *NotFoundError.
+func SetTags(ctx context.Context, key string, tags map[string]string) error {
+	return defaultBucket().SetTags(ctx, key, tags)
+}
+
+// SetTags replaces the tags of the object stored under key, see the package-level SetTags.
+func (b *Bucket) SetTags(ctx context.Context, key string, tags map[string]string) (err error) {
+	ctx, op := b.startOperation(ctx, "SetTags", attribute.String("aws.s3.key", key))
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+
+	tagSet := make([]types.Tag, 0, len(tags))
+	for k, v := range tags {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+
+	_, err = b.client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(b.name),
+		Key:     aws.String(key),
+		Tagging: &types.Tagging{TagSet: tagSet},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set tags: %w", notFound(err, key))
+	}
+
+	return nil
+}
+
+// GetTags returns the tags of the object stored under key. A missing object is reported
+// as a *NotFoundError.
+func GetTags(ctx context.Context, key string) (map[string]string, error) {
+	return defaultBucket().GetTags(ctx, key)
+}
+
+// GetTags returns the tags of the object stored under key, see the package-level GetTags.
+func (b *Bucket) GetTags(ctx context.Context, key string) (_ map[string]string, err error) {
+	ctx, op := b.startOperation(ctx, "GetTags", attribute.String("aws.s3.key", key))
+	defer func() { op.end(err) }()
+
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	out, err := b.client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(b.name),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", notFound(err, key))
+	}
+
+	tags := make(map[string]string, len(out.TagSet))
+	for _, tag := range out.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// encodeTags returns tags in the URL query format of the x-amz-tagging header.
+func encodeTags(tags map[string]string) string {
+	values := url.Values{}
+	for k, v := range tags {
+		values.Set(k, v)
+	}
+	return values.Encode()
+}
@@ -0,0 +1,50 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Timeouts are the longest operations may take when the caller's context has no deadline, see
+// WithTimeouts. Zero means no limit.
+type Timeouts struct {
+	// Upload limits Upload and the functions built on it, such as UploadFile, with all parts.
+	Upload time.Duration
+	// Download limits Download, until the Object is closed, DownloadIfModified and DownloadToFile.
+	Download time.Duration
+	// List limits ListPage and each page of List, so long listings are not cut short.
+	List time.Duration
+}
+
+// WithTimeouts sets default timeouts per kind of operation, which apply when the ctx passed in has
+// no deadline, so a stuck connection cannot hang a worker that passes context.Background():
+//
+//	s3.Init(s3.WithTimeouts(s3.Timeouts{Upload: 10 * time.Minute, Download: 10 * time.Minute, List: time.Minute}))
+//
+// Operations that run out of time fail with an error matching context.DeadlineExceeded. A deadline
+// of ctx takes precedence, even if it is later.
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(c *config) {
+		c.timeouts = timeouts
+	}
+}
+
+// withTimeout returns ctx limited to timeout, unless ctx has a deadline or timeout is zero.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// cancelOnClose releases the context of a download once its content is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelOnClose) Close() error {
+	defer r.cancel()
+	return r.ReadCloser.Close()
+}
@@ -0,0 +1,57 @@
+package s3
+
+import (
+	"context"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the spans of this package.
+const tracerName = "github.com/michaldziurowski/one/s3"
+
+// WithTracerProvider records the operations reported to WithOperationHook as OpenTelemetry spans
+// of tp, named like "s3.Upload" and children of the span in their ctx, so storage latency shows up
+// in request traces. Spans carry the bucket, the key or prefix, and the bytes and parts transferred
+// or objects listed. Defaults to the global provider, which records nothing until the
+// application sets one with otel.SetTracerProvider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+// startSpan starts the span of the operation op on b.
+func (b *Bucket) startSpan(ctx context.Context, op string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tp := b.settings.tracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(tracerName).Start(ctx, "s3."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append([]attribute.KeyValue{attribute.String("aws.s3.bucket", b.name)}, attrs...)...))
+}
+
+// endSpan marks span as failed if err is not nil and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
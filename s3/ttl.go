@@ -0,0 +1,118 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const (
+	// ttlPrefix is the prefix under which UploadTemp stores objects, followed by their lifetime in days.
+	ttlPrefix = "ttl/"
+	// ttlRuleAttempts is how often ensureTTLRule adds its rule, as the lifecycle configuration is
+	// replaced as a whole and another process may replace it at the same time.
+	ttlRuleAttempts = 5
+	// ttlRuleSettle is the longest time ensureTTLRule waits before checking that its rule was kept.
+	ttlRuleSettle = time.Second
+)
+
+// UploadTemp uploads the content of reader like Upload for an object that S3 deletes once ttl has
+// passed, e.g. exports offered for download for a week. As S3 expires objects once a day, ttl is
+// rounded up to whole days and objects can outlive it by a day or two. The object is stored under
+// ttl/<days>d/key, where a lifecycle rule added on first use expires it; the full key is returned.
+// Adding the rule takes permission to get and put the lifecycle configuration of the bucket and up
+// to a few seconds, as it is checked to survive other processes changing the configuration.
+func UploadTemp(ctx context.Context, key string, reader io.Reader, ttl time.Duration, opts ...UploadOption) (string, error) {
+	return defaultBucket().UploadTemp(ctx, key, reader, ttl, opts...)
+}
+
+// UploadTemp uploads an object that expires once ttl has passed, see the package-level UploadTemp.
+func (b *Bucket) UploadTemp(ctx context.Context, key string, reader io.Reader, ttl time.Duration, opts ...UploadOption) (string, error) {
+	if err := b.checkOpen(); err != nil {
+		return "", err
+	}
+	key, err := b.tempKey(ctx, key, ttl)
+	if err != nil {
+		return "", err
+	}
+	if err := b.Upload(ctx, key, reader, opts...); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// tempKey returns the key under which UploadTemp stores an object for ttl, after making sure
+// the bucket has the lifecycle rule expiring it.
+func (b *Bucket) tempKey(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		return "", fmt.Errorf("ttl must be positive, got %s", ttl)
+	}
+	if b.settings.directoryZone != "" {
+		return "", fmt.Errorf("directory buckets do not support lifecycle rules for temporary objects")
+	}
+
+	days := int32((ttl + 24*time.Hour - 1) / (24 * time.Hour))
+	if err := b.ensureTTLRule(ctx, days); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s%dd/%s", ttlPrefix, days, key), nil
+}
+
+// ensureTTLRule adds the lifecycle rule expiring objects stored for days, unless the bucket has it.
+// After adding it, the configuration is read again a random while later and the rule added again
+// until it is kept, as processes adding other rules at the same time replace each other's.
+func (b *Bucket) ensureTTLRule(ctx context.Context, days int32) error {
+	b.ttlRulesMu.Lock()
+	defer b.ttlRulesMu.Unlock()
+	if b.ttlRules[days] {
+		return nil
+	}
+
+	id := fmt.Sprintf("ttl:%dd", days)
+	for attempt := 0; ; attempt++ {
+		rules, err := b.currentLifecycleRules(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to add expiry rule: %w", err)
+		}
+		if slices.ContainsFunc(rules, func(r types.LifecycleRule) bool { return aws.ToString(r.ID) == id }) {
+			break
+		}
+		if attempt == ttlRuleAttempts {
+			return fmt.Errorf("failed to add expiry rule: %s was replaced by concurrent lifecycle changes %d times", id, attempt)
+		}
+
+		rules = append(rules, types.LifecycleRule{
+			ID:         aws.String(id),
+			Status:     types.ExpirationStatusEnabled,
+			Filter:     &types.LifecycleRuleFilter{Prefix: aws.String(fmt.Sprintf("%s%dd/", ttlPrefix, days))},
+			Expiration: &types.LifecycleExpiration{Days: aws.Int32(days)},
+		})
+		if err := b.putLifecycleRules(ctx, rules); err != nil {
+			return fmt.Errorf("failed to add expiry rule: %w", err)
+		}
+
+		select {
+		case <-time.After(rand.N(ttlRuleSettle)):
+		case <-ctx.Done():
+			return fmt.Errorf("failed to add expiry rule: %w", ctx.Err())
+		}
+	}
+
+	if b.ttlRules == nil {
+		b.ttlRules = map[int32]bool{}
+	}
+	b.ttlRules[days] = true
+	return nil
+}
+
+// isTTLRule reports whether r is a rule added by UploadTemp.
+func isTTLRule(r types.LifecycleRule) bool {
+	return strings.HasPrefix(aws.ToString(r.ID), "ttl:")
+}
@@ -0,0 +1,246 @@
+package s3
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// sniffLen is the number of bytes http.DetectContentType considers.
+const sniffLen = 512
+
+// UploadOption configures Upload.
+type UploadOption func(*uploadOptions)
+
+type uploadOptions struct {
+	contentType string
+	metadata    map[string]string
+	tags        map[string]string
+	checksum    ChecksumAlgorithm
+	progress    func(bytesSent, total int64)
+	gzip        bool
+	publicRead  bool
+	ifNotExists bool
+	ifMatch     string
+	skipSame    bool
+
+	idempotencyKey    string
+	encryptionContext map[string]string
+
+	retentionMode RetentionMode
+	retainUntil   time.Time
+	legalHold     bool
+}
+
+// WithContentType sets the Content-Type of the uploaded object, which browsers use to render
+// downloads. Without it, the type is detected from the extension of the key, then from the
+// first 512 bytes of the content.
+func WithContentType(contentType string) UploadOption {
+	return func(o *uploadOptions) {
+		o.contentType = contentType
+	}
+}
+
+// WithMetadata stores metadata with the uploaded object as x-amz-meta-* headers, e.g. its origin
+// or owner. It is returned by Stat and Download with lowercase names. Repeated options add to
+// the metadata. Metadata is limited to 2KB in total.
+func WithMetadata(metadata map[string]string) UploadOption {
+	return func(o *uploadOptions) {
+		if o.metadata == nil {
+			o.metadata = map[string]string{}
+		}
+		maps.Copy(o.metadata, metadata)
+	}
+}
+
+// WithProgress calls fn as the content is read for uploading, with the bytes read so far and the
+// total size, or -1 if the reader does not tell its size, so long uploads can show progress.
+// Parts are read ahead of sending them, so bytesSent leads the network by up to WithConcurrency parts.
+// fn is called from the uploading goroutine and should return quickly. The content is then read one
+// part at a time, also from an io.ReaderAt, see UploadReaderAt.
+func WithProgress(fn func(bytesSent, total int64)) UploadOption {
+	return func(o *uploadOptions) {
+		o.progress = fn
+	}
+}
+
+// WithIfNotExists makes the upload fail with a *PreconditionFailedError if an object is already
+// stored under key, so only one of several writers creates it, e.g. a lock or a create-once state
+// file. The check is done by S3 when the upload completes, which makes it atomic.
+// It only has effect on a Bucket.
+func WithIfNotExists() UploadOption {
+	return func(o *uploadOptions) {
+		o.ifNotExists = true
+	}
+}
+
+// WithIfMatch makes the upload fail with a *PreconditionFailedError unless the object stored under
+// key has the ETag etag, i.e. it was not changed since it was read, for optimistic concurrency:
+//
+//	obj, err := s3.Download(ctx, "state.json")
+//	// ... read and modify the state
+//	err = s3.Upload(ctx, "state.json", updated, s3.WithIfMatch(obj.ETag))
+//
+// On a *PreconditionFailedError, read the object again and retry. It only has effect on a Bucket.
+func WithIfMatch(etag string) UploadOption {
+	return func(o *uploadOptions) {
+		o.ifMatch = etag
+	}
+}
+
+// WithSkipUnchanged skips the upload if the object stored under key already has the same content,
+// e.g. in sync jobs that re-upload mostly unchanged files. The SHA-256 of the content is stored
+// with the object as the metadata "sha256" and compared against that of the stored object, which
+// costs reading the content twice, see PutCAS, and one HEAD request. Objects stored without it are
+// uploaded. It only has effect on a Bucket.
+func WithSkipUnchanged() UploadOption {
+	return func(o *uploadOptions) {
+		o.skipSame = true
+	}
+}
+
+// UploadFile uploads the file filename under key. Its content type is detected from the extension
+// of key, then of filename, then from its content. The file is read in parallel parts, so large files
+// upload as fast as with Upload and without buffering.
+func UploadFile(ctx context.Context, key, filename string, opts ...UploadOption) error {
+	return defaultBucket().UploadFile(ctx, key, filename, opts...)
+}
+
+// UploadFile uploads the file filename under key, see the package-level UploadFile.
+func (b *Bucket) UploadFile(ctx context.Context, key, filename string, opts ...UploadOption) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	if mime.TypeByExtension(path.Ext(key)) == "" {
+		if contentType := mime.TypeByExtension(filepath.Ext(filename)); contentType != "" {
+			opts = append([]UploadOption{WithContentType(contentType)}, opts...)
+		}
+	}
+	return b.Upload(ctx, key, file, opts...)
+}
+
+// UploadReaderAt uploads the size bytes of r under key like Upload. The parts of large objects are
+// read from r by the goroutines uploading them, as many at a time, while the content of other readers
+// is read one part at a time ahead of them, which limits throughput on fast disks and arrays. Files
+// passed to Upload or UploadFile are read in parallel too; UploadReaderAt is for other sources such
+// as a memory-mapped file or a region of a larger file. WithProgress and WithGzip read one part at a
+// time.
+func UploadReaderAt(ctx context.Context, key string, r io.ReaderAt, size int64, opts ...UploadOption) error {
+	return defaultBucket().UploadReaderAt(ctx, key, r, size, opts...)
+}
+
+// UploadReaderAt uploads the size bytes of r under key, see the package-level UploadReaderAt.
+func (b *Bucket) UploadReaderAt(ctx context.Context, key string, r io.ReaderAt, size int64, opts ...UploadOption) error {
+	if size < 0 {
+		return fmt.Errorf("size must not be negative, got %d", size)
+	}
+	// The uploader reads parts of io.ReaderAt and io.Seeker bodies at their offsets in parallel.
+	return b.Upload(ctx, key, io.NewSectionReader(r, 0, size), opts...)
+}
+
+// applyUploadOptions sets the fields of input configured by opts.
+func applyUploadOptions(input *s3.PutObjectInput, opts []UploadOption) error {
+	var o uploadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// The size is taken before sniffing, which may buffer the reader and hide it.
+	total := int64(-1)
+	if o.progress != nil {
+		total = readerSize(input.Body)
+	}
+
+	if o.contentType == "" {
+		o.contentType = mime.TypeByExtension(path.Ext(aws.ToString(input.Key)))
+	}
+	if o.contentType == "" {
+		var err error
+		o.contentType, input.Body, err = sniffContentType(input.Body)
+		if err != nil {
+			return err
+		}
+	}
+	input.ContentType = aws.String(o.contentType)
+	input.Metadata = o.metadata
+	if o.encryptionContext != nil {
+		if err := applyEncryptionContext(input, o.encryptionContext); err != nil {
+			return err
+		}
+	}
+	input.ChecksumAlgorithm = types.ChecksumAlgorithm(o.checksum)
+	if len(o.tags) > 0 {
+		input.Tagging = aws.String(encodeTags(o.tags))
+	}
+	if o.publicRead {
+		input.ACL = types.ObjectCannedACLPublicRead
+	}
+	if o.ifNotExists {
+		input.IfNoneMatch = aws.String("*")
+	}
+	if o.ifMatch != "" {
+		input.IfMatch = aws.String(o.ifMatch)
+	}
+	if o.retentionMode != "" {
+		input.ObjectLockMode = types.ObjectLockMode(o.retentionMode)
+		input.ObjectLockRetainUntilDate = aws.Time(o.retainUntil)
+	}
+	if o.legalHold {
+		input.ObjectLockLegalHoldStatus = types.ObjectLockLegalHoldStatusOn
+	}
+	// S3 requires a checksum of uploads that lock the object.
+	if (o.retentionMode != "" || o.legalHold) && input.ChecksumAlgorithm == "" {
+		input.ChecksumAlgorithm = types.ChecksumAlgorithmCrc32
+	}
+	if o.progress != nil {
+		input.Body = &progressReader{Reader: input.Body, fn: o.progress, total: total}
+	}
+	if o.gzip {
+		input.ContentEncoding = aws.String("gzip")
+		input.Body = newCompressingReader(input.Body)
+	}
+	return nil
+}
+
+// sniffContentType detects the content type of the content of r and returns a reader of the
+// whole content. A seekable r is rewound rather than buffered, so the uploader can still read
+// its parts in parallel.
+func sniffContentType(r io.Reader) (string, io.Reader, error) {
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to detect content type: %w", err)
+		}
+		head := make([]byte, sniffLen)
+		n, err := io.ReadFull(seeker, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return "", nil, fmt.Errorf("failed to detect content type: %w", err)
+		}
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return "", nil, fmt.Errorf("failed to detect content type: %w", err)
+		}
+		return http.DetectContentType(head[:n]), r, nil
+	}
+
+	buffered := bufio.NewReaderSize(r, sniffLen)
+	head, err := buffered.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return "", nil, fmt.Errorf("failed to detect content type: %w", err)
+	}
+	return http.DetectContentType(head), buffered, nil
+}
@@ -0,0 +1,39 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/michaldziurowski/one/s3"
+)
+
+func TestConditionalUpload(t *testing.T) {
+	ctx := context.Background()
+	b, prefix := openTestBucket(t)
+	key := prefix + "state.json"
+
+	if err := b.Upload(ctx, key, strings.NewReader("1"), s3.WithIfNotExists()); err != nil {
+		t.Fatalf("first create-once upload: %v", err)
+	}
+	var preconditionFailed *s3.PreconditionFailedError
+	if err := b.Upload(ctx, key, strings.NewReader("2"), s3.WithIfNotExists()); !errors.As(err, &preconditionFailed) {
+		t.Errorf("second create-once upload returned %v, want a *PreconditionFailedError", err)
+	}
+
+	info, err := b.Stat(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Upload(ctx, key, strings.NewReader("3"), s3.WithIfMatch(info.ETag)); err != nil {
+		t.Fatalf("upload with the current ETag: %v", err)
+	}
+	// The ETag read before the last upload is stale now.
+	if err := b.Upload(ctx, key, strings.NewReader("4"), s3.WithIfMatch(info.ETag)); !errors.As(err, &preconditionFailed) {
+		t.Errorf("upload with a stale ETag returned %v, want a *PreconditionFailedError", err)
+	}
+	if got := content(t, b, key); got != "3" {
+		t.Errorf("object holds %q, want the last successful upload", got)
+	}
+}
@@ -0,0 +1,111 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// usageProgressInterval is the number of objects Usage counts between progress reports.
+const usageProgressInterval = 1000
+
+// PrefixUsage is the storage used by the objects under a prefix, see Usage.
+type PrefixUsage struct {
+	Objects int64
+	Bytes   int64
+}
+
+// UsageOptions configures Usage.
+type UsageOptions struct {
+	// Concurrency is the number of top-level prefixes listed at once, 4 by default.
+	Concurrency int
+	// Progress is called every 1000 objects and once a top-level prefix is done, with the number
+	// of objects and bytes counted so far. It is called by one goroutine at a time and should
+	// return quickly.
+	Progress func(objects, bytes int64)
+}
+
+// Usage returns the number of objects and bytes stored under each top-level prefix below prefix,
+// keyed by that prefix, e.g. for per-tenant storage accounting with keys like tenants/<id>/...:
+//
+//	usage, err := s3.Usage(ctx, "tenants/", s3.UsageOptions{Concurrency: 16})
+//	// usage["tenants/acme/"] is the storage used by acme
+//
+// Objects directly below prefix are counted under prefix itself. Every object is listed, which
+// takes one request per 1000 objects; top-level prefixes are listed in parallel. Only current
+// versions are counted, not noncurrent ones or incomplete multipart uploads.
+func Usage(ctx context.Context, prefix string, opts UsageOptions) (map[string]PrefixUsage, error) {
+	return defaultBucket().Usage(ctx, prefix, opts)
+}
+
+// Usage returns the storage used under each top-level prefix below prefix, see the package-level Usage.
+func (b *Bucket) Usage(ctx context.Context, prefix string, opts UsageOptions) (map[string]PrefixUsage, error) {
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	var mu sync.Mutex
+	usage := map[string]PrefixUsage{}
+	var total PrefixUsage
+	add := func(key string, counted PrefixUsage) {
+		mu.Lock()
+		defer mu.Unlock()
+		u := usage[key]
+		u.Objects += counted.Objects
+		u.Bytes += counted.Bytes
+		usage[key] = u
+		total.Objects += counted.Objects
+		total.Bytes += counted.Bytes
+		if opts.Progress != nil {
+			opts.Progress(total.Objects, total.Bytes)
+		}
+	}
+
+	var prefixes []string
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(b.name),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		var counted PrefixUsage
+		for _, o := range page.Contents {
+			counted.Objects++
+			counted.Bytes += aws.ToInt64(o.Size)
+		}
+		if counted.Objects > 0 {
+			add(prefix, counted)
+		}
+		for _, p := range page.CommonPrefixes {
+			prefixes = append(prefixes, aws.ToString(p.Prefix))
+		}
+	}
+
+	err := forEachParallel(ctx, prefixes, opts.Concurrency, func(ctx context.Context, p string) error {
+		var counted PrefixUsage
+		for obj, err := range b.List(ctx, p) {
+			if err != nil {
+				return err
+			}
+			counted.Objects++
+			counted.Bytes += obj.Size
+			if counted.Objects%usageProgressInterval == 0 {
+				add(p, counted)
+				counted = PrefixUsage{}
+			}
+		}
+		add(p, counted)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
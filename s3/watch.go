@@ -0,0 +1,104 @@
+package s3
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+)
+
+// watchWindow is how far behind the newest object Watch still looks for new ones. Objects appear
+// with a LastModified in the past when their upload took time, for multipart uploads the time it
+// started.
+const watchWindow = time.Hour
+
+// Watch polls the objects whose key starts with prefix every interval and returns the ones that
+// appeared since the previous poll, oldest first, for ingestion pipelines without event
+// notifications:
+//
+//	for obj, err := range s3.Watch(ctx, "incoming/", time.Minute) {
+//		if err != nil {
+//			log.Print(err)
+//			continue
+//		}
+//		process(obj.Key)
+//	}
+//
+// Objects present at the first poll are not returned. Objects are tracked by a high-water mark of
+// their LastModified, so one overwritten with new content is returned again, while one that
+// appears more than an hour older than the newest object seen is missed. Every poll lists the whole
+// prefix, so keep processed objects elsewhere. The error of a failed poll is returned, and polling
+// continues if the loop does. It ends when ctx is done.
+func Watch(ctx context.Context, prefix string, interval time.Duration) iter.Seq2[ObjectInfo, error] {
+	return defaultBucket().Watch(ctx, prefix, interval)
+}
+
+// Watch polls the objects under prefix and returns new ones, see the package-level Watch.
+func (b *Bucket) Watch(ctx context.Context, prefix string, interval time.Duration) iter.Seq2[ObjectInfo, error] {
+	return func(yield func(ObjectInfo, error) bool) {
+		if interval <= 0 {
+			yield(ObjectInfo{}, fmt.Errorf("interval must be positive, got %v", interval))
+			return
+		}
+
+		var mark time.Time
+		// seen holds the objects within watchWindow of mark by key, with their LastModified.
+		var seen map[string]time.Time
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			var fresh []ObjectInfo
+			current := map[string]time.Time{}
+			newMark := mark
+			var err error
+			for obj, listErr := range b.List(ctx, prefix) {
+				if listErr != nil {
+					err = listErr
+					break
+				}
+				if !obj.LastModified.After(mark.Add(-watchWindow)) {
+					continue
+				}
+				current[obj.Key] = obj.LastModified
+				if last, ok := seen[obj.Key]; seen != nil && (!ok || obj.LastModified.After(last)) {
+					fresh = append(fresh, obj)
+				}
+				if obj.LastModified.After(newMark) {
+					newMark = obj.LastModified
+				}
+			}
+
+			if err != nil {
+				if ctx.Err() != nil || !yield(ObjectInfo{}, err) {
+					return
+				}
+			} else {
+				slices.SortFunc(fresh, func(a, b ObjectInfo) int {
+					return cmp.Or(a.LastModified.Compare(b.LastModified), cmp.Compare(a.Key, b.Key))
+				})
+				for _, obj := range fresh {
+					if !yield(obj, nil) {
+						return
+					}
+				}
+
+				mark = newMark
+				seen = map[string]time.Time{}
+				for key, lastModified := range current {
+					if lastModified.After(mark.Add(-watchWindow)) {
+						seen[key] = lastModified
+					}
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}